@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"expvar"
 	"net/http"
 	"os"
 	"os/signal"
@@ -12,30 +13,44 @@ import (
 	"github.com/go-kit/kit/log/level"
 	"github.com/kelseyhightower/envconfig"
 	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/expvarhandler"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
 
+	"github.com/mts-test-task/internal/cachekey"
+	"github.com/mts-test-task/internal/cachepolicy"
 	"github.com/mts-test-task/internal/converter"
+	"github.com/mts-test-task/internal/diff"
+	"github.com/mts-test-task/internal/jobs"
+	"github.com/mts-test-task/internal/netguard"
+	"github.com/mts-test-task/internal/robots"
+	"github.com/mts-test-task/internal/siterules"
 	"github.com/mts-test-task/internal/sites"
 	"github.com/mts-test-task/internal/sitesdataservice"
 	"github.com/mts-test-task/internal/sitesdataservice/httpserver"
 	"github.com/mts-test-task/internal/storages/mongodb/builder"
 	"github.com/mts-test-task/internal/storages/mongodb/wrapper"
+	"github.com/mts-test-task/internal/urlcanon"
 	"github.com/mts-test-task/internal/validator"
+	"github.com/mts-test-task/internal/webhook"
+	"github.com/mts-test-task/internal/workerpool"
 	"github.com/mts-test-task/pkg/sitesdataservice/httperror"
 )
 
 // Название полей в mongodb
 const (
-	createDateNameField = "create_date"
-	urlsNameField       = "url"
-	dataNameFiled       = "data"
-)
-
-// Направление сортировки
-const (
-	sortAsc = 1
+	createDateNameField   = "create_date"
+	urlsNameField         = "url"
+	keyNameField          = "key"
+	finalKeyNameField     = "final_key"
+	dataNameFiled         = "data"
+	binaryDataNameField   = "binary_data"
+	metaNameField         = "meta"
+	etagNameField         = "etag"
+	lastModifiedNameField = "last_modified"
+	varyKeyNameField      = "vary_key"
+	contentHashNameField  = "content_hash"
 )
 
 type configuration struct {
@@ -44,6 +59,8 @@ type configuration struct {
 	MaxRequestBodySize   int           `envconfig:"MAX_REQUEST_BODY_SIZE" default:"10485760"` // 10 MB
 	MaxSimultaneousConns int           `envconfig:"MAX_SIM_CONNS" default:"100"`
 	ServerTimeout        time.Duration `envconfig:"SERVER_TIMEOUT" default:"10000ms"`
+	// Адрес служебного сервера с метриками и ручками вебхуков, недоступного снаружи. Пустое значение отключает сервер
+	AdminAddr string `envconfig:"ADMIN_ADDR" default:"localhost:8081"`
 
 	// Отображение логов успешных запросов
 	Debug bool `envconfig:"DEBUG" default:"true"`
@@ -51,16 +68,108 @@ type configuration struct {
 	// Максимальное число урлов для обработки
 	MaxURLsCount int `envconfig:"MAX_URLS_COUNT" default:"20"`
 
-	// Таймаут запроса к сайтам
-	SitesClientTimeout time.Duration `envconfig:"SITES_CLIENT_TIMEOUT" default:"500ms"`
+	// Настройки асинхронных заданий
+	MaxJobURLsCount       int           `envconfig:"MAX_JOB_URLS_COUNT" default:"10000"`
+	JobsWorkers           int           `envconfig:"JOBS_WORKERS" default:"4"`
+	JobsQueueSize         int           `envconfig:"JOBS_QUEUE_SIZE" default:"100"`
+	JobsPollInterval      time.Duration `envconfig:"JOBS_POLL_INTERVAL" default:"5s"`
+	JobResultsPageLimit   int           `envconfig:"JOB_RESULTS_PAGE_LIMIT" default:"100"`
+	JobResultsMaxPageSize int           `envconfig:"JOB_RESULTS_MAX_PAGE_SIZE" default:"1000"`
+
+	// Размер страницы истории урла по умолчанию и максимальный
+	SnapshotsPageLimit   int `envconfig:"SNAPSHOTS_PAGE_LIMIT" default:"100"`
+	SnapshotsMaxPageSize int `envconfig:"SNAPSHOTS_MAX_PAGE_SIZE" default:"1000"`
+	// Сравнение снимков: максимальный размер данных снимка, число строк контекста и предел числа
+	// измененных строк, после которого различающаяся часть заменяется целиком
+	SnapshotsDiffMaxSize      int `envconfig:"SNAPSHOTS_DIFF_MAX_SIZE" default:"1048576"`
+	SnapshotsDiffContextLines int `envconfig:"SNAPSHOTS_DIFF_CONTEXT_LINES" default:"3"`
+	SnapshotsDiffMaxEdits     int `envconfig:"SNAPSHOTS_DIFF_MAX_EDITS" default:"2000"`
+
+	// Настройки вебхуков. Без секрета обратные вызовы отключены
+	WebhookSecret      string        `envconfig:"WEBHOOK_SECRET" default:""`
+	WebhookTimeout     time.Duration `envconfig:"WEBHOOK_TIMEOUT" default:"5s"`
+	WebhookMaxAttempts int           `envconfig:"WEBHOOK_MAX_ATTEMPTS" default:"5"`
+	WebhookBaseBackoff time.Duration `envconfig:"WEBHOOK_BASE_BACKOFF" default:"1s"`
+	WebhookMaxBackoff  time.Duration `envconfig:"WEBHOOK_MAX_BACKOFF" default:"30s"`
+	// Хосты получателей вебхуков во внутренней сети, адреса которых разрешены
+	WebhookAllowedHosts []string `envconfig:"WEBHOOK_ALLOWED_HOSTS" default:""`
+	// Адрес сервиса для получателей вебхуков, из него строится ссылка на результаты задания
+	PublicURL string `envconfig:"PUBLIC_URL" default:""`
+
+	// Таймаут запроса к сайтам по умолчанию и максимальный таймаут, который можно задать в запросе
+	SitesClientTimeout    time.Duration `envconfig:"SITES_CLIENT_TIMEOUT" default:"500ms"`
+	SitesClientMaxTimeout time.Duration `envconfig:"SITES_CLIENT_MAX_TIMEOUT" default:"5000ms"`
+	// Политика редиректов по умолчанию: follow, none или same_host
+	SitesClientRedirectPolicy string `envconfig:"SITES_CLIENT_REDIRECT_POLICY" default:"follow"`
+	SitesClientMaxRedirects   int    `envconfig:"SITES_CLIENT_MAX_REDIRECTS" default:"10"`
+	// Максимальный размер ответа сайта после распаковки и поведение при его превышении: fail или truncate
+	SitesClientMaxBodySize   int64  `envconfig:"SITES_CLIENT_MAX_BODY_SIZE" default:"10485760"` // 10 MB
+	SitesClientBodyLimitMode string `envconfig:"SITES_CLIENT_BODY_LIMIT_MODE" default:"fail"`
+	// Двоичные ответы сайтов по умолчанию: allow - возвращать в base64, reject - считать ошибкой
+	SitesClientBinaryContent string `envconfig:"SITES_CLIENT_BINARY_CONTENT" default:"allow"`
+	// Политика повторов запросов к сайтам. Классы ошибок: timeout, connection_reset, connection_refused, dns, eof
+	SitesClientRetryMaxAttempts  int           `envconfig:"SITES_CLIENT_RETRY_MAX_ATTEMPTS" default:"3"`
+	SitesClientRetryBaseBackoff  time.Duration `envconfig:"SITES_CLIENT_RETRY_BASE_BACKOFF" default:"50ms"`
+	SitesClientRetryMaxBackoff   time.Duration `envconfig:"SITES_CLIENT_RETRY_MAX_BACKOFF" default:"1s"`
+	SitesClientRetryStatusCodes  []int         `envconfig:"SITES_CLIENT_RETRY_STATUS_CODES" default:"429,502,503,504"`
+	SitesClientRetryErrorClasses []string      `envconfig:"SITES_CLIENT_RETRY_ERROR_CLASSES" default:"timeout,connection_reset,connection_refused,eof"`
+	// Методы, запросы которых повторяются автоматически. POST и PATCH повторяются только по правилу домена
+	SitesClientRetryMethods []string `envconfig:"SITES_CLIENT_RETRY_METHODS" default:"GET,HEAD,OPTIONS"`
+	// Ограничения запросов к одному хосту, общие для всех входящих запросов: число одновременных запросов
+	// и минимальная пауза между ними. Переопределения для хостов задаются в виде host:value через запятую
+	SitesClientHostMaxConcurrent          int                      `envconfig:"SITES_CLIENT_HOST_MAX_CONCURRENT" default:"4"`
+	SitesClientHostMinDelay               time.Duration            `envconfig:"SITES_CLIENT_HOST_MIN_DELAY" default:"50ms"`
+	SitesClientHostMaxConcurrentOverrides map[string]int           `envconfig:"SITES_CLIENT_HOST_MAX_CONCURRENT_OVERRIDES" default:""`
+	SitesClientHostMinDelayOverrides      map[string]time.Duration `envconfig:"SITES_CLIENT_HOST_MIN_DELAY_OVERRIDES" default:""`
+	// Срок ожидания очереди хоста, не входит в таймаут урла. Не дождавшийся очереди урл получает ошибку 503
+	SitesClientHostMaxWait time.Duration `envconfig:"SITES_CLIENT_HOST_MAX_WAIT" default:"5s"`
+	// Общий пул воркеров для запросов к сайтам и размер его очереди. Запрос, урлы которого не помещаются
+	// в очередь, получает ошибку 503
+	FetchPoolWorkers   int `envconfig:"FETCH_POOL_WORKERS" default:"100"`
+	FetchPoolQueueSize int `envconfig:"FETCH_POOL_QUEUE_SIZE" default:"1000"`
+	// User-Agent запросов к сайтам, для него же применяются правила robots.txt
+	SitesClientUserAgent string `envconfig:"SITES_CLIENT_USER_AGENT" default:"sites-data/1.0"`
+	// Время жизни кэша robots.txt и его недоступности, таймаут запроса и максимальный учитываемый crawl-delay.
+	// Для своих хостов robots.txt не проверяется
+	RobotsCacheTTL      time.Duration `envconfig:"ROBOTS_CACHE_TTL" default:"24h"`
+	RobotsErrorTTL      time.Duration `envconfig:"ROBOTS_ERROR_TTL" default:"1m"`
+	RobotsTimeout       time.Duration `envconfig:"ROBOTS_TIMEOUT" default:"2s"`
+	RobotsMaxCrawlDelay time.Duration `envconfig:"ROBOTS_MAX_CRAWL_DELAY" default:"10s"`
+	RobotsOwnedHosts    []string      `envconfig:"ROBOTS_OWNED_HOSTS" default:""`
+	// Заголовки ответа сайта, которые возвращаются в метаданных
+	SitesClientResponseHeaders []string `envconfig:"SITES_CLIENT_RESPONSE_HEADERS" default:"Cache-Control,ETag,Last-Modified,Location,Server"`
+
+	// Возраст данных в кэше по умолчанию
+	SitesDataCacheMaxAge time.Duration `envconfig:"SITES_DATA_CACHE_MAX_AGE" default:"1m"`
+	// Нижняя и верхняя граница срока свежести из заголовков Cache-Control и Expires. Без этих заголовков
+	// действует SITES_DATA_CACHE_MAX_AGE
+	SitesCacheMinTTL time.Duration `envconfig:"SITES_CACHE_MIN_TTL" default:"0s"`
+	SitesCacheMaxTTL time.Duration `envconfig:"SITES_CACHE_MAX_TTL" default:"24h"`
+	// JSON файл с правилами доменов: срок кэширования, таймаут, число попыток, типы содержимого и заголовки
+	SitesRulesFile string `envconfig:"SITES_RULES_FILE" default:""`
+	// Срок, в течение которого устаревшие данные с ETag или Last-Modified проверяются условным запросом
+	SitesDataRevalidateWindow time.Duration `envconfig:"SITES_DATA_REVALIDATE_WINDOW" default:"24h"`
+	// Сколько времени после окончания срока свежести данные из кэша отдаются, если сайт не ответил,
+	// и отдаются сразу с обновлением в фоне. 0 отключает соответствующий режим
+	SitesDataStaleIfError         time.Duration `envconfig:"SITES_DATA_STALE_IF_ERROR" default:"0s"`
+	SitesDataStaleWhileRevalidate time.Duration `envconfig:"SITES_DATA_STALE_WHILE_REVALIDATE" default:"0s"`
+	// Заголовки запроса к сайту, которые входят в ключ кэша
+	SitesCacheKeyHeaders []string `envconfig:"SITES_CACHE_KEY_HEADERS" default:"Accept,Accept-Language,Authorization,Content-Type"`
+	// Параметры отслеживания, которые не входят в ключ кэша. Шаблон с * в конце задает префикс
+	SitesCacheTrackingParams []string `envconfig:"SITES_CACHE_TRACKING_PARAMS" default:"utm_*,gclid,fbclid,yclid,_openstat"`
 
 	// Настройки mongodb
-	SitesDataMongoCollection string        `envconfig:"SITES_DATA_MONGO_COLLECTION" default:"sites"`
-	SitesDataMongoAddr       []string      `envconfig:"MONGO_ADDR" default:"127.0.0.1:27017"`
-	SitesDataMongoName       string        `envconfig:"SITES_DATA_MONGO_NAME" default:"sites"`
-	SitesDataMongoUser       string        `envconfig:"SITES_DATA_MONGO_USER" default:"root"`
-	SitesDataMongoPass       string        `envconfig:"SITES_DATA_MONGO_PASS" default:"rootpassword"`
-	SitesDataMongoTimeout    time.Duration `envconfig:"SITES_DATA_MONGO_TIMEOUT" default:"1000ms"`
+	SitesDataMongoCollection         string        `envconfig:"SITES_DATA_MONGO_COLLECTION" default:"sites"`
+	SitesDataHistoryMongoCollection  string        `envconfig:"SITES_DATA_HISTORY_MONGO_COLLECTION" default:"sites_history"`
+	JobsMongoCollection              string        `envconfig:"JOBS_MONGO_COLLECTION" default:"jobs"`
+	JobResultsMongoCollection        string        `envconfig:"JOB_RESULTS_MONGO_COLLECTION" default:"job_results"`
+	WebhookDeliveriesMongoCollection string        `envconfig:"WEBHOOK_DELIVERIES_MONGO_COLLECTION" default:"webhook_deliveries"`
+	RobotsMongoCollection            string        `envconfig:"ROBOTS_MONGO_COLLECTION" default:"robots"`
+	SitesDataMongoAddr               []string      `envconfig:"MONGO_ADDR" default:"127.0.0.1:27017"`
+	SitesDataMongoName               string        `envconfig:"SITES_DATA_MONGO_NAME" default:"sites"`
+	SitesDataMongoUser               string        `envconfig:"SITES_DATA_MONGO_USER" default:"root"`
+	SitesDataMongoPass               string        `envconfig:"SITES_DATA_MONGO_PASS" default:"rootpassword"`
+	SitesDataMongoTimeout            time.Duration `envconfig:"SITES_DATA_MONGO_TIMEOUT" default:"1000ms"`
 }
 
 func main() {
@@ -79,9 +188,58 @@ func main() {
 		logger = level.NewFilter(logger, level.AllowInfo())
 	}
 
-	inputValidator := validator.NewInput(cfg.MaxURLsCount, httperror.NewError)
+	siteRules, err := siterules.Load(cfg.SitesRulesFile)
+	if err != nil {
+		_ = level.Error(logger).Log("msg", "failed to load site rules", "err", err)
+		os.Exit(1)
+	}
 
-	sitesClient := sites.NewClient(http.Client{Timeout: cfg.SitesClientTimeout})
+	// Вебхуки не отправляются во внутренние сети, кроме явно разрешенных хостов
+	callbackGuard := netguard.NewGuard(cfg.WebhookAllowedHosts, cfg.WebhookTimeout)
+	inputValidator := validator.NewInput(
+		cfg.MaxURLsCount,
+		cfg.MaxJobURLsCount,
+		cfg.SitesClientMaxTimeout,
+		cfg.WebhookSecret != "",
+		callbackGuard,
+		httperror.NewError,
+	)
+
+	// Таймаут задается сервисом для каждого урла отдельно
+	sitesClient := sites.NewClient(
+		http.Client{},
+		cfg.SitesClientResponseHeaders,
+		cfg.SitesClientUserAgent,
+		cfg.SitesClientRedirectPolicy,
+		cfg.SitesClientMaxRedirects,
+	)
+	// Ограничения хоста применяются к каждой попытке, поэтому повторы тоже их соблюдают
+	hostLimiter := sites.NewHostLimiter(
+		sites.HostLimit{MaxConcurrent: cfg.SitesClientHostMaxConcurrent, MinDelay: cfg.SitesClientHostMinDelay},
+		hostLimitOverrides(cfg),
+		cfg.SitesClientHostMaxWait,
+	)
+	sitesClient = sites.NewHostLimitClient(sitesClient, hostLimiter)
+	sitesClient = sites.NewRetryClient(sitesClient, sites.RetryPolicy{
+		MaxAttempts:      cfg.SitesClientRetryMaxAttempts,
+		BaseBackoff:      cfg.SitesClientRetryBaseBackoff,
+		MaxBackoff:       cfg.SitesClientRetryMaxBackoff,
+		RetryStatusCodes: cfg.SitesClientRetryStatusCodes,
+		RetryErrors:      cfg.SitesClientRetryErrorClasses,
+		RetryMethods:     cfg.SitesClientRetryMethods,
+	})
+	// Одинаковые одновременные запросы разделяют один запрос к сайту
+	sitesClient = sites.NewCoalescingClient(sitesClient)
+
+	// Общий пул воркеров ограничивает число одновременных запросов к сайтам для всех входящих запросов
+	if cfg.FetchPoolQueueSize < cfg.MaxURLsCount {
+		_ = level.Error(logger).Log("msg", "fetch pool queue size must not be less than max urls count")
+		os.Exit(1)
+	}
+	fetchPool := workerpool.NewPool(cfg.FetchPoolWorkers, cfg.FetchPoolQueueSize)
+	expvar.Publish("fetch_pool", expvar.Func(func() interface{} {
+		return fetchPool.Stats()
+	}))
 
 	// mongo storage
 	ctxTimeout, cancel := context.WithTimeout(context.Background(), cfg.SitesDataMongoTimeout)
@@ -118,27 +276,145 @@ func main() {
 	sitesDataMongoWrapper := wrapper.NewSitesDataWrapper(
 		sitesDataMongoClientDB,
 		cfg.SitesDataMongoCollection,
+		cfg.SitesDataHistoryMongoCollection,
+		cfg.SitesDataMongoTimeout,
+	)
+	sitesDataMongoObjects := builder.NewSitesDataMongoObjects(
+		createDateNameField,
+		urlsNameField,
+		keyNameField,
+		finalKeyNameField,
+		dataNameFiled,
+		binaryDataNameField,
+		metaNameField,
+		etagNameField,
+		lastModifiedNameField,
+		varyKeyNameField,
+		contentHashNameField,
+	)
+	if err = sitesDataMongoWrapper.CreateSitesDataIndexes(context.Background(), sitesDataMongoObjects.SitesDataIndexes()); err != nil {
+		_ = level.Error(logger).Log("msg", "failed to create sites data indexes", "err", err)
+	}
+	if err = sitesDataMongoWrapper.CreateSitesDataHistoryIndexes(context.Background(), sitesDataMongoObjects.SitesDataHistoryIndexes()); err != nil {
+		_ = level.Error(logger).Log("msg", "failed to create sites data history indexes", "err", err)
+	}
+
+	jobsMongoWrapper := wrapper.NewJobsWrapper(
+		sitesDataMongoClientDB,
+		cfg.JobsMongoCollection,
+		cfg.JobResultsMongoCollection,
+		cfg.SitesDataMongoTimeout,
+	)
+	jobsMongoObjects := builder.NewJobsMongoObjects()
+	if err = jobsMongoWrapper.CreateJobResultsIndexes(context.Background(), jobsMongoObjects.JobResultsIndexes()); err != nil {
+		_ = level.Error(logger).Log("msg", "failed to create job results indexes", "err", err)
+	}
+
+	robotsMongoObjects := builder.NewRobotsMongoObjects()
+	robotsMongoWrapper := wrapper.NewRobotsWrapper(
+		sitesDataMongoClientDB,
+		cfg.RobotsMongoCollection,
 		cfg.SitesDataMongoTimeout,
 	)
+	if err = robotsMongoWrapper.CreateRobotsIndexes(context.Background(), robotsMongoObjects.RobotsIndexes()); err != nil {
+		_ = level.Error(logger).Log("msg", "failed to create robots indexes", "err", err)
+	}
+	robotsChecker := robots.NewChecker(
+		http.Client{Timeout: cfg.RobotsTimeout},
+		robotsMongoObjects,
+		robotsMongoWrapper,
+		hostLimiter,
+		logger,
+		cfg.SitesClientUserAgent,
+		cfg.RobotsCacheTTL,
+		cfg.RobotsErrorTTL,
+		cfg.RobotsMaxCrawlDelay,
+		cfg.RobotsOwnedHosts,
+	)
+
+	webhookSender := webhook.NewSender(
+		http.Client{
+			Timeout:   cfg.WebhookTimeout,
+			Transport: &http.Transport{DialContext: callbackGuard.DialContext},
+		},
+		cfg.WebhookSecret,
+		cfg.WebhookMaxAttempts,
+		cfg.WebhookBaseBackoff,
+		cfg.WebhookMaxBackoff,
+		cfg.JobResultsPageLimit,
+		cfg.JobResultsMaxPageSize,
+		builder.NewWebhookDeliveriesMongoObjects(),
+		wrapper.NewWebhookDeliveriesWrapper(
+			sitesDataMongoClientDB,
+			cfg.WebhookDeliveriesMongoCollection,
+			cfg.SitesDataMongoTimeout,
+		),
+		converter.NewWebhookDeliveries(),
+		httperror.NewError,
+		logger,
+	)
+
+	jobsManager := jobs.NewManager(
+		jobsMongoObjects,
+		jobsMongoWrapper,
+		converter.NewJobs(),
+		webhookSender,
+		httperror.NewError,
+		logger,
+		cfg.JobsWorkers,
+		cfg.JobsQueueSize,
+		cfg.MaxURLsCount,
+		cfg.JobsPollInterval,
+		cfg.JobResultsPageLimit,
+		cfg.JobResultsMaxPageSize,
+		cfg.PublicURL+httpserver.URIPathJobs+"/%s",
+	)
 
 	svc := sitesdataservice.NewService(
 		inputValidator,
 		httperror.NewError,
 		sitesClient,
-		builder.NewSitesDataMongoObjects(
-			createDateNameField,
-			urlsNameField,
-			dataNameFiled),
+		fetchPool,
+		robotsChecker,
+		cachekey.NewBuilder(
+			urlcanon.NewCanonicalizer(cfg.SitesCacheTrackingParams),
+			cfg.SitesCacheKeyHeaders,
+			cfg.SitesClientRedirectPolicy,
+		),
+		cachepolicy.NewPolicy(cfg.SitesCacheMinTTL, cfg.SitesCacheMaxTTL),
+		siterules.NewMatcher(siteRules),
+		sitesDataMongoObjects,
 		sitesDataMongoWrapper,
 		logger,
 		converter.NewSitesData(),
-		createDateNameField,
-		sortAsc,
+		cfg.SitesDataCacheMaxAge,
+		cfg.SitesDataRevalidateWindow,
+		cfg.SitesDataStaleIfError,
+		cfg.SitesDataStaleWhileRevalidate,
+		cfg.SitesClientTimeout,
+		cfg.SitesClientMaxBodySize,
+		cfg.SitesClientBodyLimitMode,
+		cfg.SitesClientBinaryContent,
+		jobsManager,
+		webhookSender,
+		cfg.SnapshotsPageLimit,
+		cfg.SnapshotsMaxPageSize,
+		diff.NewDiffer(cfg.SnapshotsDiffContextLines, cfg.SnapshotsDiffMaxEdits),
+		cfg.SnapshotsDiffMaxSize,
 	)
 	// Добавляем логи к сервису
 	svc = sitesdataservice.NewLoggingMiddleware(logger, svc)
 
-	router := httpserver.NewPreparedServer(svc)
+	// Задания обрабатываются порциями через сервис, чтобы использовать общий кэш
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	jobsStopped := make(chan struct{})
+	go func() {
+		jobsManager.Run(jobsCtx, svc)
+		close(jobsStopped)
+	}()
+
+	// Таймаут передается и роутеру: потоковый ответ формируется после выхода из хендлера
+	router := httpserver.NewPreparedServer(svc, cfg.ServerTimeout)
 
 	// Устанавливаем таймаут сервера и ошибку
 	handlerWithTimeout := fasthttp.TimeoutHandler(
@@ -161,6 +437,22 @@ func main() {
 			os.Exit(1)
 		}
 	}()
+	// Метрики, в том числе пула воркеров, и ручки недоставленных вебхуков отдаются отдельным сервером,
+	// а не публичным роутером
+	var adminServer *fasthttp.Server
+	if cfg.AdminAddr != "" {
+		adminRouter := httpserver.NewPreparedAdminServer(svc)
+		adminRouter.GET("/debug/vars", expvarhandler.ExpvarHandler)
+		adminServer = &fasthttp.Server{Handler: adminRouter.Handler}
+
+		go func() {
+			_ = level.Info(logger).Log("msg", "starting admin http server", "addr", cfg.AdminAddr)
+			if err := adminServer.ListenAndServe(cfg.AdminAddr); err != nil {
+				_ = level.Error(logger).Log("msg", "admin server run failure", "err", err)
+				os.Exit(1)
+			}
+		}()
+	}
 	// graceful shutdown
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
@@ -170,7 +462,40 @@ func main() {
 		if err := fasthttpServer.Shutdown(); err != nil {
 			_ = level.Error(logger).Log("msg", "server shutdown failure", "err", err)
 		}
+		if adminServer != nil {
+			if err := adminServer.Shutdown(); err != nil {
+				_ = level.Error(logger).Log("msg", "admin server shutdown failure", "err", err)
+			}
+		}
+
+		// Незавершенные задания продолжатся после перезапуска
+		stopJobs()
+		<-jobsStopped
 
 		_ = level.Info(logger).Log("msg", "server stopped")
 	}(<-c)
 }
+
+// hostLimitOverrides объединяет переопределения числа запросов и паузы, для незаданного значения берется общее
+func hostLimitOverrides(cfg configuration) (overrides map[string]sites.HostLimit) {
+	overrides = make(map[string]sites.HostLimit)
+	defaultLimit := sites.HostLimit{MaxConcurrent: cfg.SitesClientHostMaxConcurrent, MinDelay: cfg.SitesClientHostMinDelay}
+	for host, maxConcurrent := range cfg.SitesClientHostMaxConcurrentOverrides {
+		limit, ok := overrides[host]
+		if !ok {
+			limit = defaultLimit
+		}
+		limit.MaxConcurrent = maxConcurrent
+		overrides[host] = limit
+	}
+	for host, minDelay := range cfg.SitesClientHostMinDelayOverrides {
+		limit, ok := overrides[host]
+		if !ok {
+			limit = defaultLimit
+		}
+		limit.MinDelay = minDelay
+		overrides[host] = limit
+	}
+
+	return
+}
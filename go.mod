@@ -10,4 +10,5 @@ require (
 	github.com/valyala/fasthttp v1.17.0
 	go.mongodb.org/mongo-driver v1.4.4
 	golang.org/x/sync v0.0.0-20201020160332-67f06af15bc9
+	golang.org/x/text v0.3.3
 )
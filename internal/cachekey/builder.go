@@ -0,0 +1,132 @@
+package cachekey
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/mts-test-task/internal/sites"
+	"github.com/mts-test-task/pkg/sitesdataservice/api"
+)
+
+// Builder makes keys of cached site data
+type Builder interface {
+	// Key depends on method, canonical url, relevant headers and body of the request
+	Key(request *sites.Request) (key string)
+	// URL is the canonical url of the request the key is built from
+	URL(request *sites.Request) (canonicalURL string)
+	// VaryKey depends on values of request headers listed in Vary of the response, empty without them
+	VaryKey(request *sites.Request, varyHeaders []string) (varyKey string)
+}
+
+type urlCanonicalizer interface {
+	Canonicalize(rawURL string) (canonical string)
+}
+
+type builder struct {
+	urlCanonicalizer      urlCanonicalizer
+	relevantHeaders       []string
+	defaultRedirectPolicy string
+}
+
+func (b *builder) Key(request *sites.Request) (key string) {
+	method := strings.ToUpper(request.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	bodyHash := sha256.Sum256([]byte(request.Body))
+
+	// Собираем ключ в каноническом виде: заголовки в фиксированном порядке, от тела берем только хэш.
+	// Итоговая строка тоже хэшируется, чтобы значения заголовков вроде Authorization не попадали в монгу
+	var canonical strings.Builder
+	canonical.WriteString(method)
+	canonical.WriteByte('\n')
+	canonical.WriteString(b.urlCanonicalizer.Canonicalize(request.URL))
+	canonical.WriteByte('\n')
+	for _, name := range b.relevantHeaders {
+		value, ok := headerValue(request.Headers, name)
+		if !ok {
+			continue
+		}
+		canonical.WriteString(name)
+		canonical.WriteByte(':')
+		canonical.WriteString(value)
+		canonical.WriteByte('\n')
+	}
+	canonical.WriteString(hex.EncodeToString(bodyHash[:]))
+	// Без следования редиректам вместо данных сайта сохраняется сам ответ с редиректом
+	redirectPolicy := request.RedirectPolicy
+	if redirectPolicy == "" {
+		redirectPolicy = b.defaultRedirectPolicy
+	}
+	if redirectPolicy == api.RedirectPolicyNone {
+		canonical.WriteString("\nredirect:")
+		canonical.WriteString(redirectPolicy)
+	}
+	// Тело без перекодировки хранится отдельно от перекодированного
+	if request.RawBody {
+		canonical.WriteString("\nraw")
+	}
+
+	keyHash := sha256.Sum256([]byte(canonical.String()))
+	return hex.EncodeToString(keyHash[:])
+}
+
+func (b *builder) URL(request *sites.Request) (canonicalURL string) {
+	return b.urlCanonicalizer.Canonicalize(request.URL)
+}
+
+func (b *builder) VaryKey(request *sites.Request, varyHeaders []string) (varyKey string) {
+	if len(varyHeaders) == 0 {
+		return
+	}
+
+	names := make([]string, len(varyHeaders))
+	for i := range varyHeaders {
+		names[i] = http.CanonicalHeaderKey(varyHeaders[i])
+	}
+	sort.Strings(names)
+
+	// Отсутствующий заголовок отличается от пустого
+	var canonical strings.Builder
+	for _, name := range names {
+		canonical.WriteString(name)
+		if value, ok := headerValue(request.Headers, name); ok {
+			canonical.WriteByte(':')
+			canonical.WriteString(value)
+		}
+		canonical.WriteByte('\n')
+	}
+
+	varyHash := sha256.Sum256([]byte(canonical.String()))
+	return hex.EncodeToString(varyHash[:])
+}
+
+// headerValue ищет заголовок без учета регистра имени
+func headerValue(headers map[string]string, name string) (value string, ok bool) {
+	for headerName, headerValue := range headers {
+		if http.CanonicalHeaderKey(headerName) == name {
+			return headerValue, true
+		}
+	}
+
+	return
+}
+
+// NewBuilder ...
+func NewBuilder(urlCanonicalizer urlCanonicalizer, relevantHeaders []string, defaultRedirectPolicy string) Builder {
+	headers := make([]string, len(relevantHeaders))
+	for i := range relevantHeaders {
+		headers[i] = http.CanonicalHeaderKey(relevantHeaders[i])
+	}
+	sort.Strings(headers)
+
+	return &builder{
+		urlCanonicalizer:      urlCanonicalizer,
+		relevantHeaders:       headers,
+		defaultRedirectPolicy: defaultRedirectPolicy,
+	}
+}
@@ -0,0 +1,145 @@
+package cachekey
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mts-test-task/internal/sites"
+	"github.com/mts-test-task/pkg/sitesdataservice/api"
+)
+
+type lowerCanonicalizer struct{}
+
+func (lowerCanonicalizer) Canonicalize(rawURL string) (canonical string) {
+	return strings.ToLower(rawURL)
+}
+
+func TestBuilder_Key(t *testing.T) {
+	tests := []struct {
+		name  string
+		a, b  *sites.Request
+		equal bool
+	}{
+		{
+			name:  "canonical url",
+			a:     &sites.Request{URL: "HTTP://Example.com/"},
+			b:     &sites.Request{URL: "http://example.com/"},
+			equal: true,
+		},
+		{
+			name:  "default method",
+			a:     &sites.Request{URL: "http://example.com/"},
+			b:     &sites.Request{URL: "http://example.com/", Method: "get"},
+			equal: true,
+		},
+		{
+			name: "method",
+			a:    &sites.Request{URL: "http://example.com/", Method: "POST"},
+			b:    &sites.Request{URL: "http://example.com/"},
+		},
+		{
+			name: "body",
+			a:    &sites.Request{URL: "http://example.com/", Method: "POST", Body: `{"a":1}`},
+			b:    &sites.Request{URL: "http://example.com/", Method: "POST", Body: `{"a":2}`},
+		},
+		{
+			name: "relevant header",
+			a:    &sites.Request{URL: "http://example.com/", Headers: map[string]string{"accept-language": "ru"}},
+			b:    &sites.Request{URL: "http://example.com/", Headers: map[string]string{"Accept-Language": "en"}},
+		},
+		{
+			name:  "relevant header name case",
+			a:     &sites.Request{URL: "http://example.com/", Headers: map[string]string{"accept-language": "ru"}},
+			b:     &sites.Request{URL: "http://example.com/", Headers: map[string]string{"Accept-Language": "ru"}},
+			equal: true,
+		},
+		{
+			name:  "irrelevant header",
+			a:     &sites.Request{URL: "http://example.com/", Headers: map[string]string{"X-Request-Id": "1"}},
+			b:     &sites.Request{URL: "http://example.com/", Headers: map[string]string{"X-Request-Id": "2"}},
+			equal: true,
+		},
+		{
+			name: "redirect policy none",
+			a:    &sites.Request{URL: "http://example.com/", RedirectPolicy: api.RedirectPolicyNone},
+			b:    &sites.Request{URL: "http://example.com/"},
+		},
+		{
+			name:  "explicit default redirect policy",
+			a:     &sites.Request{URL: "http://example.com/", RedirectPolicy: api.RedirectPolicyFollow},
+			b:     &sites.Request{URL: "http://example.com/"},
+			equal: true,
+		},
+		{
+			name: "raw body",
+			a:    &sites.Request{URL: "http://example.com/", RawBody: true},
+			b:    &sites.Request{URL: "http://example.com/"},
+		},
+	}
+	keyBuilder := NewBuilder(lowerCanonicalizer{}, []string{"accept-language"}, api.RedirectPolicyFollow)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.equal, keyBuilder.Key(tt.a) == keyBuilder.Key(tt.b))
+		})
+	}
+}
+
+func TestBuilder_KeyHidesHeaderValues(t *testing.T) {
+	keyBuilder := NewBuilder(lowerCanonicalizer{}, []string{"Authorization"}, api.RedirectPolicyFollow)
+	key := keyBuilder.Key(&sites.Request{URL: "http://example.com/", Headers: map[string]string{"Authorization": "Bearer secret"}})
+
+	assert.NotContains(t, key, "secret")
+	assert.Len(t, key, 64)
+}
+
+func TestBuilder_VaryKey(t *testing.T) {
+	tests := []struct {
+		name        string
+		varyHeaders []string
+		a, b        map[string]string
+		equal       bool
+	}{
+		{
+			name:  "no vary",
+			a:     map[string]string{"Accept": "text/html"},
+			b:     map[string]string{"Accept": "application/json"},
+			equal: true,
+		},
+		{
+			name:        "different values",
+			varyHeaders: []string{"accept"},
+			a:           map[string]string{"Accept": "text/html"},
+			b:           map[string]string{"accept": "application/json"},
+		},
+		{
+			name:        "order of vary headers",
+			varyHeaders: []string{"Accept", "Accept-Encoding"},
+			a:           map[string]string{"Accept": "text/html", "Accept-Encoding": "gzip"},
+			b:           map[string]string{"accept-encoding": "gzip", "accept": "text/html"},
+			equal:       true,
+		},
+		{
+			name:        "missing and empty header",
+			varyHeaders: []string{"Accept"},
+			a:           map[string]string{},
+			b:           map[string]string{"Accept": ""},
+		},
+	}
+	keyBuilder := NewBuilder(lowerCanonicalizer{}, nil, api.RedirectPolicyFollow)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := keyBuilder.VaryKey(&sites.Request{URL: "http://example.com/", Headers: tt.a}, tt.varyHeaders)
+			b := keyBuilder.VaryKey(&sites.Request{URL: "http://example.com/", Headers: tt.b}, reverse(tt.varyHeaders))
+			assert.Equal(t, tt.equal, a == b)
+		})
+	}
+}
+
+func reverse(names []string) (reversed []string) {
+	for i := len(names) - 1; i >= 0; i-- {
+		reversed = append(reversed, names[i])
+	}
+	return
+}
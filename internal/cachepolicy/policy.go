@@ -0,0 +1,174 @@
+package cachepolicy
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Freshness describes how long site response may be served from cache
+type Freshness struct {
+	// Known is false when response has no caching headers, the default cache age is used then
+	Known bool
+	// TTL is the remaining freshness lifetime at the moment of response
+	TTL time.Duration
+	// NoStore forbids saving the response
+	NoStore bool
+	// MustRevalidate forbids serving the response after its freshness lifetime without checking it with the site
+	MustRevalidate bool
+	// Vary lists request headers the response depends on
+	Vary []string
+}
+
+// Policy computes freshness of site responses from Cache-Control, Expires, Age and Vary headers
+type Policy interface {
+	Freshness(header http.Header, responseTime time.Time) (freshness *Freshness)
+	// MaxTTL is the ceiling of freshness lifetime
+	MaxTTL() (maxTTL time.Duration)
+}
+
+type policy struct {
+	minTTL time.Duration
+	maxTTL time.Duration
+}
+
+func (p *policy) Freshness(header http.Header, responseTime time.Time) (freshness *Freshness) {
+	freshness = &Freshness{Vary: varyHeaders(header)}
+	directives := cacheControl(header)
+
+	// Ответ, зависящий от любых заголовков, сопоставить с запросом нельзя
+	_, noStore := directives["no-store"]
+	if noStore || containsWildcard(freshness.Vary) {
+		freshness.Known = true
+		freshness.NoStore = true
+		return
+	}
+	// no-cache разрешает хранить ответ, но перед каждым использованием его нужно проверить
+	if _, ok := directives["no-cache"]; ok {
+		freshness.Known = true
+		freshness.MustRevalidate = true
+		return
+	}
+	_, mustRevalidate := directives["must-revalidate"]
+	_, proxyRevalidate := directives["proxy-revalidate"]
+	freshness.MustRevalidate = mustRevalidate || proxyRevalidate
+
+	lifetime, ok := p.lifetime(header, directives)
+	if !ok {
+		return
+	}
+	freshness.Known = true
+
+	if lifetime < p.minTTL {
+		lifetime = p.minTTL
+	}
+	if p.maxTTL > 0 && lifetime > p.maxTTL {
+		lifetime = p.maxTTL
+	}
+	freshness.TTL = lifetime - currentAge(header, responseTime)
+	if freshness.TTL < 0 {
+		freshness.TTL = 0
+	}
+
+	return
+}
+
+func (p *policy) MaxTTL() (maxTTL time.Duration) {
+	return p.maxTTL
+}
+
+// lifetime определяет срок свежести: s-maxage важнее max-age, max-age важнее Expires
+func (p *policy) lifetime(header http.Header, directives map[string]string) (lifetime time.Duration, ok bool) {
+	for _, name := range []string{"s-maxage", "max-age"} {
+		if value, found := directives[name]; found {
+			seconds, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || seconds < 0 {
+				// Некорректное значение означает, что ответ уже устарел
+				return 0, true
+			}
+			return time.Duration(seconds) * time.Second, true
+		}
+	}
+
+	expiresValue := header.Get("Expires")
+	if expiresValue == "" {
+		return 0, false
+	}
+	expires, err := http.ParseTime(expiresValue)
+	if err != nil {
+		return 0, true
+	}
+	date, err := http.ParseTime(header.Get("Date"))
+	if err != nil {
+		date = time.Now()
+	}
+
+	return expires.Sub(date), true
+}
+
+// currentAge - возраст ответа к моменту получения: больший из заголовка Age и разницы с Date
+func currentAge(header http.Header, responseTime time.Time) (age time.Duration) {
+	if seconds, err := strconv.ParseInt(header.Get("Age"), 10, 64); err == nil && seconds > 0 {
+		age = time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(header.Get("Date")); err == nil {
+		if apparent := responseTime.Sub(date); apparent > age {
+			age = apparent
+		}
+	}
+
+	return
+}
+
+// cacheControl разбирает директивы Cache-Control, имена приводятся к нижнему регистру
+func cacheControl(header http.Header) (directives map[string]string) {
+	directives = make(map[string]string)
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			directive = strings.TrimSpace(directive)
+			if directive == "" {
+				continue
+			}
+			name, arg := directive, ""
+			if i := strings.IndexByte(directive, '='); i >= 0 {
+				name, arg = directive[:i], strings.Trim(strings.TrimSpace(directive[i+1:]), `"`)
+			}
+			directives[strings.ToLower(strings.TrimSpace(name))] = arg
+		}
+	}
+
+	return
+}
+
+// varyHeaders возвращает канонические имена заголовков из Vary
+func varyHeaders(header http.Header) (names []string) {
+	for _, value := range header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+
+	return
+}
+
+func containsWildcard(names []string) bool {
+	for _, name := range names {
+		if name == "*" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NewPolicy ...
+func NewPolicy(minTTL time.Duration, maxTTL time.Duration) Policy {
+	return &policy{
+		minTTL: minTTL,
+		maxTTL: maxTTL,
+	}
+}
@@ -0,0 +1,116 @@
+package cachepolicy
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicy_Freshness(t *testing.T) {
+	now := time.Date(2020, 7, 1, 12, 0, 0, 0, time.UTC)
+	date := now.Format(http.TimeFormat)
+	tests := []struct {
+		name   string
+		header http.Header
+		want   Freshness
+	}{
+		{
+			name:   "no caching headers",
+			header: http.Header{},
+			want:   Freshness{},
+		},
+		{
+			name:   "max-age",
+			header: http.Header{"Cache-Control": {"public, max-age=600"}},
+			want:   Freshness{Known: true, TTL: 10 * time.Minute},
+		},
+		{
+			name:   "s-maxage wins over max-age",
+			header: http.Header{"Cache-Control": {"max-age=600, s-maxage=120"}},
+			want:   Freshness{Known: true, TTL: 2 * time.Minute},
+		},
+		{
+			name:   "directives are case insensitive and split across headers",
+			header: http.Header{"Cache-Control": {"Public", `MAX-AGE="300"`}},
+			want:   Freshness{Known: true, TTL: 5 * time.Minute},
+		},
+		{
+			name:   "invalid max-age is stale",
+			header: http.Header{"Cache-Control": {"max-age=soon"}},
+			want:   Freshness{Known: true, TTL: time.Second},
+		},
+		{
+			name:   "age is subtracted",
+			header: http.Header{"Cache-Control": {"max-age=600"}, "Age": {"100"}},
+			want:   Freshness{Known: true, TTL: 500 * time.Second},
+		},
+		{
+			name:   "age over lifetime",
+			header: http.Header{"Cache-Control": {"max-age=600"}, "Age": {"1000"}},
+			want:   Freshness{Known: true},
+		},
+		{
+			name:   "expires",
+			header: http.Header{"Date": {date}, "Expires": {now.Add(time.Hour).Format(http.TimeFormat)}},
+			want:   Freshness{Known: true, TTL: time.Hour},
+		},
+		{
+			name:   "max-age wins over expires",
+			header: http.Header{"Cache-Control": {"max-age=60"}, "Expires": {now.Add(time.Hour).Format(http.TimeFormat)}},
+			want:   Freshness{Known: true, TTL: time.Minute},
+		},
+		{
+			name:   "invalid expires is stale",
+			header: http.Header{"Date": {date}, "Expires": {"0"}},
+			want:   Freshness{Known: true, TTL: time.Second},
+		},
+		{
+			name:   "lifetime is capped",
+			header: http.Header{"Cache-Control": {"max-age=864000"}},
+			want:   Freshness{Known: true, TTL: 24 * time.Hour},
+		},
+		{
+			name:   "no-store",
+			header: http.Header{"Cache-Control": {"no-store, max-age=600"}},
+			want:   Freshness{Known: true, NoStore: true},
+		},
+		{
+			name:   "vary star",
+			header: http.Header{"Cache-Control": {"max-age=600"}, "Vary": {"Accept, *"}},
+			want:   Freshness{Known: true, NoStore: true, Vary: []string{"Accept", "*"}},
+		},
+		{
+			name:   "vary",
+			header: http.Header{"Cache-Control": {"max-age=600"}, "Vary": {"accept-encoding, Accept-Language"}},
+			want:   Freshness{Known: true, TTL: 10 * time.Minute, Vary: []string{"Accept-Encoding", "Accept-Language"}},
+		},
+		{
+			name:   "no-cache",
+			header: http.Header{"Cache-Control": {"no-cache, max-age=600"}},
+			want:   Freshness{Known: true, MustRevalidate: true},
+		},
+		{
+			name:   "must-revalidate",
+			header: http.Header{"Cache-Control": {"max-age=600, must-revalidate"}},
+			want:   Freshness{Known: true, TTL: 10 * time.Minute, MustRevalidate: true},
+		},
+		{
+			name:   "proxy-revalidate",
+			header: http.Header{"Cache-Control": {"max-age=600, proxy-revalidate"}},
+			want:   Freshness{Known: true, TTL: 10 * time.Minute, MustRevalidate: true},
+		},
+		{
+			name:   "must-revalidate without lifetime",
+			header: http.Header{"Cache-Control": {"must-revalidate"}},
+			want:   Freshness{MustRevalidate: true},
+		},
+	}
+	policy := NewPolicy(time.Second, 24*time.Hour)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, &tt.want, policy.Freshness(tt.header, now))
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package converter
+
+import (
+	"time"
+
+	"github.com/mts-test-task/internal/storages/mongodb/models"
+	"github.com/mts-test-task/pkg/sitesdataservice/api"
+)
+
+// Jobs convert batch jobs from storage format to user response
+type Jobs interface {
+	JobToAPI(job *models.Job) (apiJob *api.Job)
+	JobResultsToSitesData(results []*models.JobResult) (sitesData []*api.SiteData)
+}
+
+type jobs struct{}
+
+func (j *jobs) JobToAPI(job *models.Job) (apiJob *api.Job) {
+	return &api.Job{
+		ID:        job.ID,
+		Status:    job.Status,
+		Total:     job.Total,
+		Processed: job.Processed,
+		Failed:    job.Failed,
+		Error:     job.Error,
+		CreatedAt: time.Unix(job.CreateDate, 0).UTC(),
+		UpdatedAt: time.Unix(job.UpdateDate, 0).UTC(),
+	}
+}
+
+func (j *jobs) JobResultsToSitesData(results []*models.JobResult) (sitesData []*api.SiteData) {
+	sitesData = make([]*api.SiteData, len(results))
+	for i := 0; i < len(results); i++ {
+		sitesData[i] = &api.SiteData{
+			URL:    results[i].URL,
+			Status: results[i].Status,
+			Meta:   storedSiteDataMeta(results[i].Meta),
+		}
+		sitesData[i].Data, sitesData[i].Encoding = storedData(results[i].Data, results[i].BinaryData)
+		if results[i].ErrorCode != 0 {
+			sitesData[i].Error = &api.SiteDataError{
+				Code:    results[i].ErrorCode,
+				Message: results[i].ErrorMessage,
+			}
+		}
+	}
+
+	return
+}
+
+// NewJobs ...
+func NewJobs() Jobs {
+	return &jobs{}
+}
@@ -1,10 +1,23 @@
 package converter
 
-import "github.com/mts-test-task/internal/storages/mongodb/models"
+import (
+	"encoding/base64"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mts-test-task/internal/sites"
+	"github.com/mts-test-task/internal/storages/mongodb/models"
+	"github.com/mts-test-task/pkg/sitesdataservice/api"
+)
 
 // SitesData convert side data to map
 type SitesData interface {
 	SitesDataToMap(sitesData []*models.SiteData) (sitesDataMap map[string]*models.SiteData)
+	SiteResponseToSiteData(url string, response *sites.Response) (siteData *api.SiteData)
+	StoredSiteDataToSiteData(url string, storedSiteData *models.SiteData, now time.Time) (siteData *api.SiteData)
+	RevalidatedSiteDataToSiteData(url string, storedSiteData *models.SiteData, response *sites.Response) (siteData *api.SiteData)
+	SelectFields(siteData *api.SiteData, fields []string) (selected *api.SiteData)
+	StoredSiteDataToSnapshot(storedSiteData *models.SiteData, withData bool) (snapshot *api.Snapshot)
 }
 
 type sitesData struct{}
@@ -12,7 +25,200 @@ type sitesData struct{}
 func (s *sitesData) SitesDataToMap(sitesData []*models.SiteData) (sitesDataMap map[string]*models.SiteData) {
 	sitesDataMap = make(map[string]*models.SiteData)
 	for i := 0; i < len(sitesData); i++ {
-		sitesDataMap[sitesData[i].URL] = sitesData[i]
+		// Записи без ключа сохранены до появления ключей кэша и скоро устареют
+		if sitesData[i].Key == "" {
+			continue
+		}
+		sitesDataMap[sitesData[i].Key] = sitesData[i]
+	}
+	// Запись доступна и по ключу запроса к конечному урлу, но прямой запрос к урлу важнее
+	for i := 0; i < len(sitesData); i++ {
+		if sitesData[i].FinalKey == "" {
+			continue
+		}
+		if _, ok := sitesDataMap[sitesData[i].FinalKey]; !ok {
+			sitesDataMap[sitesData[i].FinalKey] = sitesData[i]
+		}
+	}
+
+	return
+}
+
+func (s *sitesData) SiteResponseToSiteData(url string, response *sites.Response) (siteData *api.SiteData) {
+	siteData = &api.SiteData{
+		URL:    url,
+		Status: api.SiteDataStatusOK,
+		Meta: &api.SiteDataMeta{
+			StatusCode:      response.StatusCode,
+			Headers:         response.Headers,
+			ContentType:     response.ContentType,
+			ContentLength:   response.ContentLength,
+			Charset:         response.Charset,
+			FetchDurationMs: response.Duration.Milliseconds(),
+			FetchedAt:       response.FetchedAt.UTC(),
+			Truncated:       response.Truncated,
+			Attempts:        response.Attempts,
+			ETag:            response.ETag,
+			LastModified:    response.LastModified,
+			Redirects:       siteResponseRedirects(response.Redirects),
+		},
+	}
+	if len(response.Redirects) > 0 {
+		siteData.Meta.FinalURL = response.FinalURL
+	}
+	// Двоичные данные и тело без перекодировки с невалидным UTF-8 не пережили бы JSON
+	siteData.Data = response.Data
+	if response.Binary || !utf8.ValidString(response.Data) {
+		siteData.Data = base64.StdEncoding.EncodeToString([]byte(response.Data))
+		siteData.Encoding = api.SiteDataEncodingBase64
+	}
+
+	return
+}
+
+// siteResponseRedirects переводит цепочку редиректов в формат ответа
+func siteResponseRedirects(redirects []*sites.Redirect) (apiRedirects []*api.SiteDataRedirect) {
+	if len(redirects) == 0 {
+		return
+	}
+
+	apiRedirects = make([]*api.SiteDataRedirect, len(redirects))
+	for i := range redirects {
+		apiRedirects[i] = &api.SiteDataRedirect{URL: redirects[i].URL, StatusCode: redirects[i].StatusCode}
+	}
+
+	return
+}
+
+func (s *sitesData) StoredSiteDataToSiteData(url string, storedSiteData *models.SiteData, now time.Time) (siteData *api.SiteData) {
+	// У записей, сохраненных до появления метаданных, известна только дата создания
+	meta := &api.SiteDataMeta{
+		ContentLength: int64(len(storedSiteData.Data) + len(storedSiteData.BinaryData)),
+		FetchedAt:     time.Unix(int64(storedSiteData.CreateDate), 0).UTC(),
+		Cached:        true,
+	}
+	if storedSiteData.Meta != nil {
+		meta = storedSiteDataMeta(storedSiteData.Meta)
+		meta.Cached = true
+	}
+	meta.CacheAgeMs = now.Sub(meta.FetchedAt).Milliseconds()
+	meta.ETag = storedSiteData.ETag
+	meta.LastModified = storedSiteData.LastModified
+
+	siteData = &api.SiteData{
+		URL:    url,
+		Status: api.SiteDataStatusOK,
+		Meta:   meta,
+	}
+	siteData.Data, siteData.Encoding = storedData(storedSiteData.Data, storedSiteData.BinaryData)
+
+	return
+}
+
+// RevalidatedSiteDataToSiteData returns stored data confirmed by 304 response, the data is fresh again
+func (s *sitesData) RevalidatedSiteDataToSiteData(url string, storedSiteData *models.SiteData, response *sites.Response) (siteData *api.SiteData) {
+	siteData = s.StoredSiteDataToSiteData(url, storedSiteData, response.FetchedAt)
+	siteData.Meta.Revalidated = true
+	siteData.Meta.FetchedAt = response.FetchedAt.UTC()
+	siteData.Meta.FetchDurationMs = response.Duration.Milliseconds()
+	siteData.Meta.CacheAgeMs = 0
+	siteData.Meta.Attempts = response.Attempts
+	// Сайт может прислать новые валидаторы вместе с 304
+	if response.ETag != "" {
+		siteData.Meta.ETag = response.ETag
+	}
+	if response.LastModified != "" {
+		siteData.Meta.LastModified = response.LastModified
+	}
+
+	return
+}
+
+func (s *sitesData) SelectFields(siteData *api.SiteData, fields []string) (selected *api.SiteData) {
+	if len(fields) == 0 {
+		return siteData
+	}
+
+	// Урл возвращается всегда, иначе результат нельзя сопоставить с запросом
+	selected = &api.SiteData{URL: siteData.URL}
+	for _, field := range fields {
+		switch field {
+		case api.SiteDataFieldData:
+			// Без кодировки данные нельзя правильно прочитать
+			selected.Data = siteData.Data
+			selected.Encoding = siteData.Encoding
+		case api.SiteDataFieldStatus:
+			selected.Status = siteData.Status
+		case api.SiteDataFieldError:
+			selected.Error = siteData.Error
+		case api.SiteDataFieldMeta:
+			selected.Meta = siteData.Meta
+		}
+	}
+
+	return
+}
+
+// storedData возвращает данные в формате ответа: двоичные данные хранятся отдельно и кодируются в base64
+func storedData(data string, binaryData []byte) (apiData string, encoding string) {
+	if binaryData == nil {
+		return data, ""
+	}
+
+	return base64.StdEncoding.EncodeToString(binaryData), api.SiteDataEncodingBase64
+}
+
+// storedSiteDataMeta переводит метаданные из формата хранения в формат ответа
+func storedSiteDataMeta(storedMeta *models.SiteDataMeta) (meta *api.SiteDataMeta) {
+	if storedMeta == nil {
+		return
+	}
+
+	return &api.SiteDataMeta{
+		StatusCode:      storedMeta.StatusCode,
+		Headers:         storedMeta.Headers,
+		ContentType:     storedMeta.ContentType,
+		ContentLength:   storedMeta.ContentLength,
+		Charset:         storedMeta.Charset,
+		FetchDurationMs: storedMeta.FetchDuration,
+		FetchedAt:       time.Unix(0, storedMeta.FetchedAt*int64(time.Millisecond)).UTC(),
+		Redirects:       storedSiteDataRedirects(storedMeta.Redirects),
+		FinalURL:        storedMeta.FinalURL,
+		CacheTTLMs:      storedMeta.CacheTTL,
+		MustRevalidate:  storedMeta.MustRevalidate,
+		Vary:            storedMeta.Vary,
+	}
+}
+
+// storedSiteDataRedirects переводит цепочку редиректов из формата хранения в формат ответа
+func storedSiteDataRedirects(storedRedirects []*models.SiteDataRedirect) (redirects []*api.SiteDataRedirect) {
+	if len(storedRedirects) == 0 {
+		return
+	}
+
+	redirects = make([]*api.SiteDataRedirect, len(storedRedirects))
+	for i := range storedRedirects {
+		redirects[i] = &api.SiteDataRedirect{URL: storedRedirects[i].URL, StatusCode: storedRedirects[i].StatusCode}
+	}
+
+	return
+}
+
+// StoredSiteDataToSnapshot returns snapshot of url history, data is returned only when withData is set
+func (s *sitesData) StoredSiteDataToSnapshot(storedSiteData *models.SiteData, withData bool) (snapshot *api.Snapshot) {
+	snapshot = &api.Snapshot{
+		ID:          storedSiteData.ID,
+		URL:         storedSiteData.URL,
+		CreatedAt:   time.Unix(int64(storedSiteData.CreateDate), 0).UTC(),
+		ContentHash: storedSiteData.ContentHash,
+		Meta:        storedSiteDataMeta(storedSiteData.Meta),
+	}
+	if snapshot.Meta != nil {
+		snapshot.Meta.ETag = storedSiteData.ETag
+		snapshot.Meta.LastModified = storedSiteData.LastModified
+	}
+	if withData {
+		snapshot.Data, snapshot.Encoding = storedData(storedSiteData.Data, storedSiteData.BinaryData)
 	}
 
 	return
@@ -0,0 +1,44 @@
+package converter
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/mts-test-task/internal/storages/mongodb/models"
+	"github.com/mts-test-task/pkg/sitesdataservice/api"
+)
+
+// WebhookDeliveries convert webhook deliveries from storage format to user response
+type WebhookDeliveries interface {
+	DeliveryToAPI(delivery *models.WebhookDelivery) (apiDelivery *api.WebhookDelivery)
+	DeliveriesToAPI(deliveries []*models.WebhookDelivery) (apiDeliveries []*api.WebhookDelivery)
+}
+
+type webhookDeliveries struct{}
+
+func (w *webhookDeliveries) DeliveryToAPI(delivery *models.WebhookDelivery) (apiDelivery *api.WebhookDelivery) {
+	return &api.WebhookDelivery{
+		ID:        delivery.ID,
+		URL:       delivery.URL,
+		Status:    delivery.Status,
+		Attempts:  delivery.Attempts,
+		LastError: delivery.LastError,
+		Payload:   json.RawMessage(delivery.Payload),
+		CreatedAt: time.Unix(delivery.CreateDate, 0).UTC(),
+		UpdatedAt: time.Unix(delivery.UpdateDate, 0).UTC(),
+	}
+}
+
+func (w *webhookDeliveries) DeliveriesToAPI(deliveries []*models.WebhookDelivery) (apiDeliveries []*api.WebhookDelivery) {
+	apiDeliveries = make([]*api.WebhookDelivery, len(deliveries))
+	for i := 0; i < len(deliveries); i++ {
+		apiDeliveries[i] = w.DeliveryToAPI(deliveries[i])
+	}
+
+	return
+}
+
+// NewWebhookDeliveries ...
+func NewWebhookDeliveries() WebhookDeliveries {
+	return &webhookDeliveries{}
+}
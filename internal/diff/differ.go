@@ -0,0 +1,47 @@
+package diff
+
+import (
+	"github.com/mts-test-task/pkg/sitesdataservice/api"
+)
+
+// Differ compares two versions of site data
+type Differ interface {
+	// Unified returns line-based unified diff of texts, empty if they are equal
+	Unified(fromName string, toName string, from string, to string) (unified string)
+	// JSON returns structural changes of JSON documents, err is returned if any of them is not valid JSON
+	JSON(from []byte, to []byte) (changes []*api.JSONChange, err error)
+}
+
+type differ struct {
+	// contextLines - число неизменных строк вокруг изменений в unified diff
+	contextLines int
+	// maxEdits - предел числа вставок и удалений, после которого поиск кратчайшего diff прекращается
+	maxEdits int
+}
+
+func (d *differ) Unified(fromName string, toName string, from string, to string) (unified string) {
+	return formatUnified(fromName, toName, diffLines(splitLines(from), splitLines(to), d.maxEdits), d.contextLines)
+}
+
+func (d *differ) JSON(from []byte, to []byte) (changes []*api.JSONChange, err error) {
+	fromValue, err := decodeJSON(from)
+	if err != nil {
+		return
+	}
+	toValue, err := decodeJSON(to)
+	if err != nil {
+		return
+	}
+
+	changes = make([]*api.JSONChange, 0)
+	err = compareJSON("", fromValue, toValue, &changes)
+	return
+}
+
+// NewDiffer ...
+func NewDiffer(contextLines int, maxEdits int) Differ {
+	return &differ{
+		contextLines: contextLines,
+		maxEdits:     maxEdits,
+	}
+}
@@ -0,0 +1,126 @@
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mts-test-task/pkg/sitesdataservice/api"
+)
+
+// Экранирование ключей в JSON Pointer (RFC 6901)
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// decodeJSON сохраняет числа как json.Number, чтобы не терять точность при сравнении
+func decodeJSON(data []byte) (value interface{}, err error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err = decoder.Decode(&value); err != nil {
+		return
+	}
+	if _, err = decoder.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after JSON value")
+	}
+	return value, nil
+}
+
+// compareJSON добавляет в changes изменения значения по пути path. Объекты сравниваются по ключам,
+// массивы - по позициям, значения разных типов заменяются целиком
+func compareJSON(path string, from interface{}, to interface{}, changes *[]*api.JSONChange) (err error) {
+	switch fromValue := from.(type) {
+	case map[string]interface{}:
+		toValue, ok := to.(map[string]interface{})
+		if !ok {
+			return addChange(changes, api.JSONChangeReplace, path, from, to)
+		}
+		for _, key := range sortedKeys(fromValue) {
+			keyPath := path + "/" + pointerEscaper.Replace(key)
+			if toKeyValue, ok := toValue[key]; ok {
+				err = compareJSON(keyPath, fromValue[key], toKeyValue, changes)
+			} else {
+				err = addChange(changes, api.JSONChangeRemove, keyPath, fromValue[key], nil)
+			}
+			if err != nil {
+				return
+			}
+		}
+		for _, key := range sortedKeys(toValue) {
+			if _, ok := fromValue[key]; ok {
+				continue
+			}
+			if err = addChange(changes, api.JSONChangeAdd, path+"/"+pointerEscaper.Replace(key), nil, toValue[key]); err != nil {
+				return
+			}
+		}
+	case []interface{}:
+		toValue, ok := to.([]interface{})
+		if !ok {
+			return addChange(changes, api.JSONChangeReplace, path, from, to)
+		}
+		for i := 0; i < len(fromValue) || i < len(toValue); i++ {
+			indexPath := path + "/" + strconv.Itoa(i)
+			switch {
+			case i >= len(toValue):
+				err = addChange(changes, api.JSONChangeRemove, indexPath, fromValue[i], nil)
+			case i >= len(fromValue):
+				err = addChange(changes, api.JSONChangeAdd, indexPath, nil, toValue[i])
+			default:
+				err = compareJSON(indexPath, fromValue[i], toValue[i], changes)
+			}
+			if err != nil {
+				return
+			}
+		}
+	case json.Number:
+		if toValue, ok := to.(json.Number); !ok || !equalNumbers(fromValue, toValue) {
+			return addChange(changes, api.JSONChangeReplace, path, from, to)
+		}
+	default:
+		if !reflect.DeepEqual(from, to) {
+			return addChange(changes, api.JSONChangeReplace, path, from, to)
+		}
+	}
+
+	return
+}
+
+// addChange пропускает пустые значения для add и remove: у добавленного нет старого значения, у удаленного - нового
+func addChange(changes *[]*api.JSONChange, op string, path string, from interface{}, to interface{}) (err error) {
+	change := &api.JSONChange{Op: op, Path: path}
+	if op != api.JSONChangeAdd {
+		if change.OldValue, err = json.Marshal(from); err != nil {
+			return
+		}
+	}
+	if op != api.JSONChangeRemove {
+		if change.NewValue, err = json.Marshal(to); err != nil {
+			return
+		}
+	}
+	*changes = append(*changes, change)
+	return
+}
+
+// equalNumbers считает равными разные записи одного числа, например 1 и 1.0
+func equalNumbers(from json.Number, to json.Number) (equal bool) {
+	if from == to {
+		return true
+	}
+	fromFloat, fromErr := from.Float64()
+	toFloat, toErr := to.Float64()
+	return fromErr == nil && toErr == nil && fromFloat == toFloat
+}
+
+func sortedKeys(object map[string]interface{}) (keys []string) {
+	keys = make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return
+}
@@ -0,0 +1,93 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mts-test-task/pkg/sitesdataservice/api"
+)
+
+func TestDiffer_JSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		want    []*api.JSONChange
+		wantErr bool
+	}{
+		{
+			name: "equal numbers in different notation",
+			from: `{"a": 1, "b": [1, 2]}`,
+			to:   `{"b": [1.0, 2], "a": 1e0}`,
+			want: []*api.JSONChange{},
+		},
+		{
+			name: "added, removed and changed keys",
+			from: `{"a": 1, "b": "x"}`,
+			to:   `{"b": "y", "c": true}`,
+			want: []*api.JSONChange{
+				{Op: api.JSONChangeRemove, Path: "/a", OldValue: []byte(`1`)},
+				{Op: api.JSONChangeReplace, Path: "/b", OldValue: []byte(`"x"`), NewValue: []byte(`"y"`)},
+				{Op: api.JSONChangeAdd, Path: "/c", NewValue: []byte(`true`)},
+			},
+		},
+		{
+			name: "nested path is escaped",
+			from: `{"a/b": {"c~d": null}}`,
+			to:   `{"a/b": {"c~d": 2}}`,
+			want: []*api.JSONChange{
+				{Op: api.JSONChangeReplace, Path: "/a~1b/c~0d", OldValue: []byte(`null`), NewValue: []byte(`2`)},
+			},
+		},
+		{
+			name: "arrays are compared by position",
+			from: `[1, 2, 3]`,
+			to:   `[1, 5]`,
+			want: []*api.JSONChange{
+				{Op: api.JSONChangeReplace, Path: "/1", OldValue: []byte(`2`), NewValue: []byte(`5`)},
+				{Op: api.JSONChangeRemove, Path: "/2", OldValue: []byte(`3`)},
+			},
+		},
+		{
+			name: "different types replace whole value",
+			from: `{"a": [1]}`,
+			to:   `{"a": {"0": 1}}`,
+			want: []*api.JSONChange{
+				{Op: api.JSONChangeReplace, Path: "/a", OldValue: []byte(`[1]`), NewValue: []byte(`{"0":1}`)},
+			},
+		},
+		{
+			name:    "invalid json",
+			from:    `{"a": 1}`,
+			to:      `{"a": `,
+			wantErr: true,
+		},
+		{
+			name:    "data after json value",
+			from:    `{"a": 1} {}`,
+			to:      `{"a": 1}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, err := NewDiffer(1, 100).JSON([]byte(tt.from), []byte(tt.to))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, len(tt.want), len(changes))
+			for i := range tt.want {
+				if i >= len(changes) {
+					break
+				}
+				assert.Equal(t, tt.want[i].Op, changes[i].Op)
+				assert.Equal(t, tt.want[i].Path, changes[i].Path)
+				assert.Equal(t, string(tt.want[i].OldValue), string(changes[i].OldValue))
+				assert.Equal(t, string(tt.want[i].NewValue), string(changes[i].NewValue))
+			}
+		})
+	}
+}
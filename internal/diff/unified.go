@@ -0,0 +1,229 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+const noNewlineMarker = "\\ No newline at end of file\n"
+
+// Виды строк diff, совпадают с префиксами строк unified diff
+const (
+	opEqual  = ' '
+	opDelete = '-'
+	opInsert = '+'
+)
+
+type edit struct {
+	op   byte
+	line string
+}
+
+// splitLines делит текст на строки вместе с переводами строк, чтобы различалось отсутствие перевода в конце
+func splitLines(text string) (lines []string) {
+	if text == "" {
+		return nil
+	}
+	lines = strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return
+}
+
+// diffLines возвращает последовательность правок, превращающую from в to. Общие начало и конец отбрасываются
+// до поиска, а если различий больше maxEdits, середина целиком заменяется
+func diffLines(from []string, to []string, maxEdits int) (edits []edit) {
+	prefix := 0
+	for prefix < len(from) && prefix < len(to) && from[prefix] == to[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(from)-prefix && suffix < len(to)-prefix && from[len(from)-1-suffix] == to[len(to)-1-suffix] {
+		suffix++
+	}
+
+	edits = make([]edit, 0, len(from)+len(to)-prefix-suffix)
+	for _, line := range from[:prefix] {
+		edits = append(edits, edit{op: opEqual, line: line})
+	}
+	fromMiddle, toMiddle := from[prefix:len(from)-suffix], to[prefix:len(to)-suffix]
+	middle, ok := myers(fromMiddle, toMiddle, maxEdits)
+	if !ok {
+		middle = middle[:0]
+		for _, line := range fromMiddle {
+			middle = append(middle, edit{op: opDelete, line: line})
+		}
+		for _, line := range toMiddle {
+			middle = append(middle, edit{op: opInsert, line: line})
+		}
+	}
+	edits = append(edits, middle...)
+	for _, line := range from[len(from)-suffix:] {
+		edits = append(edits, edit{op: opEqual, line: line})
+	}
+
+	return
+}
+
+// myers ищет кратчайший diff алгоритмом Майерса. Память растет квадратично от числа правок,
+// поэтому при числе правок больше maxEdits поиск прекращается и возвращается false
+func myers(from []string, to []string, maxEdits int) (edits []edit, ok bool) {
+	n, m := len(from), len(to)
+	limit := n + m
+	if limit > maxEdits {
+		limit = maxEdits
+	}
+
+	// v[offset+k] - самый дальний x на диагонали k, trace[d] - окно v с диагоналями [-d-1, d+1] перед шагом d
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	trace := make([][]int, 0)
+	for d := 0; d <= limit; d++ {
+		window := make([]int, 2*d+3)
+		copy(window, v[offset-d-1:offset+d+2])
+		trace = append(trace, window)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && from[x] == to[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				return backtrack(from, to, trace), true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// backtrack восстанавливает правки по сохраненным окнам, проходя путь от конца к началу
+func backtrack(from []string, to []string, trace [][]int) (edits []edit) {
+	x, y := len(from), len(to)
+	edits = make([]edit, 0, x+y)
+	for d := len(trace) - 1; d > 0; d-- {
+		window := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && window[k-1+d+1] < window[k+1+d+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := window[prevK+d+1]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			edits = append(edits, edit{op: opEqual, line: from[x-1]})
+			x--
+			y--
+		}
+		if x == prevX {
+			edits = append(edits, edit{op: opInsert, line: to[y-1]})
+			y--
+		} else {
+			edits = append(edits, edit{op: opDelete, line: from[x-1]})
+			x--
+		}
+	}
+	for x > 0 && y > 0 {
+		edits = append(edits, edit{op: opEqual, line: from[x-1]})
+		x--
+		y--
+	}
+
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return
+}
+
+// formatUnified собирает правки в блоки с contextLines неизменных строк вокруг изменений.
+// Блоки, между которыми не больше 2*contextLines неизменных строк, объединяются
+func formatUnified(fromName string, toName string, edits []edit, contextLines int) (unified string) {
+	// fromLines[i] и toLines[i] - число строк from и to до правки i
+	fromLines := make([]int, len(edits)+1)
+	toLines := make([]int, len(edits)+1)
+	for i, e := range edits {
+		fromLines[i+1], toLines[i+1] = fromLines[i], toLines[i]
+		if e.op != opInsert {
+			fromLines[i+1]++
+		}
+		if e.op != opDelete {
+			toLines[i+1]++
+		}
+	}
+
+	var b strings.Builder
+	end := 0
+	for i := 0; i < len(edits); {
+		if edits[i].op == opEqual {
+			i++
+			continue
+		}
+
+		start := i - contextLines
+		if start < end {
+			start = end
+		}
+		end = i
+		for {
+			for end < len(edits) && edits[end].op != opEqual {
+				end++
+			}
+			next := end
+			for next < len(edits) && edits[next].op == opEqual {
+				next++
+			}
+			if next == len(edits) || next-end > 2*contextLines {
+				end += contextLines
+				if end > next {
+					end = next
+				}
+				break
+			}
+			end = next
+		}
+
+		if b.Len() == 0 {
+			fmt.Fprintf(&b, "--- %s\n+++ %s\n", fromName, toName)
+		}
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n",
+			hunkRange(fromLines[start], fromLines[end]-fromLines[start]),
+			hunkRange(toLines[start], toLines[end]-toLines[start]),
+		)
+		for _, e := range edits[start:end] {
+			b.WriteByte(e.op)
+			b.WriteString(e.line)
+			if !strings.HasSuffix(e.line, "\n") {
+				b.WriteString("\n")
+				b.WriteString(noNewlineMarker)
+			}
+		}
+		i = end
+	}
+
+	return b.String()
+}
+
+// hunkRange форматирует диапазон строк блока, пустой диапазон указывает на строку перед ним
+func hunkRange(before int, count int) (hunkRange string) {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", before)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", before+1)
+	}
+	return fmt.Sprintf("%d,%d", before+1, count)
+}
@@ -0,0 +1,73 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffer_Unified(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxEdits int
+		from     string
+		to       string
+		want     string
+	}{
+		{
+			name: "equal",
+			from: "a\nb\n",
+			to:   "a\nb\n",
+		},
+		{
+			name: "changed line",
+			from: "a\nb\nc\n",
+			to:   "a\nx\nc\n",
+			want: "--- from\n+++ to\n@@ -1,3 +1,3 @@\n a\n-b\n+x\n c\n",
+		},
+		{
+			name: "insert into empty",
+			to:   "a\n",
+			want: "--- from\n+++ to\n@@ -0,0 +1 @@\n+a\n",
+		},
+		{
+			name: "delete all",
+			from: "a\nb\n",
+			want: "--- from\n+++ to\n@@ -1,2 +0,0 @@\n-a\n-b\n",
+		},
+		{
+			name: "missing newline at end",
+			from: "a",
+			to:   "a\n",
+			want: "--- from\n+++ to\n@@ -1 +1 @@\n-a\n\\ No newline at end of file\n+a\n",
+		},
+		{
+			name: "distant changes in separate hunks",
+			from: "a\nb\nc\nd\ne\nf\ng\nh\n",
+			to:   "A\nb\nc\nd\ne\nf\ng\nH\n",
+			want: "--- from\n+++ to\n@@ -1,2 +1,2 @@\n-a\n+A\n b\n@@ -7,2 +7,2 @@\n g\n-h\n+H\n",
+		},
+		{
+			name: "close changes in one hunk",
+			from: "a\nb\nc\nd\n",
+			to:   "A\nb\nc\nD\n",
+			want: "--- from\n+++ to\n@@ -1,4 +1,4 @@\n-a\n+A\n b\n c\n-d\n+D\n",
+		},
+		{
+			name:     "too many edits replace the middle",
+			maxEdits: 1,
+			from:     "a\nb\nc\n",
+			to:       "x\nb\ny\n",
+			want:     "--- from\n+++ to\n@@ -1,3 +1,3 @@\n-a\n-b\n-c\n+x\n+b\n+y\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			maxEdits := tt.maxEdits
+			if maxEdits == 0 {
+				maxEdits = 100
+			}
+			assert.Equal(t, tt.want, NewDiffer(1, maxEdits).Unified("from", "to", tt.from, tt.to))
+		})
+	}
+}
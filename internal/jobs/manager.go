@@ -0,0 +1,442 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/mts-test-task/internal/storages/mongodb/models"
+	"github.com/mts-test-task/internal/workerpool"
+	"github.com/mts-test-task/pkg/sitesdataservice/api"
+	"github.com/mts-test-task/pkg/sitesdataservice/httperror"
+)
+
+// Fetcher gets data for a chunk of job urls
+type Fetcher interface {
+	GetDataFromURLsWithOptions(ctx context.Context, requests []*api.SiteRequest, options *api.FetchOptions) (response []*api.SiteData, err error)
+}
+
+type jobsMongoObjectsBuilder interface {
+	NewJob(id string, request *api.CreateJobRequest, createTime int64) (job *models.Job)
+	JobFilter(id string, statuses ...string) (filter bson.M)
+	JobsFilter(statuses ...string) (filter bson.M)
+	JobIDsOptions() (findOptions *options.FindOptions)
+	JobWithoutURLsOptions() (findOptions *options.FindOneOptions)
+	JobUpdateOptions(withURLs bool) (updateOptions *options.FindOneAndUpdateOptions)
+	JobStatusUpdate(status string, jobError string, updateTime int64) (update bson.M)
+	JobProgressUpdate(processed int, failed int, updateTime int64) (update bson.M)
+	JobResults(jobID string, offset int, sitesData []*api.SiteData) (results []mongo.WriteModel)
+	JobResultsFilter(jobID string) (filter bson.M)
+	JobResultsPageOptions(offset int, limit int) (findOptions *options.FindOptions)
+}
+
+type jobsMongoWrapper interface {
+	AddJob(ctx context.Context, job interface{}) (err error)
+	GetJob(ctx context.Context, filter bson.M, findOptions *options.FindOneOptions) (job *models.Job, err error)
+	GetJobs(ctx context.Context, filter bson.M, findOptions *options.FindOptions) (jobs []*models.Job, err error)
+	UpdateJob(ctx context.Context, filter bson.M, update bson.M, updateOptions *options.FindOneAndUpdateOptions) (job *models.Job, err error)
+	UpsertJobResults(ctx context.Context, results []mongo.WriteModel) (err error)
+	GetJobResults(ctx context.Context, filter bson.M, findOptions *options.FindOptions) (results []*models.JobResult, err error)
+}
+
+type jobsConverter interface {
+	JobToAPI(job *models.Job) (apiJob *api.Job)
+	JobResultsToSitesData(results []*models.JobResult) (sitesData []*api.SiteData)
+}
+
+type webhookSender interface {
+	Send(ctx context.Context, callbackURL string, payload interface{}) (err error)
+}
+
+// Manager stores batch jobs in mongo and processes them in background
+type Manager interface {
+	Create(ctx context.Context, request *api.CreateJobRequest) (job *api.Job, err error)
+	Get(ctx context.Context, id string, offset int, limit int) (response *api.GetJobResponse, err error)
+	Cancel(ctx context.Context, id string) (job *api.Job, err error)
+	// Run starts workers and blocks until ctx is done
+	Run(ctx context.Context, fetcher Fetcher)
+}
+
+type manager struct {
+	jobsMongoObjectsBuilder jobsMongoObjectsBuilder
+	jobsMongoWrapper        jobsMongoWrapper
+	jobsConverter           jobsConverter
+	webhookSender           webhookSender
+	errorCreator            httperror.ErrorCreator
+	logger                  log.Logger
+	workers                 int
+	chunkSize               int
+	pollInterval            time.Duration
+	defaultPageLimit        int
+	maxPageLimit            int
+	// resultsURLTemplate - адрес страниц результатов задания для обратного вызова, %s - идентификатор задания
+	resultsURLTemplate string
+
+	queue   chan string
+	mu      sync.Mutex
+	queued  map[string]struct{}
+	cancels map[string]context.CancelFunc
+}
+
+func (m *manager) Create(ctx context.Context, request *api.CreateJobRequest) (job *api.Job, err error) {
+	storedJob := m.jobsMongoObjectsBuilder.NewJob(primitive.NewObjectID().Hex(), request, time.Now().Unix())
+
+	if err = m.jobsMongoWrapper.AddJob(ctx, storedJob); err != nil {
+		return nil, m.errorCreator(
+			http.StatusInternalServerError,
+			"Не удалось создать задание",
+			fmt.Sprintf("failed to add job: %s", err),
+		)
+	}
+
+	// Если очередь заполнена, задание подхватит периодическая проверка
+	m.enqueue(storedJob.ID)
+
+	return m.jobsConverter.JobToAPI(storedJob), nil
+}
+
+func (m *manager) Get(ctx context.Context, id string, offset int, limit int) (response *api.GetJobResponse, err error) {
+	if limit <= 0 {
+		limit = m.defaultPageLimit
+	}
+	if limit > m.maxPageLimit {
+		limit = m.maxPageLimit
+	}
+
+	job, err := m.jobsMongoWrapper.GetJob(ctx, m.jobsMongoObjectsBuilder.JobFilter(id), m.jobsMongoObjectsBuilder.JobWithoutURLsOptions())
+	if err != nil {
+		return nil, m.errorCreator(
+			http.StatusInternalServerError,
+			"Не удалось получить задание",
+			fmt.Sprintf("failed to get job %s: %s", id, err),
+		)
+	}
+	if job == nil {
+		return nil, m.notFound(id)
+	}
+
+	results, err := m.jobsMongoWrapper.GetJobResults(
+		ctx,
+		m.jobsMongoObjectsBuilder.JobResultsFilter(id),
+		m.jobsMongoObjectsBuilder.JobResultsPageOptions(offset, limit),
+	)
+	if err != nil {
+		return nil, m.errorCreator(
+			http.StatusInternalServerError,
+			"Не удалось получить результаты задания",
+			fmt.Sprintf("failed to get job %s results: %s", id, err),
+		)
+	}
+
+	return &api.GetJobResponse{
+		Job:     m.jobsConverter.JobToAPI(job),
+		Results: m.jobsConverter.JobResultsToSitesData(results),
+		Offset:  offset,
+		Limit:   limit,
+	}, nil
+}
+
+func (m *manager) Cancel(ctx context.Context, id string) (job *api.Job, err error) {
+	storedJob, err := m.jobsMongoWrapper.UpdateJob(
+		ctx,
+		m.jobsMongoObjectsBuilder.JobFilter(id, api.JobStatusPending, api.JobStatusRunning),
+		m.jobsMongoObjectsBuilder.JobStatusUpdate(api.JobStatusCancelled, "", time.Now().Unix()),
+		m.jobsMongoObjectsBuilder.JobUpdateOptions(false),
+	)
+	if err != nil {
+		return nil, m.errorCreator(
+			http.StatusInternalServerError,
+			"Не удалось отменить задание",
+			fmt.Sprintf("failed to cancel job %s: %s", id, err),
+		)
+	}
+
+	if storedJob != nil {
+		m.mu.Lock()
+		if cancel, ok := m.cancels[id]; ok {
+			cancel()
+		}
+		m.mu.Unlock()
+
+		return m.jobsConverter.JobToAPI(storedJob), nil
+	}
+
+	// Задание уже завершено или не существует
+	storedJob, err = m.jobsMongoWrapper.GetJob(ctx, m.jobsMongoObjectsBuilder.JobFilter(id), m.jobsMongoObjectsBuilder.JobWithoutURLsOptions())
+	if err != nil {
+		return nil, m.errorCreator(
+			http.StatusInternalServerError,
+			"Не удалось отменить задание",
+			fmt.Sprintf("failed to get job %s: %s", id, err),
+		)
+	}
+	if storedJob == nil {
+		return nil, m.notFound(id)
+	}
+
+	return m.jobsConverter.JobToAPI(storedJob), nil
+}
+
+func (m *manager) Run(ctx context.Context, fetcher Fetcher) {
+	var wg sync.WaitGroup
+	for i := 0; i < m.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.work(ctx, fetcher)
+		}()
+	}
+
+	// Сразу после запуска подхватываем задания, которые не успели выполниться до остановки сервиса
+	m.enqueueUnfinished(ctx)
+
+	ticker := time.NewTicker(m.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case <-ticker.C:
+			m.enqueueUnfinished(ctx)
+		}
+	}
+}
+
+// enqueueUnfinished ставит в очередь ожидающие и прерванные задания
+func (m *manager) enqueueUnfinished(ctx context.Context) {
+	jobs, err := m.jobsMongoWrapper.GetJobs(
+		ctx,
+		m.jobsMongoObjectsBuilder.JobsFilter(api.JobStatusPending, api.JobStatusRunning),
+		m.jobsMongoObjectsBuilder.JobIDsOptions(),
+	)
+	if err != nil {
+		_ = level.Error(m.logger).Log("msg", "Failed to get unfinished jobs:", "err", err)
+		return
+	}
+
+	for i := range jobs {
+		if !m.enqueue(jobs[i].ID) {
+			return
+		}
+	}
+}
+
+// enqueue ставит задание в очередь, если его там еще нет. Возвращает false, если очередь заполнена
+func (m *manager) enqueue(id string) (ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.queued[id]; ok {
+		return true
+	}
+
+	select {
+	case m.queue <- id:
+		m.queued[id] = struct{}{}
+		return true
+	default:
+		return false
+	}
+}
+
+func (m *manager) work(ctx context.Context, fetcher Fetcher) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case id := <-m.queue:
+			m.process(ctx, fetcher, id)
+
+			m.mu.Lock()
+			delete(m.queued, id)
+			m.mu.Unlock()
+		}
+	}
+}
+
+// process обрабатывает урлы задания порциями, начиная с первого необработанного
+func (m *manager) process(ctx context.Context, fetcher Fetcher, id string) {
+	jobCtx, cancel := context.WithCancel(ctx)
+	m.mu.Lock()
+	m.cancels[id] = cancel
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		delete(m.cancels, id)
+		m.mu.Unlock()
+		cancel()
+	}()
+
+	job, err := m.jobsMongoWrapper.UpdateJob(
+		ctx,
+		m.jobsMongoObjectsBuilder.JobFilter(id, api.JobStatusPending, api.JobStatusRunning),
+		m.jobsMongoObjectsBuilder.JobStatusUpdate(api.JobStatusRunning, "", time.Now().Unix()),
+		m.jobsMongoObjectsBuilder.JobUpdateOptions(true),
+	)
+	if err != nil {
+		_ = level.Error(m.logger).Log("msg", "Failed to start job:", "job", id, "err", err)
+		return
+	}
+	// Задание отменено до начала обработки
+	if job == nil {
+		return
+	}
+
+	fetchOptions := &api.FetchOptions{FailureMode: api.FailureModePartial}
+	if job.Options != nil {
+		if job.Options.FailureMode != "" {
+			fetchOptions.FailureMode = job.Options.FailureMode
+		}
+		fetchOptions.TimeoutMs = job.Options.TimeoutMs
+		fetchOptions.MaxCacheAgeMs = job.Options.MaxCacheAgeMs
+		fetchOptions.RedirectPolicy = job.Options.RedirectPolicy
+		fetchOptions.MaxRedirects = job.Options.MaxRedirects
+		fetchOptions.MaxBodySize = job.Options.MaxBodySize
+		fetchOptions.BodyLimitMode = job.Options.BodyLimitMode
+		fetchOptions.RawBody = job.Options.RawBody
+		fetchOptions.BinaryContent = job.Options.BinaryContent
+		fetchOptions.Headers = job.Options.Headers
+	}
+
+	for offset := job.Processed; offset < len(job.URLs); offset += m.chunkSize {
+		end := offset + m.chunkSize
+		if end > len(job.URLs) {
+			end = len(job.URLs)
+		}
+
+		response, err := fetcher.GetDataFromURLsWithOptions(jobCtx, api.SiteRequestsFromURLs(job.URLs[offset:end]), fetchOptions)
+		// Задание отменено или сервис останавливается: статус уже выставлен либо задание продолжится после перезапуска
+		if jobCtx.Err() != nil {
+			return
+		}
+		// Пул воркеров перегружен: задание остается в работе и продолжится при следующем опросе.
+		// Остальные ошибки, в том числе 503 по отдельному урлу, завершают задание
+		if errors.Is(err, workerpool.ErrOverloaded) {
+			_ = level.Info(m.logger).Log("msg", "Fetch pool is overloaded, job is postponed:", "job", id)
+			return
+		}
+		if err != nil {
+			m.finish(ctx, id, api.JobStatusFailed, errorMessage(err))
+			return
+		}
+
+		if err = m.jobsMongoWrapper.UpsertJobResults(ctx, m.jobsMongoObjectsBuilder.JobResults(id, offset, response)); err != nil {
+			_ = level.Error(m.logger).Log("msg", "Failed to put job results:", "job", id, "err", err)
+			return
+		}
+
+		failed := 0
+		for i := range response {
+			if response[i].Status == api.SiteDataStatusError {
+				failed++
+			}
+		}
+		_, err = m.jobsMongoWrapper.UpdateJob(
+			ctx,
+			m.jobsMongoObjectsBuilder.JobFilter(id, api.JobStatusRunning),
+			m.jobsMongoObjectsBuilder.JobProgressUpdate(end, failed, time.Now().Unix()),
+			m.jobsMongoObjectsBuilder.JobUpdateOptions(false),
+		)
+		if err != nil {
+			_ = level.Error(m.logger).Log("msg", "Failed to update job progress:", "job", id, "err", err)
+			return
+		}
+	}
+
+	m.finish(ctx, id, api.JobStatusDone, "")
+}
+
+func (m *manager) finish(ctx context.Context, id string, status string, jobError string) {
+	job, err := m.jobsMongoWrapper.UpdateJob(
+		ctx,
+		m.jobsMongoObjectsBuilder.JobFilter(id, api.JobStatusRunning),
+		m.jobsMongoObjectsBuilder.JobStatusUpdate(status, jobError, time.Now().Unix()),
+		m.jobsMongoObjectsBuilder.JobUpdateOptions(false),
+	)
+	if err != nil {
+		_ = level.Error(m.logger).Log("msg", "Failed to finish job:", "job", id, "status", status, "err", err)
+		return
+	}
+	if job == nil || job.CallbackURL == "" {
+		return
+	}
+
+	m.notify(ctx, job)
+}
+
+// notify отправляет итог завершенного задания на адрес обратного вызова. Результаты не отправляются:
+// вместе с данными урлов они могут быть больше допустимого размера документа монги, в которой хранятся
+// недоставленные вебхуки, поэтому получатель читает их постранично по ссылке
+func (m *manager) notify(ctx context.Context, job *models.Job) {
+	payload := &api.JobCallback{
+		Job:        m.jobsConverter.JobToAPI(job),
+		ResultsURL: fmt.Sprintf(m.resultsURLTemplate, url.PathEscape(job.ID)),
+	}
+	if err := m.webhookSender.Send(ctx, job.CallbackURL, payload); err != nil {
+		_ = level.Error(m.logger).Log("msg", "Failed to deliver job callback:", "job", job.ID, "url", job.CallbackURL, "err", err)
+	}
+}
+
+func (m *manager) notFound(id string) error {
+	return m.errorCreator(
+		http.StatusNotFound,
+		fmt.Sprintf("Задание %s не найдено", id),
+		fmt.Sprintf("job %s not found", id),
+	)
+}
+
+// errorMessage возвращает сообщение ошибки для пользователя, если оно есть
+func errorMessage(err error) string {
+	if e, ok := err.(*httperror.Error); ok {
+		return e.Message
+	}
+	return err.Error()
+}
+
+// NewManager ...
+func NewManager(
+	jobsMongoObjectsBuilder jobsMongoObjectsBuilder,
+	jobsMongoWrapper jobsMongoWrapper,
+	jobsConverter jobsConverter,
+	webhookSender webhookSender,
+	errorCreator httperror.ErrorCreator,
+	logger log.Logger,
+	workers int,
+	queueSize int,
+	chunkSize int,
+	pollInterval time.Duration,
+	defaultPageLimit int,
+	maxPageLimit int,
+	resultsURLTemplate string,
+) Manager {
+	return &manager{
+		jobsMongoObjectsBuilder: jobsMongoObjectsBuilder,
+		jobsMongoWrapper:        jobsMongoWrapper,
+		jobsConverter:           jobsConverter,
+		webhookSender:           webhookSender,
+		errorCreator:            errorCreator,
+		logger:                  logger,
+		workers:                 workers,
+		chunkSize:               chunkSize,
+		pollInterval:            pollInterval,
+		defaultPageLimit:        defaultPageLimit,
+		maxPageLimit:            maxPageLimit,
+		resultsURLTemplate:      resultsURLTemplate,
+		queue:                   make(chan string, queueSize),
+		queued:                  make(map[string]struct{}),
+		cancels:                 make(map[string]context.CancelFunc),
+	}
+}
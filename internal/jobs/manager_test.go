@@ -0,0 +1,235 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/mts-test-task/internal/converter"
+	"github.com/mts-test-task/internal/storages/mongodb/builder"
+	"github.com/mts-test-task/internal/storages/mongodb/models"
+	"github.com/mts-test-task/internal/workerpool"
+	"github.com/mts-test-task/pkg/sitesdataservice/api"
+	"github.com/mts-test-task/pkg/sitesdataservice/httperror"
+)
+
+const testJobID = "5f0c6a1e2b3c4d5e6f708192"
+
+// jobsBuilderStub запоминает статусы, которые менеджер выставляет заданию
+type jobsBuilderStub struct {
+	builder.JobsMongoObjects
+	statuses []string
+	errors   []string
+}
+
+func (b *jobsBuilderStub) JobStatusUpdate(status string, jobError string, updateTime int64) (update bson.M) {
+	b.statuses = append(b.statuses, status)
+	b.errors = append(b.errors, jobError)
+	return b.JobsMongoObjects.JobStatusUpdate(status, jobError, updateTime)
+}
+
+type jobsWrapperStub struct {
+	job     *models.Job
+	results int
+}
+
+func (w *jobsWrapperStub) AddJob(ctx context.Context, job interface{}) (err error) {
+	return nil
+}
+
+func (w *jobsWrapperStub) GetJob(ctx context.Context, filter bson.M, findOptions *options.FindOneOptions) (job *models.Job, err error) {
+	return w.job, nil
+}
+
+func (w *jobsWrapperStub) GetJobs(ctx context.Context, filter bson.M, findOptions *options.FindOptions) (jobs []*models.Job, err error) {
+	return nil, nil
+}
+
+func (w *jobsWrapperStub) UpdateJob(ctx context.Context, filter bson.M, update bson.M, updateOptions *options.FindOneAndUpdateOptions) (job *models.Job, err error) {
+	return w.job, nil
+}
+
+func (w *jobsWrapperStub) UpsertJobResults(ctx context.Context, results []mongo.WriteModel) (err error) {
+	w.results += len(results)
+	return nil
+}
+
+func (w *jobsWrapperStub) GetJobResults(ctx context.Context, filter bson.M, findOptions *options.FindOptions) (results []*models.JobResult, err error) {
+	return nil, nil
+}
+
+type webhookSenderStub struct {
+	mu       sync.Mutex
+	payloads []interface{}
+}
+
+func (s *webhookSenderStub) Send(ctx context.Context, callbackURL string, payload interface{}) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.payloads = append(s.payloads, payload)
+	return nil
+}
+
+type fetcherFunc func(ctx context.Context, requests []*api.SiteRequest, options *api.FetchOptions) ([]*api.SiteData, error)
+
+func (f fetcherFunc) GetDataFromURLsWithOptions(ctx context.Context, requests []*api.SiteRequest, options *api.FetchOptions) ([]*api.SiteData, error) {
+	return f(ctx, requests, options)
+}
+
+func newTestManager(wrapper *jobsWrapperStub, jobsBuilder *jobsBuilderStub, sender *webhookSenderStub) *manager {
+	return NewManager(
+		jobsBuilder,
+		wrapper,
+		converter.NewJobs(),
+		sender,
+		httperror.NewError,
+		log.NewNopLogger(),
+		1,
+		10,
+		2,
+		time.Minute,
+		10,
+		100,
+		"https://sites.example.com/api/v1/jobs/%s",
+	).(*manager)
+}
+
+func TestManager_Process(t *testing.T) {
+	urls := []string{"http://a.example.com", "http://b.example.com", "http://c.example.com", "http://d.example.com", "http://e.example.com"}
+	tests := []struct {
+		name      string
+		job       *models.Job
+		fetchErr  error
+		wantFirst []string
+		wantCalls int
+		// wantStatuses - статусы, выставленные заданию: в работе и итоговый
+		wantStatuses []string
+		wantError    string
+		wantCallback bool
+	}{
+		{
+			name:         "all chunks",
+			job:          &models.Job{ID: testJobID, URLs: urls, Total: len(urls), CallbackURL: "https://hooks.example.com"},
+			wantFirst:    []string{urls[0], urls[2], urls[4]},
+			wantCalls:    3,
+			wantStatuses: []string{api.JobStatusRunning, api.JobStatusDone},
+			wantCallback: true,
+		},
+		{
+			name:         "resumes from processed",
+			job:          &models.Job{ID: testJobID, URLs: urls, Total: len(urls), Processed: 2},
+			wantFirst:    []string{urls[2], urls[4]},
+			wantCalls:    2,
+			wantStatuses: []string{api.JobStatusRunning, api.JobStatusDone},
+		},
+		{
+			name:         "fetch error fails job",
+			job:          &models.Job{ID: testJobID, URLs: urls, Total: len(urls), CallbackURL: "https://hooks.example.com"},
+			fetchErr:     httperror.NewError(http.StatusBadGateway, "Не удалось получить данные", "bad gateway"),
+			wantFirst:    []string{urls[0]},
+			wantCalls:    1,
+			wantStatuses: []string{api.JobStatusRunning, api.JobStatusFailed},
+			wantError:    "Не удалось получить данные",
+			wantCallback: true,
+		},
+		{
+			name:         "overloaded pool postpones job",
+			job:          &models.Job{ID: testJobID, URLs: urls, Total: len(urls), CallbackURL: "https://hooks.example.com"},
+			fetchErr:     httperror.WithCause(httperror.NewError(http.StatusServiceUnavailable, "Сервис перегружен", "overloaded"), workerpool.ErrOverloaded),
+			wantFirst:    []string{urls[0]},
+			wantCalls:    1,
+			wantStatuses: []string{api.JobStatusRunning},
+		},
+		{
+			name:         "unavailable url fails job",
+			job:          &models.Job{ID: testJobID, URLs: urls, Total: len(urls)},
+			fetchErr:     httperror.NewError(http.StatusServiceUnavailable, "robots.txt сайта недоступен", "robots unavailable"),
+			wantFirst:    []string{urls[0]},
+			wantCalls:    1,
+			wantStatuses: []string{api.JobStatusRunning, api.JobStatusFailed},
+			wantError:    "robots.txt сайта недоступен",
+		},
+		{
+			name:         "cancelled before start",
+			wantStatuses: []string{api.JobStatusRunning},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wrapper := &jobsWrapperStub{job: tt.job}
+			jobsBuilder := &jobsBuilderStub{JobsMongoObjects: builder.NewJobsMongoObjects()}
+			sender := &webhookSenderStub{}
+			m := newTestManager(wrapper, jobsBuilder, sender)
+
+			var first []string
+			m.process(context.Background(), fetcherFunc(func(ctx context.Context, requests []*api.SiteRequest, options *api.FetchOptions) ([]*api.SiteData, error) {
+				first = append(first, requests[0].URL)
+				assert.Equal(t, api.FailureModePartial, options.FailureMode)
+				if tt.fetchErr != nil {
+					return nil, tt.fetchErr
+				}
+				response := make([]*api.SiteData, len(requests))
+				for i := range requests {
+					response[i] = &api.SiteData{URL: requests[i].URL, Status: api.SiteDataStatusOK}
+				}
+				return response, nil
+			}), testJobID)
+
+			assert.Equal(t, tt.wantFirst, first)
+			assert.Len(t, first, tt.wantCalls)
+			assert.Equal(t, tt.wantStatuses, jobsBuilder.statuses)
+			assert.Equal(t, tt.wantError, jobsBuilder.errors[len(jobsBuilder.errors)-1])
+			if !tt.wantCallback {
+				assert.Empty(t, sender.payloads)
+				return
+			}
+			if assert.Len(t, sender.payloads, 1) {
+				callback := sender.payloads[0].(*api.JobCallback)
+				assert.Equal(t, testJobID, callback.Job.ID)
+				assert.Equal(t, "https://sites.example.com/api/v1/jobs/"+testJobID, callback.ResultsURL)
+			}
+		})
+	}
+}
+
+func TestManager_GetPageLimit(t *testing.T) {
+	tests := []struct {
+		name      string
+		limit     int
+		wantLimit int
+	}{
+		{name: "default", limit: 0, wantLimit: 10},
+		{name: "requested", limit: 50, wantLimit: 50},
+		{name: "capped", limit: 1000, wantLimit: 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wrapper := &jobsWrapperStub{job: &models.Job{ID: testJobID, Status: api.JobStatusDone}}
+			m := newTestManager(wrapper, &jobsBuilderStub{JobsMongoObjects: builder.NewJobsMongoObjects()}, &webhookSenderStub{})
+
+			response, err := m.Get(context.Background(), testJobID, 0, tt.limit)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.wantLimit, response.Limit)
+			}
+		})
+	}
+}
+
+func TestManager_GetNotFound(t *testing.T) {
+	m := newTestManager(&jobsWrapperStub{}, &jobsBuilderStub{JobsMongoObjects: builder.NewJobsMongoObjects()}, &webhookSenderStub{})
+
+	_, err := m.Get(context.Background(), testJobID, 0, 10)
+	var serviceErr *httperror.Error
+	if assert.True(t, errors.As(err, &serviceErr)) {
+		assert.Equal(t, http.StatusNotFound, serviceErr.Code)
+	}
+}
@@ -0,0 +1,133 @@
+package netguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress is returned for hosts and addresses of internal networks
+var ErrForbiddenAddress = errors.New("address of internal network is forbidden")
+
+// Внутренние сети, в которые сервис не должен отправлять запросы по адресам от пользователей
+var forbiddenNetworks = mustParseCIDRs(
+	"0.0.0.0/8",      // текущая сеть
+	"10.0.0.0/8",     // RFC 1918
+	"100.64.0.0/10",  // shared address space (CGNAT)
+	"127.0.0.0/8",    // loopback
+	"169.254.0.0/16", // link-local, в том числе адреса метаданных облаков
+	"172.16.0.0/12",  // RFC 1918
+	"192.0.0.0/24",   // IETF protocol assignments
+	"192.168.0.0/16", // RFC 1918
+	"198.18.0.0/15",  // benchmarking
+	"224.0.0.0/4",    // multicast
+	"240.0.0.0/4",    // reserved и broadcast
+	"::/128",         // unspecified
+	"::1/128",        // loopback
+	"64:ff9b::/96",   // NAT64, может указывать на внутренние IPv4
+	"fc00::/7",       // unique local
+	"fe80::/10",      // link-local
+	"ff00::/8",       // multicast
+)
+
+// Guard rejects requests to internal networks unless the host is explicitly allowed
+type Guard interface {
+	// CheckHost rejects hosts which are known to be internal without resolving them
+	CheckHost(host string) (err error)
+	// DialContext dials allowed hosts as is and checks other hosts after DNS resolution
+	DialContext(ctx context.Context, network string, address string) (conn net.Conn, err error)
+}
+
+type guard struct {
+	allowedHosts map[string]bool
+	dialer       *net.Dialer
+}
+
+func (g *guard) CheckHost(host string) (err error) {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if g.allowedHosts[host] {
+		return nil
+	}
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+	}
+	if ip := net.ParseIP(strings.Trim(host, "[]")); ip != nil && IsForbiddenIP(ip) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+	}
+
+	return nil
+}
+
+func (g *guard) DialContext(ctx context.Context, network string, address string) (conn net.Conn, err error) {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	if g.allowedHosts[strings.ToLower(host)] {
+		return (&net.Dialer{Timeout: g.dialer.Timeout, KeepAlive: g.dialer.KeepAlive}).DialContext(ctx, network, address)
+	}
+
+	return g.dialer.DialContext(ctx, network, address)
+}
+
+// control проверяет адрес после разрешения имени, поэтому DNS не может подменить адрес на внутренний
+func control(network string, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || IsForbiddenIP(ip) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+	}
+
+	return nil
+}
+
+// IsForbiddenIP reports whether ip belongs to loopback, private, link-local or another internal network
+func IsForbiddenIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	for _, network := range forbiddenNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func mustParseCIDRs(cidrs ...string) (networks []*net.IPNet) {
+	networks = make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+
+	return
+}
+
+// NewGuard creates guard, allowedHosts are trusted hosts which may resolve to internal addresses
+func NewGuard(allowedHosts []string, dialTimeout time.Duration) Guard {
+	allowed := make(map[string]bool, len(allowedHosts))
+	for _, host := range allowedHosts {
+		allowed[strings.ToLower(host)] = true
+	}
+
+	return &guard{
+		allowedHosts: allowed,
+		dialer: &net.Dialer{
+			Timeout:   dialTimeout,
+			KeepAlive: 30 * time.Second,
+			Control:   control,
+		},
+	}
+}
@@ -0,0 +1,104 @@
+package netguard
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsForbiddenIP(t *testing.T) {
+	tests := []struct {
+		ip        string
+		forbidden bool
+	}{
+		{ip: "127.0.0.1", forbidden: true},
+		{ip: "10.1.2.3", forbidden: true},
+		{ip: "172.16.0.1", forbidden: true},
+		{ip: "172.32.0.1"},
+		{ip: "192.168.1.1", forbidden: true},
+		{ip: "169.254.169.254", forbidden: true},
+		{ip: "100.64.0.1", forbidden: true},
+		{ip: "0.0.0.0", forbidden: true},
+		{ip: "::1", forbidden: true},
+		{ip: "::ffff:127.0.0.1", forbidden: true},
+		{ip: "fd00::1", forbidden: true},
+		{ip: "fe80::1", forbidden: true},
+		{ip: "8.8.8.8"},
+		{ip: "2a00:1450:4010::8a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			assert.Equal(t, tt.forbidden, IsForbiddenIP(net.ParseIP(tt.ip)))
+		})
+	}
+}
+
+func TestGuard_CheckHost(t *testing.T) {
+	tests := []struct {
+		host      string
+		forbidden bool
+	}{
+		{host: "localhost", forbidden: true},
+		{host: "LOCALHOST.", forbidden: true},
+		{host: "api.localhost", forbidden: true},
+		{host: "127.0.0.1", forbidden: true},
+		{host: "[::1]", forbidden: true},
+		{host: "169.254.169.254", forbidden: true},
+		{host: "10.0.0.5"},
+		{host: "example.com"},
+		{host: "8.8.8.8"},
+	}
+	// 10.0.0.5 явно разрешен
+	guard := NewGuard([]string{"10.0.0.5"}, time.Second)
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			err := guard.CheckHost(tt.host)
+			assert.Equal(t, tt.forbidden, errors.Is(err, ErrForbiddenAddress), "unexpected error: %v", err)
+		})
+	}
+}
+
+func TestGuard_DialContext(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.Close()
+		}
+	}()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	tests := []struct {
+		name      string
+		allowed   []string
+		address   string
+		forbidden bool
+	}{
+		{name: "loopback ip", address: "127.0.0.1:" + port, forbidden: true},
+		{name: "name resolved to loopback", address: "localhost:" + port, forbidden: true},
+		{name: "allowed host", allowed: []string{"127.0.0.1"}, address: "127.0.0.1:" + port},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, err := NewGuard(tt.allowed, time.Second).DialContext(context.Background(), "tcp", tt.address)
+			if tt.forbidden {
+				assert.True(t, errors.Is(err, ErrForbiddenAddress), "unexpected error: %v", err)
+				return
+			}
+			if assert.NoError(t, err) {
+				_ = conn.Close()
+			}
+		})
+	}
+}
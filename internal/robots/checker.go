@@ -0,0 +1,213 @@
+package robots
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/mts-test-task/internal/storages/mongodb/models"
+)
+
+// Максимальный размер robots.txt, остаток файла игнорируется
+const maxRobotsSize = 512 * 1024
+
+// ErrDisallowed is returned for urls which must not be fetched according to robots.txt
+var ErrDisallowed = errors.New("disallowed by robots.txt")
+
+// ErrUnavailable is returned when robots.txt can not be fetched and rules of the host are unknown
+var ErrUnavailable = errors.New("robots.txt is unavailable")
+
+type robotsMongoObjectsBuilder interface {
+	NewRobots(origin string, statusCode int, body string, createTime time.Time, ttl time.Duration) (robots *models.Robots)
+	RobotsFilter(origin string, now time.Time) (filter bson.M)
+	RobotsIDFilter(origin string) (filter bson.M)
+	RobotsUpsertOptions() (replaceOptions *options.ReplaceOptions)
+}
+
+type hostLimiter interface {
+	Wait(ctx context.Context, rawURL string, minDelay time.Duration) (release func(), err error)
+}
+
+type robotsMongoWrapper interface {
+	GetRobots(ctx context.Context, filter bson.M) (robots *models.Robots, err error)
+	UpsertRobots(ctx context.Context, filter bson.M, robots interface{}, replaceOptions *options.ReplaceOptions) (err error)
+}
+
+// Checker checks urls against robots.txt of their hosts
+type Checker interface {
+	Check(ctx context.Context, rawURL string) (crawlDelay time.Duration, err error)
+}
+
+type checker struct {
+	clientHTTP                http.Client
+	robotsMongoObjectsBuilder robotsMongoObjectsBuilder
+	robotsMongoWrapper        robotsMongoWrapper
+	hostLimiter               hostLimiter
+	logger                    log.Logger
+	userAgent                 string
+	ttl                       time.Duration
+	errorTTL                  time.Duration
+	maxCrawlDelay             time.Duration
+	ownedHosts                map[string]bool
+}
+
+// Check returns ErrDisallowed if the url is forbidden and ErrUnavailable if robots.txt can not be fetched
+func (c *checker) Check(ctx context.Context, rawURL string) (crawlDelay time.Duration, err error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse url: %s", err)
+	}
+	if c.ownedHosts[strings.ToLower(u.Hostname())] {
+		return 0, nil
+	}
+
+	origin := u.Scheme + "://" + strings.ToLower(u.Host)
+	rules, err := c.rules(ctx, origin)
+	if err != nil {
+		return 0, err
+	}
+
+	if !rules.Allowed(u.RequestURI()) {
+		return 0, ErrDisallowed
+	}
+
+	crawlDelay = rules.CrawlDelay()
+	if crawlDelay > c.maxCrawlDelay {
+		crawlDelay = c.maxCrawlDelay
+	}
+
+	return
+}
+
+// rules берет robots.txt из кэша в монге или запрашивает его с сайта
+func (c *checker) rules(ctx context.Context, origin string) (rules *Rules, err error) {
+	now := time.Now()
+	stored, err := c.robotsMongoWrapper.GetRobots(ctx, c.robotsMongoObjectsBuilder.RobotsFilter(origin, now))
+	if err != nil {
+		// Монга используется как кэш, поэтому при ошибке просто запрашиваем файл заново
+		_ = level.Error(c.logger).Log("msg", "Failed to get robots.txt from mongo:", "origin", origin, "err", err)
+	}
+	if stored != nil {
+		if isUnavailable(stored.StatusCode) {
+			return nil, fmt.Errorf("%w: status code %d, cached", ErrUnavailable, stored.StatusCode)
+		}
+		return c.parse(stored.StatusCode, stored.Body), nil
+	}
+
+	release, err := c.hostLimiter.Wait(ctx, origin+"/robots.txt", 0)
+	if err != nil {
+		// Ожидание очереди хоста - ограничение сервиса, а не сайта, поэтому оно не кэшируется
+		return nil, fmt.Errorf("%w: %s", ErrUnavailable, err)
+	}
+	statusCode, body, fetchErr := c.fetch(ctx, origin)
+	release()
+
+	ttl := c.ttl
+	if fetchErr != nil {
+		// Отмена запроса клиентом ничего не говорит о сайте
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("%w: %s", ErrUnavailable, fetchErr)
+		}
+		// Недоступность кэшируется ненадолго, чтобы не запрашивать файл на каждый урл хоста
+		ttl = c.errorTTL
+	}
+
+	robots := c.robotsMongoObjectsBuilder.NewRobots(origin, statusCode, body, now, ttl)
+	err = c.robotsMongoWrapper.UpsertRobots(
+		ctx,
+		c.robotsMongoObjectsBuilder.RobotsIDFilter(origin),
+		robots,
+		c.robotsMongoObjectsBuilder.RobotsUpsertOptions(),
+	)
+	if err != nil {
+		_ = level.Error(c.logger).Log("msg", "Failed to put robots.txt to mongo:", "origin", origin, "err", err)
+	}
+	if fetchErr != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnavailable, fetchErr)
+	}
+
+	return c.parse(statusCode, body), nil
+}
+
+// isUnavailable - сайт не ответил (statusCode 0) или вернул ошибку сервера, правила хоста неизвестны
+func isUnavailable(statusCode int) bool {
+	return statusCode == 0 || statusCode >= http.StatusInternalServerError
+}
+
+// parse трактует ответ сайта: без файла (4xx) ограничений нет
+func (c *checker) parse(statusCode int, body string) (rules *Rules) {
+	if statusCode >= http.StatusBadRequest {
+		return AllowAll()
+	}
+
+	return Parse(body, c.userAgent)
+}
+
+// fetch запрашивает robots.txt. Ошибка сервера считается недоступностью файла, ее код возвращается вместе с ошибкой
+func (c *checker) fetch(ctx context.Context, origin string) (statusCode int, body string, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, origin+"/robots.txt", nil)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to create robots.txt request: %s", err)
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+
+	resp, err := c.clientHTTP.Do(req)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to get robots.txt: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return resp.StatusCode, "", fmt.Errorf("status code %d", resp.StatusCode)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxRobotsSize))
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to read robots.txt: %s", err)
+	}
+
+	return resp.StatusCode, string(data), nil
+}
+
+// NewChecker ...
+func NewChecker(
+	clientHTTP http.Client,
+	robotsMongoObjectsBuilder robotsMongoObjectsBuilder,
+	robotsMongoWrapper robotsMongoWrapper,
+	hostLimiter hostLimiter,
+	logger log.Logger,
+	userAgent string,
+	ttl time.Duration,
+	errorTTL time.Duration,
+	maxCrawlDelay time.Duration,
+	ownedHosts []string,
+) Checker {
+	owned := make(map[string]bool, len(ownedHosts))
+	for _, host := range ownedHosts {
+		owned[strings.ToLower(host)] = true
+	}
+
+	return &checker{
+		clientHTTP:                clientHTTP,
+		robotsMongoObjectsBuilder: robotsMongoObjectsBuilder,
+		robotsMongoWrapper:        robotsMongoWrapper,
+		hostLimiter:               hostLimiter,
+		logger:                    logger,
+		userAgent:                 userAgent,
+		ttl:                       ttl,
+		errorTTL:                  errorTTL,
+		maxCrawlDelay:             maxCrawlDelay,
+		ownedHosts:                owned,
+	}
+}
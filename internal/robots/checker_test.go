@@ -0,0 +1,191 @@
+package robots
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/mts-test-task/internal/storages/mongodb/builder"
+	"github.com/mts-test-task/internal/storages/mongodb/models"
+)
+
+const (
+	testTTL      = time.Hour
+	testErrorTTL = time.Minute
+)
+
+type robotsWrapperStub struct {
+	stored *models.Robots
+}
+
+func (r *robotsWrapperStub) GetRobots(ctx context.Context, filter bson.M) (robots *models.Robots, err error) {
+	if r.stored == nil || !r.stored.ExpireAt.After(time.Now()) {
+		return nil, nil
+	}
+	return r.stored, nil
+}
+
+func (r *robotsWrapperStub) UpsertRobots(ctx context.Context, filter bson.M, robots interface{}, replaceOptions *options.ReplaceOptions) (err error) {
+	r.stored = robots.(*models.Robots)
+	return nil
+}
+
+type hostLimiterStub struct {
+	waits int32
+	err   error
+}
+
+func (h *hostLimiterStub) Wait(ctx context.Context, rawURL string, minDelay time.Duration) (release func(), err error) {
+	atomic.AddInt32(&h.waits, 1)
+	if h.err != nil {
+		return nil, h.err
+	}
+	return func() {}, nil
+}
+
+func TestChecker_Check(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		path       string
+		limiterErr error
+		wantErr    error
+		wantDelay  time.Duration
+		// wantFetches - запросов robots.txt за две проверки подряд
+		wantFetches int32
+		wantTTL     time.Duration
+	}{
+		{
+			name:        "allowed",
+			status:      http.StatusOK,
+			body:        "User-agent: *\nDisallow: /private\nCrawl-delay: 1",
+			path:        "/page",
+			wantDelay:   time.Second,
+			wantFetches: 1,
+			wantTTL:     testTTL,
+		},
+		{
+			name:        "disallowed",
+			status:      http.StatusOK,
+			body:        "User-agent: *\nDisallow: /private",
+			path:        "/private",
+			wantErr:     ErrDisallowed,
+			wantFetches: 1,
+			wantTTL:     testTTL,
+		},
+		{
+			name:        "crawl delay is capped",
+			status:      http.StatusOK,
+			body:        "User-agent: *\nCrawl-delay: 60",
+			path:        "/page",
+			wantDelay:   10 * time.Second,
+			wantFetches: 1,
+			wantTTL:     testTTL,
+		},
+		{
+			name:        "missing file allows everything",
+			status:      http.StatusNotFound,
+			body:        "User-agent: *\nDisallow: /",
+			path:        "/page",
+			wantFetches: 1,
+			wantTTL:     testTTL,
+		},
+		{
+			name:        "server error is cached briefly",
+			status:      http.StatusServiceUnavailable,
+			path:        "/page",
+			wantErr:     ErrUnavailable,
+			wantFetches: 1,
+			wantTTL:     testErrorTTL,
+		},
+		{
+			name:       "busy host is not cached",
+			status:     http.StatusOK,
+			path:       "/page",
+			limiterErr: errors.New("host is busy"),
+			wantErr:    ErrUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fetches int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&fetches, 1)
+				assert.Equal(t, "/robots.txt", r.URL.Path)
+				assert.Equal(t, testUserAgent, r.Header.Get("User-Agent"))
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			wrapper := &robotsWrapperStub{}
+			limiter := &hostLimiterStub{err: tt.limiterErr}
+			checker := NewChecker(
+				http.Client{Timeout: time.Second},
+				builder.NewRobotsMongoObjects(),
+				wrapper,
+				limiter,
+				log.NewNopLogger(),
+				testUserAgent,
+				testTTL,
+				testErrorTTL,
+				10*time.Second,
+				nil,
+			)
+
+			for i := 0; i < 2; i++ {
+				delay, err := checker.Check(context.Background(), server.URL+tt.path)
+				if tt.wantErr != nil {
+					assert.True(t, errors.Is(err, tt.wantErr), "unexpected error: %v", err)
+				} else {
+					assert.NoError(t, err)
+				}
+				assert.Equal(t, tt.wantDelay, delay)
+			}
+
+			assert.Equal(t, tt.wantFetches, atomic.LoadInt32(&fetches))
+			// Каждый запрос robots.txt проходит через очередь хоста
+			if tt.limiterErr == nil {
+				assert.Equal(t, tt.wantFetches, atomic.LoadInt32(&limiter.waits))
+			}
+			if tt.wantTTL == 0 {
+				assert.Nil(t, wrapper.stored)
+				return
+			}
+			if assert.NotNil(t, wrapper.stored) {
+				ttl := wrapper.stored.ExpireAt.Sub(time.Unix(wrapper.stored.CreateDate, 0))
+				assert.InDelta(t, float64(tt.wantTTL), float64(ttl), float64(time.Second))
+			}
+		})
+	}
+}
+
+func TestChecker_OwnedHosts(t *testing.T) {
+	limiter := &hostLimiterStub{}
+	checker := NewChecker(
+		http.Client{Timeout: time.Second},
+		builder.NewRobotsMongoObjects(),
+		&robotsWrapperStub{},
+		limiter,
+		log.NewNopLogger(),
+		testUserAgent,
+		testTTL,
+		testErrorTTL,
+		10*time.Second,
+		[]string{"Internal.Example.com"},
+	)
+
+	_, err := checker.Check(context.Background(), "http://internal.example.com/page")
+	assert.NoError(t, err)
+	assert.Zero(t, atomic.LoadInt32(&limiter.waits))
+}
@@ -0,0 +1,171 @@
+package robots
+
+import (
+	"bufio"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// rule - правило allow или disallow, pattern поддерживает * и $ в конце
+type rule struct {
+	allow   bool
+	length  int
+	pattern *regexp.Regexp
+}
+
+// Rules are robots.txt rules of the group matching the user agent
+type Rules struct {
+	rules      []*rule
+	crawlDelay time.Duration
+}
+
+// group - группа правил для одного или нескольких user-agent
+type group struct {
+	agents     []string
+	rules      []*rule
+	crawlDelay time.Duration
+}
+
+// Allowed checks path with query against rules: the longest matching pattern wins, allow wins ties
+func (r *Rules) Allowed(path string) bool {
+	// Сам robots.txt доступен всегда
+	if path == "/robots.txt" {
+		return true
+	}
+
+	var matched *rule
+	for _, rl := range r.rules {
+		if !rl.pattern.MatchString(path) {
+			continue
+		}
+		if matched == nil || rl.length > matched.length || (rl.length == matched.length && rl.allow) {
+			matched = rl
+		}
+	}
+
+	return matched == nil || matched.allow
+}
+
+// CrawlDelay returns delay between requests, zero if it is not set
+func (r *Rules) CrawlDelay() time.Duration {
+	return r.crawlDelay
+}
+
+// AllowAll returns rules without restrictions
+func AllowAll() *Rules {
+	return &Rules{}
+}
+
+// Parse returns rules for the user agent: groups naming its product token are merged,
+// groups for * are used if there are none
+func Parse(body string, userAgent string) (rules *Rules) {
+	token := strings.ToLower(userAgent)
+	if i := strings.IndexAny(token, "/ "); i >= 0 {
+		token = token[:i]
+	}
+
+	groups := parseGroups(body)
+	rules = &Rules{}
+	for _, name := range []string{token, "*"} {
+		found := false
+		for _, g := range groups {
+			if !containsAgent(g.agents, name) {
+				continue
+			}
+			found = true
+			rules.rules = append(rules.rules, g.rules...)
+			if g.crawlDelay > rules.crawlDelay {
+				rules.crawlDelay = g.crawlDelay
+			}
+		}
+		if found {
+			return
+		}
+	}
+
+	return
+}
+
+func parseGroups(body string) (groups []*group) {
+	var current *group
+	// Подряд идущие строки user-agent относятся к одной группе
+	agentsOpen := false
+
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		i := strings.IndexByte(line, ':')
+		if i < 0 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(line[:i]))
+		value := strings.TrimSpace(line[i+1:])
+
+		if key == "user-agent" {
+			if !agentsOpen {
+				current = &group{}
+				groups = append(groups, current)
+				agentsOpen = true
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+			continue
+		}
+		agentsOpen = false
+		// Правила до первой строки user-agent ни к кому не относятся
+		if current == nil {
+			continue
+		}
+
+		switch key {
+		case "allow", "disallow":
+			// Пустой disallow ничего не запрещает
+			if value == "" {
+				continue
+			}
+			current.rules = append(current.rules, &rule{
+				allow:   key == "allow",
+				length:  len(value),
+				pattern: compilePattern(value),
+			})
+		case "crawl-delay":
+			seconds, err := strconv.ParseFloat(value, 64)
+			if err == nil && seconds > 0 {
+				current.crawlDelay = time.Duration(seconds * float64(time.Second))
+			}
+		}
+	}
+
+	return
+}
+
+// compilePattern переводит шаблон пути в регулярное выражение с привязкой к началу
+func compilePattern(value string) *regexp.Regexp {
+	anchored := strings.HasSuffix(value, "$")
+	value = strings.TrimSuffix(value, "$")
+
+	parts := strings.Split(value, "*")
+	for i := range parts {
+		parts[i] = regexp.QuoteMeta(parts[i])
+	}
+	expr := "^" + strings.Join(parts, ".*")
+	if anchored {
+		expr += "$"
+	}
+
+	return regexp.MustCompile(expr)
+}
+
+func containsAgent(agents []string, name string) bool {
+	for _, agent := range agents {
+		if agent == name {
+			return true
+		}
+	}
+
+	return false
+}
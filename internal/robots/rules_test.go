@@ -0,0 +1,129 @@
+package robots
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testUserAgent = "sites-data/1.0"
+
+func TestRules_Allowed(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		path    string
+		allowed bool
+	}{
+		{
+			name:    "no rules",
+			body:    "",
+			path:    "/page",
+			allowed: true,
+		},
+		{
+			name:    "disallow prefix",
+			body:    "User-agent: *\nDisallow: /private",
+			path:    "/private/page",
+			allowed: false,
+		},
+		{
+			name:    "empty disallow",
+			body:    "User-agent: *\nDisallow:",
+			path:    "/page",
+			allowed: true,
+		},
+		{
+			name:    "longest match wins",
+			body:    "User-agent: *\nDisallow: /docs\nAllow: /docs/public",
+			path:    "/docs/public/page",
+			allowed: true,
+		},
+		{
+			name:    "allow wins ties",
+			body:    "User-agent: *\nDisallow: /page\nAllow: /page",
+			path:    "/page",
+			allowed: true,
+		},
+		{
+			name:    "wildcard",
+			body:    "User-agent: *\nDisallow: /*.pdf",
+			path:    "/files/doc.pdf?download=1",
+			allowed: false,
+		},
+		{
+			name:    "end anchor",
+			body:    "User-agent: *\nDisallow: /*.pdf$",
+			path:    "/files/doc.pdf?download=1",
+			allowed: true,
+		},
+		{
+			name:    "robots.txt is always allowed",
+			body:    "User-agent: *\nDisallow: /",
+			path:    "/robots.txt",
+			allowed: true,
+		},
+		{
+			name:    "own group replaces wildcard group",
+			body:    "User-agent: *\nDisallow: /\n\nUser-agent: sites-data\nDisallow: /private",
+			path:    "/page",
+			allowed: true,
+		},
+		{
+			name:    "consecutive user agents share group",
+			body:    "User-agent: other\nUser-agent: Sites-Data\nDisallow: /",
+			path:    "/page",
+			allowed: false,
+		},
+		{
+			name:    "rules before user agent are ignored",
+			body:    "Disallow: /\nUser-agent: *\nAllow: /",
+			path:    "/page",
+			allowed: true,
+		},
+		{
+			name:    "comments",
+			body:    "User-agent: * # all\nDisallow: /private # secret",
+			path:    "/private",
+			allowed: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.allowed, Parse(tt.body, testUserAgent).Allowed(tt.path))
+		})
+	}
+}
+
+func TestRules_CrawlDelay(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		delay time.Duration
+	}{
+		{
+			name: "not set",
+			body: "User-agent: *\nDisallow: /private",
+		},
+		{
+			name:  "fractional seconds",
+			body:  "User-agent: *\nCrawl-delay: 0.5",
+			delay: 500 * time.Millisecond,
+		},
+		{
+			name: "invalid",
+			body: "User-agent: *\nCrawl-delay: soon",
+		},
+		{
+			name:  "largest of merged groups",
+			body:  "User-agent: sites-data\nCrawl-delay: 1\n\nUser-agent: sites-data\nCrawl-delay: 3",
+			delay: 3 * time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.delay, Parse(tt.body, testUserAgent).CrawlDelay())
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package siterules
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Rule sets fetch and cache parameters for urls of matching hosts, zero values mean defaults
+type Rule struct {
+	Name string
+	// Host is a glob like *.example.com, HostRegex is used when Host is empty
+	Host        string
+	HostRegex   *regexp.Regexp
+	TTL         time.Duration
+	Timeout     time.Duration
+	MaxAttempts int
+	// RetryNonIdempotent allows retries of POST, PATCH and other methods the retry policy does not repeat
+	RetryNonIdempotent bool
+	// ContentTypes are allowed media types of response, type/* matches any subtype
+	ContentTypes []string
+	// Headers are added to requests, headers of the request override them
+	Headers map[string]string
+}
+
+// ruleConfig - правило в файле конфигурации, длительности задаются строками вида 5s
+type ruleConfig struct {
+	Name               string            `json:"name"`
+	Host               string            `json:"host"`
+	HostRegex          string            `json:"host_regex"`
+	TTL                string            `json:"ttl"`
+	Timeout            string            `json:"timeout"`
+	MaxAttempts        int               `json:"max_attempts"`
+	RetryNonIdempotent bool              `json:"retry_non_idempotent"`
+	ContentTypes       []string          `json:"content_types"`
+	Headers            map[string]string `json:"headers"`
+}
+
+// Load reads rules from JSON file, empty path means no rules
+func Load(filePath string) (rules []*Rule, err error) {
+	if filePath == "" {
+		return
+	}
+
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules: %s", err)
+	}
+
+	var configs []*ruleConfig
+	if err = json.Unmarshal(data, &configs); err != nil {
+		return nil, fmt.Errorf("failed to parse rules: %s", err)
+	}
+
+	rules = make([]*Rule, 0, len(configs))
+	for i, config := range configs {
+		rule, err := newRule(config)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule %d: %s", i, err)
+		}
+		rules = append(rules, rule)
+	}
+
+	return
+}
+
+func newRule(config *ruleConfig) (rule *Rule, err error) {
+	if config.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if (config.Host == "") == (config.HostRegex == "") {
+		return nil, fmt.Errorf("exactly one of host and host_regex is required")
+	}
+	if config.MaxAttempts < 0 {
+		return nil, fmt.Errorf("max_attempts must not be negative")
+	}
+
+	rule = &Rule{
+		Name:               config.Name,
+		Host:               strings.ToLower(config.Host),
+		MaxAttempts:        config.MaxAttempts,
+		RetryNonIdempotent: config.RetryNonIdempotent,
+		ContentTypes:       config.ContentTypes,
+		Headers:            config.Headers,
+	}
+	if _, err = path.Match(rule.Host, ""); err != nil {
+		return nil, fmt.Errorf("invalid host: %s", err)
+	}
+	if config.HostRegex != "" {
+		if rule.HostRegex, err = regexp.Compile(config.HostRegex); err != nil {
+			return nil, fmt.Errorf("invalid host_regex: %s", err)
+		}
+	}
+	if rule.TTL, err = parseDuration(config.TTL); err != nil {
+		return nil, fmt.Errorf("invalid ttl: %s", err)
+	}
+	if rule.Timeout, err = parseDuration(config.Timeout); err != nil {
+		return nil, fmt.Errorf("invalid timeout: %s", err)
+	}
+
+	return
+}
+
+func parseDuration(value string) (duration time.Duration, err error) {
+	if value == "" {
+		return
+	}
+	duration, err = time.ParseDuration(value)
+	if err == nil && duration < 0 {
+		err = fmt.Errorf("duration must not be negative")
+	}
+
+	return
+}
+
+// AllowsContentType checks media type of response against ContentTypes, any type is allowed if they are empty
+func (r *Rule) AllowsContentType(contentType string) bool {
+	if len(r.ContentTypes) == 0 {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(contentType))
+	}
+	for _, allowed := range r.ContentTypes {
+		allowed = strings.ToLower(allowed)
+		if allowed == mediaType {
+			return true
+		}
+		if strings.HasSuffix(allowed, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(allowed, "*")) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *Rule) matches(host string) bool {
+	if r.HostRegex != nil {
+		return r.HostRegex.MatchString(host)
+	}
+	matched, _ := path.Match(r.Host, host)
+	return matched
+}
+
+// Matcher finds the rule for url
+type Matcher interface {
+	// Match returns the first matching rule, nil if there is none
+	Match(rawURL string) (rule *Rule)
+	// MaxTTL is the largest TTL of rules
+	MaxTTL() (maxTTL time.Duration)
+}
+
+type matcher struct {
+	rules  []*Rule
+	maxTTL time.Duration
+}
+
+func (m *matcher) Match(rawURL string) (rule *Rule) {
+	if len(m.rules) == 0 {
+		return
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return
+	}
+
+	host := strings.ToLower(u.Hostname())
+	for _, rule := range m.rules {
+		if rule.matches(host) {
+			return rule
+		}
+	}
+
+	return
+}
+
+func (m *matcher) MaxTTL() (maxTTL time.Duration) {
+	return m.maxTTL
+}
+
+// NewMatcher ...
+func NewMatcher(rules []*Rule) Matcher {
+	m := &matcher{rules: rules}
+	for _, rule := range rules {
+		if rule.TTL > m.maxTTL {
+			m.maxTTL = rule.TTL
+		}
+	}
+
+	return m
+}
@@ -0,0 +1,140 @@
+package siterules
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatcher_Match(t *testing.T) {
+	rules := []*Rule{
+		{Name: "api", Host: "api.example.com"},
+		{Name: "subdomains", Host: "*.example.com"},
+		{Name: "regex", HostRegex: regexp.MustCompile(`^(www\.)?example\.org$`)},
+		{Name: "any", Host: "*.test"},
+	}
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{url: "https://api.example.com/v1", want: "api"},
+		{url: "https://API.Example.com:8443/v1", want: "api"},
+		{url: "https://www.example.com/", want: "subdomains"},
+		{url: "https://a.b.example.com/", want: "subdomains"},
+		{url: "https://example.com/"},
+		{url: "https://notexample.com/"},
+		{url: "https://example.org/", want: "regex"},
+		{url: "https://www.example.org/", want: "regex"},
+		{url: "https://cdn.example.org/"},
+		{url: "http://site.test/", want: "any"},
+		{url: "http://[::1"},
+	}
+	matcher := NewMatcher(rules)
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			rule := matcher.Match(tt.url)
+			if tt.want == "" {
+				assert.Nil(t, rule)
+				return
+			}
+			if assert.NotNil(t, rule) {
+				assert.Equal(t, tt.want, rule.Name)
+			}
+		})
+	}
+}
+
+func TestMatcher_MaxTTL(t *testing.T) {
+	matcher := NewMatcher([]*Rule{{Name: "a", TTL: time.Minute}, {Name: "b", TTL: time.Hour}, {Name: "c"}})
+	assert.Equal(t, time.Hour, matcher.MaxTTL())
+	assert.Zero(t, NewMatcher(nil).MaxTTL())
+}
+
+func TestRule_AllowsContentType(t *testing.T) {
+	tests := []struct {
+		name         string
+		contentTypes []string
+		contentType  string
+		allowed      bool
+	}{
+		{name: "no restrictions", contentType: "image/png", allowed: true},
+		{name: "exact", contentTypes: []string{"text/html"}, contentType: "text/html; charset=utf-8", allowed: true},
+		{name: "case insensitive", contentTypes: []string{"Text/HTML"}, contentType: "TEXT/html", allowed: true},
+		{name: "subtype wildcard", contentTypes: []string{"text/*"}, contentType: "text/plain", allowed: true},
+		{name: "wildcard is not prefix of other type", contentTypes: []string{"text/*"}, contentType: "textual/plain"},
+		{name: "other type", contentTypes: []string{"text/html", "application/json"}, contentType: "image/png"},
+		{name: "missing type", contentTypes: []string{"text/html"}, contentType: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := &Rule{Name: tt.name, ContentTypes: tt.contentTypes}
+			assert.Equal(t, tt.allowed, rule.AllowsContentType(tt.contentType))
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr bool
+		want    *Rule
+	}{
+		{
+			name:   "valid",
+			config: `[{"name": "example", "host": "*.Example.com", "ttl": "1h", "timeout": "5s", "max_attempts": 2, "retry_non_idempotent": true, "content_types": ["text/*"], "headers": {"Accept": "text/html"}}]`,
+			want: &Rule{
+				Name:               "example",
+				Host:               "*.example.com",
+				TTL:                time.Hour,
+				Timeout:            5 * time.Second,
+				MaxAttempts:        2,
+				RetryNonIdempotent: true,
+				ContentTypes:       []string{"text/*"},
+				Headers:            map[string]string{"Accept": "text/html"},
+			},
+		},
+		{name: "broken json", config: `[{`, wantErr: true},
+		{name: "missing name", config: `[{"host": "example.com"}]`, wantErr: true},
+		{name: "no host", config: `[{"name": "a"}]`, wantErr: true},
+		{name: "both hosts", config: `[{"name": "a", "host": "example.com", "host_regex": "example"}]`, wantErr: true},
+		{name: "bad glob", config: `[{"name": "a", "host": "[example.com"}]`, wantErr: true},
+		{name: "bad regex", config: `[{"name": "a", "host_regex": "(example"}]`, wantErr: true},
+		{name: "bad ttl", config: `[{"name": "a", "host": "example.com", "ttl": "hour"}]`, wantErr: true},
+		{name: "negative timeout", config: `[{"name": "a", "host": "example.com", "timeout": "-1s"}]`, wantErr: true},
+		{name: "negative attempts", config: `[{"name": "a", "host": "example.com", "max_attempts": -1}]`, wantErr: true},
+	}
+	dir, err := ioutil.TempDir("", "siterules")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := filepath.Join(dir, tt.name+".json")
+			if !assert.NoError(t, ioutil.WriteFile(filePath, []byte(tt.config), 0600)) {
+				return
+			}
+
+			rules, err := Load(filePath)
+			if tt.wantErr {
+				assert.Error(t, err, "config %d", i)
+				return
+			}
+			if assert.NoError(t, err) && assert.Len(t, rules, 1) {
+				assert.Equal(t, tt.want, rules[0])
+			}
+		})
+	}
+}
+
+func TestLoad_EmptyPath(t *testing.T) {
+	rules, err := Load("")
+	assert.NoError(t, err)
+	assert.Empty(t, rules)
+}
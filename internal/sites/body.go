@@ -0,0 +1,71 @@
+package sites
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/mts-test-task/pkg/sitesdataservice/api"
+)
+
+// ErrBodyTooLarge is returned when response body exceeds the limit in fail mode
+var ErrBodyTooLarge = errors.New("response body is too large")
+
+// readBody читает тело ответа, но не больше maxBodySize байт после распаковки. Ноль означает отсутствие лимита
+func readBody(resp *http.Response, maxBodySize int64, bodyLimitMode string) (body []byte, truncated bool, err error) {
+	reader, err := decodedBody(resp)
+	if err != nil {
+		return nil, false, err
+	}
+	if maxBodySize <= 0 {
+		body, err = ioutil.ReadAll(reader)
+		return
+	}
+
+	// Читаем на байт больше лимита, чтобы отличить тело ровно по лимиту от превышающего его
+	body, err = ioutil.ReadAll(io.LimitReader(reader, maxBodySize+1))
+	if err != nil {
+		return
+	}
+	if int64(len(body)) <= maxBodySize {
+		return
+	}
+
+	if bodyLimitMode == api.BodyLimitModeTruncate {
+		return body[:maxBodySize], true, nil
+	}
+	return nil, false, fmt.Errorf("%w: more than %d bytes", ErrBodyTooLarge, maxBodySize)
+}
+
+// decodedBody распаковывает тело, если этого не сделал транспорт. Так бывает, когда Accept-Encoding
+// передан в заголовках запроса, и без распаковки лимит не защитил бы от сжатых бомб
+func decodedBody(resp *http.Response) (reader io.Reader, err error) {
+	if resp.Uncompressed {
+		return resp.Body, nil
+	}
+
+	switch strings.ToLower(resp.Header.Get("Content-Encoding")) {
+	case "gzip":
+		reader, err = gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress gzip body: %s", err)
+		}
+	case "deflate":
+		// В HTTP deflate означает поток zlib
+		reader, err = zlib.NewReader(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress deflate body: %s", err)
+		}
+	default:
+		return resp.Body, nil
+	}
+
+	// Данные отдаются уже распакованными
+	resp.Header.Del("Content-Encoding")
+	return
+}
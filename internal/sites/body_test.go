@@ -0,0 +1,126 @@
+package sites
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mts-test-task/pkg/sitesdataservice/api"
+)
+
+func gzipped(data string) []byte {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	_, _ = writer.Write([]byte(data))
+	_ = writer.Close()
+	return buf.Bytes()
+}
+
+func deflated(data string) []byte {
+	var buf bytes.Buffer
+	writer := zlib.NewWriter(&buf)
+	_, _ = writer.Write([]byte(data))
+	_ = writer.Close()
+	return buf.Bytes()
+}
+
+func TestReadBody(t *testing.T) {
+	large := strings.Repeat("a", 1000)
+	tests := []struct {
+		name          string
+		body          []byte
+		encoding      string
+		maxBodySize   int64
+		mode          string
+		wantBody      string
+		wantTruncated bool
+		wantErr       error
+	}{
+		{
+			name:     "no limit",
+			body:     []byte(large),
+			wantBody: large,
+		},
+		{
+			name:        "exactly limit",
+			body:        []byte("abcd"),
+			maxBodySize: 4,
+			wantBody:    "abcd",
+		},
+		{
+			name:        "over limit fails",
+			body:        []byte("abcde"),
+			maxBodySize: 4,
+			mode:        api.BodyLimitModeFail,
+			wantErr:     ErrBodyTooLarge,
+		},
+		{
+			name:          "over limit truncates",
+			body:          []byte("abcde"),
+			maxBodySize:   4,
+			mode:          api.BodyLimitModeTruncate,
+			wantBody:      "abcd",
+			wantTruncated: true,
+		},
+		{
+			name:     "gzip",
+			body:     gzipped("hello"),
+			encoding: "gzip",
+			wantBody: "hello",
+		},
+		{
+			name:     "deflate",
+			body:     deflated("hello"),
+			encoding: "Deflate",
+			wantBody: "hello",
+		},
+		{
+			name:        "limit applies after decompression",
+			body:        gzipped(large),
+			encoding:    "gzip",
+			maxBodySize: 100,
+			mode:        api.BodyLimitModeFail,
+			wantErr:     ErrBodyTooLarge,
+		},
+		{
+			name:     "unknown encoding is kept",
+			body:     []byte("br data"),
+			encoding: "br",
+			wantBody: "br data",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{}, Body: ioutil.NopCloser(bytes.NewReader(tt.body))}
+			if tt.encoding != "" {
+				resp.Header.Set("Content-Encoding", tt.encoding)
+			}
+
+			body, truncated, err := readBody(resp, tt.maxBodySize, tt.mode)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "unexpected error: %v", err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantBody, string(body))
+			assert.Equal(t, tt.wantTruncated, truncated)
+		})
+	}
+}
+
+func TestReadBody_BrokenGzip(t *testing.T) {
+	resp := &http.Response{
+		Header: http.Header{"Content-Encoding": []string{"gzip"}},
+		Body:   ioutil.NopCloser(strings.NewReader("not gzip")),
+	}
+
+	_, _, err := readBody(resp, 0, "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,96 @@
+package sites
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// Кодировку в meta-тегах ищем только в начале документа, как это делают браузеры
+const metaCharsetPrefixSize = 1024
+
+var (
+	utf8BOM    = []byte{0xEF, 0xBB, 0xBF}
+	utf16LEBOM = []byte{0xFF, 0xFE}
+	utf16BEBOM = []byte{0xFE, 0xFF}
+
+	// <meta charset="..."> и <meta http-equiv="Content-Type" content="text/html; charset=...">
+	metaCharsetRegexp = regexp.MustCompile(`(?i)<meta[^>]+charset\s*=\s*["']?\s*([a-z0-9_:.\-]+)`)
+)
+
+// detectCharset определяет кодировку по BOM, заголовку Content-Type и meta-тегам. Возвращает каноническое
+// имя кодировки и длину BOM, если он есть. Пустое имя означает, что кодировку определить не удалось
+func detectCharset(body []byte, contentType string) (charset string, enc encoding.Encoding, bomSize int) {
+	switch {
+	case bytes.HasPrefix(body, utf8BOM):
+		return "utf-8", unicode.UTF8, len(utf8BOM)
+	case bytes.HasPrefix(body, utf16LEBOM):
+		return "utf-16le", unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM), len(utf16LEBOM)
+	case bytes.HasPrefix(body, utf16BEBOM):
+		return "utf-16be", unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM), len(utf16BEBOM)
+	}
+
+	if _, params, err := mime.ParseMediaType(contentType); err == nil {
+		if charset, enc = lookupCharset(params["charset"]); enc != nil {
+			return
+		}
+	}
+
+	prefix := body
+	if len(prefix) > metaCharsetPrefixSize {
+		prefix = prefix[:metaCharsetPrefixSize]
+	}
+	if match := metaCharsetRegexp.FindSubmatch(prefix); match != nil {
+		if charset, enc = lookupCharset(string(match[1])); enc != nil {
+			return
+		}
+	}
+
+	return "", nil, 0
+}
+
+// lookupCharset ищет кодировку по имени или его синониму из WHATWG Encoding Standard
+func lookupCharset(name string) (charset string, enc encoding.Encoding) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return
+	}
+
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		return "", nil
+	}
+	charset, err = htmlindex.Name(enc)
+	if err != nil {
+		return "", nil
+	}
+
+	return
+}
+
+// toUTF8 перекодирует тело в UTF-8 и убирает BOM. Без известной кодировки тело остается как есть
+func toUTF8(body []byte, contentType string) (data []byte, charset string, err error) {
+	charset, enc, bomSize := detectCharset(body, contentType)
+	if enc == nil {
+		return body, "", nil
+	}
+
+	body = body[bomSize:]
+	if charset == "utf-8" && utf8.Valid(body) {
+		return body, charset, nil
+	}
+
+	data, err = enc.NewDecoder().Bytes(body)
+	if err != nil {
+		return nil, charset, fmt.Errorf("failed to decode body from %s: %s", charset, err)
+	}
+
+	return data, charset, nil
+}
@@ -0,0 +1,109 @@
+package sites
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func encode(t *testing.T, enc encoding.Encoding, text string) []byte {
+	data, err := enc.NewEncoder().Bytes([]byte(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestToUTF8(t *testing.T) {
+	const text = "Привет, мир"
+	tests := []struct {
+		name        string
+		body        []byte
+		contentType string
+		wantData    string
+		wantCharset string
+	}{
+		{
+			name:        "charset from header",
+			body:        encode(t, charmap.Windows1251, text),
+			contentType: "text/html; charset=windows-1251",
+			wantData:    text,
+			wantCharset: "windows-1251",
+		},
+		{
+			name:        "charset alias",
+			body:        encode(t, charmap.Windows1251, text),
+			contentType: "text/plain; charset=cp1251",
+			wantData:    text,
+			wantCharset: "windows-1251",
+		},
+		{
+			name:        "charset from meta tag",
+			body:        append([]byte(`<html><head><meta charset="koi8-r"></head>`), encode(t, charmap.KOI8R, text)...),
+			contentType: "text/html",
+			wantData:    `<html><head><meta charset="koi8-r"></head>` + text,
+			wantCharset: "koi8-r",
+		},
+		{
+			name: "charset from http-equiv meta tag",
+			body: append(
+				[]byte(`<meta http-equiv="Content-Type" content="text/html; charset=windows-1251">`),
+				encode(t, charmap.Windows1251, text)...,
+			),
+			wantData:    `<meta http-equiv="Content-Type" content="text/html; charset=windows-1251">` + text,
+			wantCharset: "windows-1251",
+		},
+		{
+			name:        "header wins over meta tag",
+			body:        append([]byte(`<meta charset="koi8-r">`), encode(t, charmap.Windows1251, text)...),
+			contentType: "text/html; charset=windows-1251",
+			wantData:    `<meta charset="koi8-r">` + text,
+			wantCharset: "windows-1251",
+		},
+		{
+			name:        "meta tag far from start is ignored",
+			body:        []byte(strings.Repeat(" ", metaCharsetPrefixSize) + `<meta charset="koi8-r">`),
+			wantData:    strings.Repeat(" ", metaCharsetPrefixSize) + `<meta charset="koi8-r">`,
+			wantCharset: "",
+		},
+		{
+			name:        "utf-8 bom is removed",
+			body:        append([]byte{0xEF, 0xBB, 0xBF}, []byte(text)...),
+			contentType: "text/plain; charset=windows-1251",
+			wantData:    text,
+			wantCharset: "utf-8",
+		},
+		{
+			name:        "utf-16 bom",
+			body:        append([]byte{0xFF, 0xFE}, encode(t, unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM), text)...),
+			wantData:    text,
+			wantCharset: "utf-16le",
+		},
+		{
+			name:        "unknown charset keeps body",
+			body:        []byte(text),
+			contentType: "text/plain; charset=x-unknown",
+			wantData:    text,
+			wantCharset: "",
+		},
+		{
+			name:        "utf-8",
+			body:        []byte(text),
+			contentType: "text/plain; charset=UTF-8",
+			wantData:    text,
+			wantCharset: "utf-8",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, charset, err := toUTF8(tt.body, tt.contentType)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantData, string(data))
+			assert.Equal(t, tt.wantCharset, charset)
+		})
+	}
+}
@@ -2,49 +2,238 @@ package sites
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mts-test-task/pkg/sitesdataservice/api"
 )
 
+// Request describes request to site
+type Request struct {
+	Method  string
+	URL     string
+	Headers map[string]string
+	Body    string
+	// RedirectPolicy and MaxRedirects override client defaults when set
+	RedirectPolicy string
+	MaxRedirects   int
+	// MaxBodySize limits decompressed body, zero means no limit. BodyLimitMode is fail or truncate
+	MaxBodySize   int64
+	BodyLimitMode string
+	// RawBody disables transcoding of body to UTF-8
+	RawBody bool
+	// MaxAttempts overrides retry policy when set
+	MaxAttempts int
+	// RetryNonIdempotent allows retries of methods the retry policy does not repeat, such as POST and PATCH
+	RetryNonIdempotent bool
+	// MinDelay raises host delay between requests, for example to crawl-delay from robots.txt
+	MinDelay time.Duration
+	// Timeout limits each attempt after waiting for host limits, zero means no limit
+	Timeout time.Duration
+	// IfNoneMatch and IfModifiedSince make request conditional, 304 is a valid response then
+	IfNoneMatch     string
+	IfModifiedSince string
+}
+
+// Response is a result of request to site
+type Response struct {
+	Data          string
+	StatusCode    int
+	Headers       map[string]string
+	ContentType   string
+	ContentLength int64
+	Duration      time.Duration
+	FetchedAt     time.Time
+	// Charset is detected charset of body, empty if it is unknown
+	Charset string
+	// Binary is set for non-text content, Data holds raw bytes then
+	Binary bool
+	// Attempts is the number of requests made to get the response
+	Attempts int
+	// Coalesced is set when the response was shared with a concurrent identical request
+	Coalesced bool
+	// NotModified is set for 304 response to conditional request, Data is empty then
+	NotModified bool
+	// ETag and LastModified are validators for conditional requests
+	ETag         string
+	LastModified string
+	// AllHeaders are all response headers, Headers contains only those returned to user
+	AllHeaders http.Header
+	// Truncated is set when body was cut to the size limit
+	Truncated bool
+	// Redirects are followed hops, FinalURL and FinalMethod describe the last request
+	Redirects   []*Redirect
+	FinalURL    string
+	FinalMethod string
+}
+
 // Client for doing request to sites
 type Client interface {
-	GetData(ctx context.Context, url string) (data string, err error)
+	GetData(ctx context.Context, request *Request) (response *Response, err error)
 }
 
 type client struct {
-	clientHTTP http.Client
+	clientHTTP      http.Client
+	responseHeaders []string
+	userAgent       string
+	redirectPolicy  string
+	maxRedirects    int
 }
 
-func (c *client) GetData(ctx context.Context, url string) (data string, err error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+func (c *client) GetData(ctx context.Context, request *Request) (response *Response, err error) {
+	method := request.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	var requestBody io.Reader
+	if request.Body != "" {
+		requestBody = strings.NewReader(request.Body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, request.URL, requestBody)
 	if err != nil {
-		return data, fmt.Errorf("failed to create request: %s", err)
+		return response, fmt.Errorf("failed to create request: %s", err)
 	}
+	// User-Agent по умолчанию совпадает с тем, для которого проверяется robots.txt
+	req.Header.Set("User-Agent", c.userAgent)
+	for name, value := range request.Headers {
+		req.Header.Set(name, value)
+	}
+	if request.IfNoneMatch != "" {
+		req.Header.Set("If-None-Match", request.IfNoneMatch)
+	}
+	if request.IfModifiedSince != "" {
+		req.Header.Set("If-Modified-Since", request.IfModifiedSince)
+	}
+
+	// Копия клиента с собственной проверкой редиректов, транспорт при этом общий
+	checker := c.redirectChecker(request)
+	clientHTTP := c.clientHTTP
+	clientHTTP.CheckRedirect = checker.check
 
-	resp, err := c.clientHTTP.Do(req)
+	fetchedAt := time.Now()
+	resp, err := clientHTTP.Do(req)
 	if err != nil {
-		return data, fmt.Errorf("failed to make request: %s", err)
+		// Оборачиваем через %w, чтобы политика повторов могла определить класс ошибки
+		return response, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Данные не изменились, тело не передается
+	conditional := request.IfNoneMatch != "" || request.IfModifiedSince != ""
+	if resp.StatusCode == http.StatusNotModified && conditional {
+		response = &Response{
+			StatusCode:   resp.StatusCode,
+			Headers:      c.selectHeaders(resp.Header),
+			NotModified:  true,
+			AllHeaders:   resp.Header,
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+			Duration:     time.Since(fetchedAt),
+			FetchedAt:    fetchedAt,
+			Redirects:    checker.chain,
+			FinalURL:     resp.Request.URL.String(),
+			FinalMethod:  resp.Request.Method,
+		}
+		return
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return data, fmt.Errorf("status code %d for url %s", resp.StatusCode, url)
+	// Результатом считается любой ответ 2xx, в том числе 204 без тела. Без следования редиректам
+	// ответ с редиректом тоже считается результатом
+	isSuccess := resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices
+	isRedirect := resp.StatusCode >= http.StatusMultipleChoices && resp.StatusCode < http.StatusBadRequest
+	if !isSuccess && !(isRedirect && checker.policy == api.RedirectPolicyNone) {
+		return response, &StatusError{StatusCode: resp.StatusCode, URL: request.URL}
 	}
 
-	body, err := ioutil.ReadAll(resp.Body)
+	body, truncated, err := readBody(resp, request.MaxBodySize, request.BodyLimitMode)
+	if errors.Is(err, ErrBodyTooLarge) {
+		return response, err
+	}
 	if err != nil {
-		return data, fmt.Errorf("failed to read body response: %s", err)
+		return response, fmt.Errorf("failed to read body response: %w", err)
 	}
-	defer resp.Body.Close()
 
-	data = string(body)
+	contentType := resp.Header.Get("Content-Type")
+	binary := isBinary(contentType, body)
+	var charset string
+	switch {
+	case binary:
+		// Двоичные данные не перекодируются
+	case request.RawBody:
+		charset, _, _ = detectCharset(body, contentType)
+	default:
+		body, charset, err = toUTF8(body, contentType)
+		if err != nil {
+			return response, err
+		}
+	}
+
+	response = &Response{
+		Data:          string(body),
+		StatusCode:    resp.StatusCode,
+		Headers:       c.selectHeaders(resp.Header),
+		ContentType:   contentType,
+		ContentLength: int64(len(body)),
+		Charset:       charset,
+		Binary:        binary,
+		Truncated:     truncated,
+		ETag:          resp.Header.Get("ETag"),
+		LastModified:  resp.Header.Get("Last-Modified"),
+		AllHeaders:    resp.Header,
+		Duration:      time.Since(fetchedAt),
+		FetchedAt:     fetchedAt,
+		Redirects:     checker.chain,
+		FinalURL:      resp.Request.URL.String(),
+		FinalMethod:   resp.Request.Method,
+	}
+
+	return
+}
+
+// redirectChecker применяет политику редиректов запроса или клиента
+func (c *client) redirectChecker(request *Request) (checker *redirectChecker) {
+	checker = &redirectChecker{
+		policy:       c.redirectPolicy,
+		maxRedirects: c.maxRedirects,
+	}
+	if request.RedirectPolicy != "" {
+		checker.policy = request.RedirectPolicy
+	}
+	if request.MaxRedirects > 0 {
+		checker.maxRedirects = request.MaxRedirects
+	}
+
+	return
+}
+
+// selectHeaders оставляет только те заголовки ответа, которые нужно вернуть пользователю
+func (c *client) selectHeaders(header http.Header) (headers map[string]string) {
+	for _, name := range c.responseHeaders {
+		value := header.Get(name)
+		if value == "" {
+			continue
+		}
+		if headers == nil {
+			headers = make(map[string]string, len(c.responseHeaders))
+		}
+		headers[http.CanonicalHeaderKey(name)] = value
+	}
 
 	return
 }
 
 // NewClient ...
-func NewClient(clientHTTP http.Client) Client {
+func NewClient(clientHTTP http.Client, responseHeaders []string, userAgent string, redirectPolicy string, maxRedirects int) Client {
 	return &client{
-		clientHTTP: clientHTTP,
+		clientHTTP:      clientHTTP,
+		responseHeaders: responseHeaders,
+		userAgent:       userAgent,
+		redirectPolicy:  redirectPolicy,
+		maxRedirects:    maxRedirects,
 	}
 }
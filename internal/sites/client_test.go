@@ -0,0 +1,115 @@
+package sites
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mts-test-task/pkg/sitesdataservice/api"
+)
+
+func TestClient_GetData_StatusCodes(t *testing.T) {
+	tests := []struct {
+		name            string
+		status          int
+		body            string
+		redirectPolicy  string
+		ifNoneMatch     string
+		wantData        string
+		wantStatusErr   bool
+		wantNotModified bool
+	}{
+		{
+			name:     "ok",
+			status:   http.StatusOK,
+			body:     "hello",
+			wantData: "hello",
+		},
+		{
+			name:     "created",
+			status:   http.StatusCreated,
+			body:     "created",
+			wantData: "created",
+		},
+		{
+			name:   "no content",
+			status: http.StatusNoContent,
+		},
+		{
+			name:     "partial content",
+			status:   http.StatusPartialContent,
+			body:     "part",
+			wantData: "part",
+		},
+		{
+			name:           "redirect without following",
+			status:         http.StatusFound,
+			redirectPolicy: api.RedirectPolicyNone,
+			body:           "moved",
+			wantData:       "moved",
+		},
+		{
+			name:            "not modified for conditional request",
+			status:          http.StatusNotModified,
+			ifNoneMatch:     `"v1"`,
+			wantNotModified: true,
+		},
+		{
+			name:          "not modified without condition",
+			status:        http.StatusNotModified,
+			wantStatusErr: true,
+		},
+		{
+			name:          "not found",
+			status:        http.StatusNotFound,
+			body:          "missing",
+			wantStatusErr: true,
+		},
+		{
+			name:          "server error",
+			status:        http.StatusInternalServerError,
+			wantStatusErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.status == http.StatusFound {
+					w.Header().Set("Location", "/other")
+				}
+				if tt.body != "" {
+					w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := NewClient(http.Client{Timeout: time.Second}, nil, "test", api.RedirectPolicyFollow, 5)
+			response, err := client.GetData(context.Background(), &Request{
+				URL:            server.URL,
+				RedirectPolicy: tt.redirectPolicy,
+				IfNoneMatch:    tt.ifNoneMatch,
+			})
+			if tt.wantStatusErr {
+				var statusErr *StatusError
+				if assert.True(t, errors.As(err, &statusErr), "unexpected error: %v", err) {
+					assert.Equal(t, tt.status, statusErr.StatusCode)
+				}
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tt.status, response.StatusCode)
+			assert.Equal(t, tt.wantData, response.Data)
+			assert.Equal(t, tt.wantNotModified, response.NotModified)
+			assert.False(t, response.Binary)
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package sites
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// errForeignDeadline - общий запрос прерван дедлайном другого клиента
+var errForeignDeadline = errors.New("shared request exceeded deadline of another client")
+
+// leaderDeadlineError - ошибка общего запроса из-за дедлайна контекста первого клиента, а не таймаута запроса
+type leaderDeadlineError struct {
+	err error
+}
+
+// Error ...
+func (e *leaderDeadlineError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap ...
+func (e *leaderDeadlineError) Unwrap() error {
+	return e.err
+}
+
+// Метрики объединения запросов: fetches - запросы к сайтам, hits - запросы, получившие чужой результат
+var coalescingStats = expvar.NewMap("sites_coalescing")
+
+type coalescingClient struct {
+	client Client
+	group  singleflight.Group
+}
+
+// GetData shares one request to the site between concurrent identical requests
+func (c *coalescingClient) GetData(ctx context.Context, request *Request) (response *Response, err error) {
+	for {
+		response, err = c.getData(ctx, request)
+		// Общий запрос уперся в более короткий дедлайн первого клиента, повторяем со своим
+		if errors.Is(err, errForeignDeadline) && ctx.Err() == nil {
+			continue
+		}
+		return
+	}
+}
+
+func (c *coalescingClient) getData(ctx context.Context, request *Request) (response *Response, err error) {
+	executed := false
+	resultCh := c.group.DoChan(coalescingKey(request), func() (interface{}, error) {
+		executed = true
+		coalescingStats.Add("fetches", 1)
+
+		// Запрос не должен прерываться, если первый клиент ушел: результат ждут остальные.
+		// Дедлайн первого клиента сохраняется
+		fetchCtx, cancel := detachedContext(ctx)
+		defer cancel()
+		response, err := c.client.GetData(fetchCtx, request)
+		if err != nil && fetchCtx.Err() == context.DeadlineExceeded {
+			err = &leaderDeadlineError{err: err}
+		}
+		return response, err
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("failed to wait for request: %w", ctx.Err())
+	case result := <-resultCh:
+		var leaderDeadlineErr *leaderDeadlineError
+		if result.Err != nil && !executed && errors.As(result.Err, &leaderDeadlineErr) {
+			return nil, fmt.Errorf("%w: %s", errForeignDeadline, result.Err)
+		}
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		response = result.Val.(*Response)
+		// Результат общий, поэтому отметку об объединении ставим в копии
+		if !executed {
+			coalescingStats.Add("hits", 1)
+			shared := *response
+			shared.Coalesced = true
+			response = &shared
+		}
+		return response, nil
+	}
+}
+
+// detachedContext возвращает контекст без отмены родителя, но с его дедлайном
+func detachedContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if deadline, ok := ctx.Deadline(); ok {
+		return context.WithDeadline(context.Background(), deadline)
+	}
+
+	return context.WithCancel(context.Background())
+}
+
+// coalescingKey описывает все параметры запроса, от которых зависит ответ
+func coalescingKey(request *Request) string {
+	names := make([]string, 0, len(request.Headers))
+	for name := range request.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	method := strings.ToUpper(request.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s\n", method, normalizeURL(request.URL))
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s: %s\n", strings.ToLower(name), request.Headers[name])
+	}
+	fmt.Fprintf(&b, "\n%s\n%s %d %d %s %t %s\n%s\n%s",
+		request.Body,
+		request.RedirectPolicy, request.MaxRedirects,
+		request.MaxBodySize, request.BodyLimitMode,
+		request.RawBody, request.Timeout,
+		request.IfNoneMatch, request.IfModifiedSince,
+	)
+
+	return b.String()
+}
+
+// normalizeURL приводит схему и хост к нижнему регистру, остальное сравнивается как есть
+func normalizeURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+
+	return u.String()
+}
+
+// NewCoalescingClient wraps client with coalescing of concurrent identical requests
+func NewCoalescingClient(client Client) Client {
+	return &coalescingClient{
+		client: client,
+	}
+}
@@ -0,0 +1,117 @@
+package sites
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoalescingClient_SharedErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		// leaderTimeout - дедлайн контекста первого клиента, 0 - без дедлайна
+		leaderTimeout time.Duration
+		wantFetches   int32
+		wantErr       bool
+	}{
+		{
+			name:        "request timeout is shared",
+			wantFetches: 1,
+			wantErr:     true,
+		},
+		{
+			name:          "leader deadline is retried by follower",
+			leaderTimeout: 20 * time.Millisecond,
+			wantFetches:   2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fetches int32
+			client := NewCoalescingClient(clientFunc(func(ctx context.Context, request *Request) (*Response, error) {
+				n := atomic.AddInt32(&fetches, 1)
+				// Первый запрос к сайту не укладывается ни в какой дедлайн
+				if n == 1 {
+					if tt.leaderTimeout == 0 {
+						time.Sleep(50 * time.Millisecond)
+						return nil, context.DeadlineExceeded
+					}
+					<-ctx.Done()
+					return nil, ctx.Err()
+				}
+				return &Response{Data: "ok"}, nil
+			}))
+
+			leaderCtx := context.Background()
+			if tt.leaderTimeout > 0 {
+				var cancel context.CancelFunc
+				leaderCtx, cancel = context.WithTimeout(leaderCtx, tt.leaderTimeout)
+				defer cancel()
+			}
+
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _ = client.GetData(leaderCtx, &Request{URL: limitedURL})
+			}()
+			time.Sleep(10 * time.Millisecond)
+			response, err := client.GetData(context.Background(), &Request{URL: limitedURL})
+			wg.Wait()
+
+			assert.Equal(t, tt.wantFetches, atomic.LoadInt32(&fetches))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "ok", response.Data)
+			assert.True(t, response.Coalesced || tt.wantFetches > 1)
+		})
+	}
+}
+
+func TestCoalescingKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		a, b  *Request
+		equal bool
+	}{
+		{
+			name:  "scheme and host case",
+			a:     &Request{URL: "HTTP://Example.com/a"},
+			b:     &Request{URL: "http://example.com/a"},
+			equal: true,
+		},
+		{
+			name:  "default method",
+			a:     &Request{URL: limitedURL},
+			b:     &Request{URL: limitedURL, Method: "get"},
+			equal: true,
+		},
+		{
+			name: "path case",
+			a:    &Request{URL: "http://example.com/A"},
+			b:    &Request{URL: "http://example.com/a"},
+		},
+		{
+			name: "headers",
+			a:    &Request{URL: limitedURL, Headers: map[string]string{"Accept": "text/html"}},
+			b:    &Request{URL: limitedURL, Headers: map[string]string{"Accept": "application/json"}},
+		},
+		{
+			name: "timeout",
+			a:    &Request{URL: limitedURL, Timeout: time.Second},
+			b:    &Request{URL: limitedURL, Timeout: 2 * time.Second},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.equal, coalescingKey(tt.a) == coalescingKey(tt.b))
+		})
+	}
+}
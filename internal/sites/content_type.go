@@ -0,0 +1,53 @@
+package sites
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// Типы application/*, которые содержат текст
+var textApplicationTypes = map[string]bool{
+	"application/json":                  true,
+	"application/xml":                   true,
+	"application/javascript":            true,
+	"application/ecmascript":            true,
+	"application/x-javascript":          true,
+	"application/xhtml+xml":             true,
+	"application/x-www-form-urlencoded": true,
+	"application/x-ndjson":              true,
+	"application/graphql":               true,
+	"application/yaml":                  true,
+	"application/x-yaml":                true,
+	"application/sql":                   true,
+	"application/csv":                   true,
+}
+
+// isBinary определяет, что тело не является текстом. Без заголовка Content-Type тип определяется по содержимому
+func isBinary(contentType string, body []byte) bool {
+	if strings.TrimSpace(contentType) == "" {
+		contentType = http.DetectContentType(body)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		// Тип не разобрать, решаем по содержимому
+		return !utf8.Valid(body)
+	}
+	// Явно указанная кодировка означает текст
+	if params["charset"] != "" {
+		return false
+	}
+
+	switch {
+	case strings.HasPrefix(mediaType, "text/"):
+		return false
+	case strings.HasSuffix(mediaType, "+json"), strings.HasSuffix(mediaType, "+xml"):
+		return false
+	case textApplicationTypes[mediaType]:
+		return false
+	}
+
+	return true
+}
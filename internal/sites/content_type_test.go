@@ -0,0 +1,56 @@
+package sites
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var pngHeader = []byte{0x89, 'P', 'N', 'G', 0x0D, 0x0A, 0x1A, 0x0A, 0x00, 0x00, 0x00, 0x0D}
+
+func TestIsBinary(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		binary      bool
+	}{
+		{name: "html", contentType: "text/html; charset=utf-8", body: []byte("<html></html>")},
+		{name: "json", contentType: "application/json", body: []byte(`{"a":1}`)},
+		{name: "json suffix", contentType: "application/problem+json", body: []byte(`{}`)},
+		{name: "xml suffix", contentType: "application/atom+xml", body: []byte(`<feed/>`)},
+		{name: "charset means text", contentType: "application/octet-stream; charset=utf-8", body: []byte("text")},
+		{name: "image", contentType: "image/png", body: pngHeader, binary: true},
+		{name: "octet stream", contentType: "application/octet-stream", body: []byte("text"), binary: true},
+		{name: "pdf", contentType: "application/pdf", body: []byte("%PDF-1.4"), binary: true},
+		{name: "sniffed text", body: []byte("plain text")},
+		{name: "sniffed image", body: pngHeader, binary: true},
+		{name: "broken type with text", contentType: "text/", body: []byte("plain text")},
+		{name: "broken type with invalid utf-8", contentType: "text/", body: []byte{0xFF, 0xFE, 0x00}, binary: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.binary, isBinary(tt.contentType, tt.body))
+		})
+	}
+}
+
+func TestClient_GetData_BinaryIsNotTranscoded(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write(pngHeader)
+	}))
+	defer server.Close()
+
+	client := NewClient(http.Client{Timeout: time.Second}, nil, "test", "", 5)
+	response, err := client.GetData(context.Background(), &Request{URL: server.URL})
+	if assert.NoError(t, err) {
+		assert.True(t, response.Binary)
+		assert.Equal(t, string(pngHeader), response.Data)
+		assert.Empty(t, response.Charset)
+	}
+}
@@ -0,0 +1,226 @@
+package sites
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// ErrHostBusy is returned when the host queue does not reach the request within the wait budget
+var ErrHostBusy = errors.New("host is busy")
+
+// HostLimit restricts requests to one host. Zero MaxConcurrent means no limit
+type HostLimit struct {
+	MaxConcurrent int
+	MinDelay      time.Duration
+}
+
+// HostLimiter applies per-host limits shared by all requests to sites
+type HostLimiter interface {
+	// Wait blocks until a request to the host of rawURL may start, release must be called when it is done
+	Wait(ctx context.Context, rawURL string, minDelay time.Duration) (release func(), err error)
+}
+
+// hostLimiter хранит состояние ограничений одного хоста
+type hostLimiter struct {
+	sem *semaphore.Weighted
+	// users - число запросов, которые ждут или выполняются, по нему неиспользуемый хост удаляется
+	users int
+	mu    sync.Mutex
+	next  time.Time
+}
+
+type hostLimits struct {
+	defaultLimit HostLimit
+	overrides    map[string]HostLimit
+	// maxWait - отдельный от таймаута запроса срок ожидания очереди хоста, 0 - без ограничения
+	maxWait time.Duration
+	mu      sync.Mutex
+	hosts   map[string]*hostLimiter
+}
+
+func (h *hostLimits) Wait(ctx context.Context, rawURL string, minDelay time.Duration) (release func(), err error) {
+	host, err := requestHost(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	limit := h.limit(host)
+	if limit.MinDelay > minDelay {
+		minDelay = limit.MinDelay
+	}
+
+	waitCtx := ctx
+	if h.maxWait > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, h.maxWait)
+		defer cancel()
+	}
+
+	limiter := h.acquireLimiter(host, limit)
+	if limiter.sem != nil {
+		if err = limiter.sem.Acquire(waitCtx, 1); err != nil {
+			h.releaseLimiter(host, limiter)
+			return nil, h.waitError(ctx, host, err)
+		}
+	}
+	if err = limiter.wait(waitCtx, minDelay); err != nil {
+		if limiter.sem != nil {
+			limiter.sem.Release(1)
+		}
+		h.releaseLimiter(host, limiter)
+		return nil, h.waitError(ctx, host, err)
+	}
+
+	release = func() {
+		if limiter.sem != nil {
+			limiter.sem.Release(1)
+		}
+		h.releaseLimiter(host, limiter)
+	}
+	return release, nil
+}
+
+// waitError отличает исчерпанный срок ожидания очереди от отмены самого запроса
+func (h *hostLimits) waitError(ctx context.Context, host string, err error) error {
+	if ctx.Err() == nil && !errors.Is(err, ErrHostBusy) {
+		err = fmt.Errorf("%w: %s", ErrHostBusy, err)
+	}
+
+	return fmt.Errorf("failed to wait for host %s: %w", host, err)
+}
+
+func (h *hostLimits) limit(host string) HostLimit {
+	if limit, ok := h.overrides[host]; ok {
+		return limit
+	}
+
+	return h.defaultLimit
+}
+
+func (h *hostLimits) acquireLimiter(host string, limit HostLimit) (limiter *hostLimiter) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	limiter, ok := h.hosts[host]
+	if !ok {
+		limiter = &hostLimiter{}
+		if limit.MaxConcurrent > 0 {
+			limiter.sem = semaphore.NewWeighted(int64(limit.MaxConcurrent))
+		}
+		h.hosts[host] = limiter
+	}
+	limiter.users++
+
+	return
+}
+
+// releaseLimiter удаляет состояние хоста, когда к нему нет запросов и пауза уже прошла
+func (h *hostLimits) releaseLimiter(host string, limiter *hostLimiter) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	limiter.users--
+	if limiter.users > 0 {
+		return
+	}
+	limiter.mu.Lock()
+	idle := !time.Now().Before(limiter.next)
+	limiter.mu.Unlock()
+	if idle {
+		delete(h.hosts, host)
+	}
+}
+
+// wait ждет, пока с начала предыдущего запроса пройдет пауза, и только тогда занимает время начала.
+// Поэтому ушедший из очереди запрос не сдвигает время для остальных, а запрос, которому не хватит
+// срока ожидания, сразу получает ErrHostBusy
+func (l *hostLimiter) wait(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return nil
+	}
+
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		if !l.next.After(now) {
+			l.next = now.Add(delay)
+			l.mu.Unlock()
+			return nil
+		}
+		start := l.next
+		l.mu.Unlock()
+
+		if deadline, ok := ctx.Deadline(); ok && start.After(deadline) {
+			return ErrHostBusy
+		}
+		timer := time.NewTimer(start.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+type hostLimitClient struct {
+	client  Client
+	limiter HostLimiter
+}
+
+// GetData ждет очереди хоста и только потом отсчитывает таймаут запроса
+func (h *hostLimitClient) GetData(ctx context.Context, request *Request) (response *Response, err error) {
+	release, err := h.limiter.Wait(ctx, request.URL, request.MinDelay)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if request.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, request.Timeout)
+		defer cancel()
+	}
+
+	// Редиректы на другие хосты выполняются в рамках ограничений исходного хоста
+	return h.client.GetData(ctx, request)
+}
+
+// requestHost возвращает имя хоста урла в нижнем регистре без порта
+func requestHost(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse url: %s", err)
+	}
+
+	return strings.ToLower(u.Hostname()), nil
+}
+
+// NewHostLimiter creates per-host concurrency limits and delays, maxWait limits waiting for the host queue
+func NewHostLimiter(defaultLimit HostLimit, overrides map[string]HostLimit, maxWait time.Duration) HostLimiter {
+	normalized := make(map[string]HostLimit, len(overrides))
+	for host, limit := range overrides {
+		normalized[strings.ToLower(host)] = limit
+	}
+
+	return &hostLimits{
+		defaultLimit: defaultLimit,
+		overrides:    normalized,
+		maxWait:      maxWait,
+		hosts:        make(map[string]*hostLimiter),
+	}
+}
+
+// NewHostLimitClient wraps client with host limits, Request.Timeout is counted after waiting for the host queue
+func NewHostLimitClient(client Client, limiter HostLimiter) Client {
+	return &hostLimitClient{
+		client:  client,
+		limiter: limiter,
+	}
+}
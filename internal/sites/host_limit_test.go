@@ -0,0 +1,126 @@
+package sites
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const limitedURL = "http://example.com/page"
+
+type clientFunc func(ctx context.Context, request *Request) (*Response, error)
+
+func (f clientFunc) GetData(ctx context.Context, request *Request) (*Response, error) {
+	return f(ctx, request)
+}
+
+func TestHostLimiter_WaitBudget(t *testing.T) {
+	tests := []struct {
+		name    string
+		limit   HostLimit
+		maxWait time.Duration
+		// ctxTimeout - дедлайн запроса, который ждет после первого
+		ctxTimeout time.Duration
+		wantErr    error
+		maxElapsed time.Duration
+	}{
+		{
+			name:       "delay fits deadline",
+			limit:      HostLimit{MinDelay: 20 * time.Millisecond},
+			ctxTimeout: time.Second,
+			maxElapsed: 200 * time.Millisecond,
+		},
+		{
+			name:       "delay after deadline fails without waiting",
+			limit:      HostLimit{MinDelay: time.Second},
+			ctxTimeout: 100 * time.Millisecond,
+			wantErr:    ErrHostBusy,
+			maxElapsed: 50 * time.Millisecond,
+		},
+		{
+			name:       "delay after max wait fails without waiting",
+			limit:      HostLimit{MinDelay: time.Second},
+			maxWait:    100 * time.Millisecond,
+			wantErr:    ErrHostBusy,
+			maxElapsed: 50 * time.Millisecond,
+		},
+		{
+			name:       "busy slots until max wait",
+			limit:      HostLimit{MaxConcurrent: 1},
+			maxWait:    50 * time.Millisecond,
+			wantErr:    ErrHostBusy,
+			maxElapsed: 500 * time.Millisecond,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := NewHostLimiter(tt.limit, nil, tt.maxWait)
+			release, err := limiter.Wait(context.Background(), limitedURL, 0)
+			assert.NoError(t, err)
+			defer release()
+
+			ctx := context.Background()
+			if tt.ctxTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.ctxTimeout)
+				defer cancel()
+			}
+			begin := time.Now()
+			second, err := limiter.Wait(ctx, limitedURL, 0)
+			assert.Less(t, int64(time.Since(begin)), int64(tt.maxElapsed))
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "unexpected error: %v", err)
+				return
+			}
+			assert.NoError(t, err)
+			second()
+		})
+	}
+}
+
+func TestHostLimiter_CancelledWaiterKeepsQueue(t *testing.T) {
+	delay := 100 * time.Millisecond
+	limiter := NewHostLimiter(HostLimit{MinDelay: delay}, nil, 0)
+	release, err := limiter.Wait(context.Background(), limitedURL, 0)
+	assert.NoError(t, err)
+	release()
+	begin := time.Now()
+
+	// Ушедший из очереди запрос не должен занять время начала
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	_, err = limiter.Wait(ctx, limitedURL, 0)
+	assert.True(t, errors.Is(err, context.Canceled), "unexpected error: %v", err)
+
+	release, err = limiter.Wait(context.Background(), limitedURL, 0)
+	assert.NoError(t, err)
+	release()
+	elapsed := time.Since(begin)
+	assert.GreaterOrEqual(t, int64(elapsed), int64(delay-10*time.Millisecond))
+	assert.Less(t, int64(elapsed), int64(2*delay))
+}
+
+func TestHostLimitClient_TimeoutAfterWait(t *testing.T) {
+	delay := 100 * time.Millisecond
+	client := NewHostLimitClient(
+		clientFunc(func(ctx context.Context, request *Request) (*Response, error) {
+			deadline, ok := ctx.Deadline()
+			assert.True(t, ok)
+			// Таймаут отсчитывается от начала запроса, а не от начала ожидания
+			assert.Greater(t, int64(time.Until(deadline)), int64(request.Timeout/2))
+			return &Response{}, nil
+		}),
+		NewHostLimiter(HostLimit{MinDelay: delay}, nil, time.Second),
+	)
+
+	for i := 0; i < 3; i++ {
+		_, err := client.GetData(context.Background(), &Request{URL: limitedURL, Timeout: delay / 2})
+		assert.NoError(t, err)
+	}
+}
@@ -0,0 +1,45 @@
+package sites
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/mts-test-task/pkg/sitesdataservice/api"
+)
+
+// Redirect is one hop of redirect chain
+type Redirect struct {
+	URL        string
+	StatusCode int
+}
+
+// redirectChecker следит за редиректами одного запроса и запоминает цепочку
+type redirectChecker struct {
+	policy       string
+	maxRedirects int
+	chain        []*Redirect
+}
+
+// check реализует http.Client.CheckRedirect
+func (r *redirectChecker) check(req *http.Request, via []*http.Request) error {
+	// Ответ с редиректом возвращается как есть
+	if r.policy == api.RedirectPolicyNone {
+		return http.ErrUseLastResponse
+	}
+
+	previous := via[len(via)-1]
+	redirect := &Redirect{URL: previous.URL.String()}
+	if req.Response != nil {
+		redirect.StatusCode = req.Response.StatusCode
+	}
+	r.chain = append(r.chain, redirect)
+
+	if r.policy == api.RedirectPolicySameHost && req.URL.Host != via[0].URL.Host {
+		return fmt.Errorf("redirect from %s to other host %s is not allowed", previous.URL, req.URL.Host)
+	}
+	if len(via) > r.maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", r.maxRedirects)
+	}
+
+	return nil
+}
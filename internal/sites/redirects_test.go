@@ -0,0 +1,125 @@
+package sites
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mts-test-task/pkg/sitesdataservice/api"
+)
+
+func TestClient_GetData_Redirects(t *testing.T) {
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("other"))
+	}))
+	defer other.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/a":
+			http.Redirect(w, r, "/b", http.StatusMovedPermanently)
+		case "/b":
+			http.Redirect(w, r, "/c", http.StatusFound)
+		case "/c":
+			_, _ = w.Write([]byte("final"))
+		case "/other":
+			http.Redirect(w, r, other.URL+"/page", http.StatusFound)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name          string
+		path          string
+		policy        string
+		maxRedirects  int
+		wantErr       bool
+		wantData      string
+		wantStatus    int
+		wantChain     []int
+		wantFinalPath string
+	}{
+		{
+			name:          "follow chain",
+			path:          "/a",
+			policy:        api.RedirectPolicyFollow,
+			wantData:      "final",
+			wantStatus:    http.StatusOK,
+			wantChain:     []int{http.StatusMovedPermanently, http.StatusFound},
+			wantFinalPath: "/c",
+		},
+		{
+			name:         "too many redirects",
+			path:         "/a",
+			policy:       api.RedirectPolicyFollow,
+			maxRedirects: 1,
+			wantErr:      true,
+		},
+		{
+			name:       "none returns redirect",
+			path:       "/a",
+			policy:     api.RedirectPolicyNone,
+			wantStatus: http.StatusMovedPermanently,
+		},
+		{
+			name:          "same host follows own redirects",
+			path:          "/b",
+			policy:        api.RedirectPolicySameHost,
+			wantData:      "final",
+			wantStatus:    http.StatusOK,
+			wantChain:     []int{http.StatusFound},
+			wantFinalPath: "/c",
+		},
+		{
+			name:    "same host rejects other host",
+			path:    "/other",
+			policy:  api.RedirectPolicySameHost,
+			wantErr: true,
+		},
+		{
+			name:          "follow other host",
+			path:          "/other",
+			policy:        api.RedirectPolicyFollow,
+			wantData:      "other",
+			wantStatus:    http.StatusOK,
+			wantChain:     []int{http.StatusFound},
+			wantFinalPath: "/page",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClient(http.Client{Timeout: time.Second}, nil, "test", api.RedirectPolicyFollow, 5)
+			response, err := client.GetData(context.Background(), &Request{
+				URL:            server.URL + tt.path,
+				RedirectPolicy: tt.policy,
+				MaxRedirects:   tt.maxRedirects,
+			})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tt.wantStatus, response.StatusCode)
+			if tt.wantData != "" {
+				assert.Equal(t, tt.wantData, response.Data)
+			}
+			chain := make([]int, 0, len(response.Redirects))
+			for _, redirect := range response.Redirects {
+				chain = append(chain, redirect.StatusCode)
+			}
+			if len(tt.wantChain) == 0 {
+				assert.Empty(t, chain)
+				return
+			}
+			assert.Equal(t, tt.wantChain, chain)
+			assert.Equal(t, server.URL+tt.path, response.Redirects[0].URL)
+			assert.Contains(t, response.FinalURL, tt.wantFinalPath)
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package sites
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Классы сетевых ошибок, после которых запрос можно повторить
+const (
+	RetryErrorTimeout           = "timeout"
+	RetryErrorConnectionReset   = "connection_reset"
+	RetryErrorConnectionRefused = "connection_refused"
+	RetryErrorDNS               = "dns"
+	RetryErrorEOF               = "eof"
+)
+
+// StatusError is returned when site responds with unexpected status code
+type StatusError struct {
+	StatusCode int
+	URL        string
+}
+
+// Error ...
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("status code %d for url %s", e.StatusCode, e.URL)
+}
+
+// AttemptsError keeps the number of attempts made before the error
+type AttemptsError struct {
+	Attempts int
+	Err      error
+}
+
+// Error ...
+func (e *AttemptsError) Error() string {
+	return fmt.Sprintf("%s (attempts: %d)", e.Err, e.Attempts)
+}
+
+// Unwrap ...
+func (e *AttemptsError) Unwrap() error {
+	return e.Err
+}
+
+// AttemptsOf returns the number of attempts stored in error, zero if it is unknown
+func AttemptsOf(err error) int {
+	var attemptsErr *AttemptsError
+	if errors.As(err, &attemptsErr) {
+		return attemptsErr.Attempts
+	}
+
+	return 0
+}
+
+// RetryPolicy describes when and how often requests are repeated
+type RetryPolicy struct {
+	MaxAttempts      int
+	BaseBackoff      time.Duration
+	MaxBackoff       time.Duration
+	RetryStatusCodes []int
+	RetryErrors      []string
+	// RetryMethods are repeated automatically, POST and PATCH are repeated only when request allows it
+	RetryMethods []string
+}
+
+type retryClient struct {
+	client      Client
+	policy      RetryPolicy
+	statusCodes map[int]bool
+	errors      map[string]bool
+	methods     map[string]bool
+}
+
+func (r *retryClient) GetData(ctx context.Context, request *Request) (response *Response, err error) {
+	maxAttempts := r.policy.MaxAttempts
+	if request.MaxAttempts > 0 {
+		maxAttempts = request.MaxAttempts
+	}
+	// Повтор неидемпотентного запроса может повторить его действие на сайте
+	method := strings.ToUpper(request.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	if !r.methods[method] && !request.RetryNonIdempotent {
+		maxAttempts = 1
+	}
+
+	backoff := r.policy.BaseBackoff
+	for attempt := 1; ; attempt++ {
+		response, err = r.client.GetData(ctx, request)
+		if err == nil {
+			response.Attempts = attempt
+			return
+		}
+		if attempt >= maxAttempts || !r.isRetryable(ctx, err) {
+			return nil, &AttemptsError{Attempts: attempt, Err: err}
+		}
+
+		// Полный джиттер: пауза случайна в пределах текущего шага, чтобы повторы к одному сайту не совпадали
+		pause := time.Duration(rand.Int63n(int64(backoff) + 1))
+		// Повтор, который не успеет до дедлайна, делать бессмысленно
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= pause {
+			return nil, &AttemptsError{Attempts: attempt, Err: err}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, &AttemptsError{Attempts: attempt, Err: err}
+		case <-time.After(pause):
+		}
+
+		backoff *= 2
+		if backoff > r.policy.MaxBackoff {
+			backoff = r.policy.MaxBackoff
+		}
+	}
+}
+
+// isRetryable проверяет, что ошибка временная и ее класс разрешен политикой
+func (r *retryClient) isRetryable(ctx context.Context, err error) bool {
+	// Истек таймаут урла или отменен весь запрос
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return r.statusCodes[statusErr.StatusCode]
+	}
+
+	class := errorClass(err)
+	return class != "" && r.errors[class]
+}
+
+// errorClass определяет класс сетевой ошибки, пустая строка - ошибка не сетевая
+func errorClass(err error) string {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	switch {
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE):
+		return RetryErrorConnectionReset
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return RetryErrorConnectionRefused
+	case errors.As(err, &dnsErr):
+		return RetryErrorDNS
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return RetryErrorEOF
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return RetryErrorTimeout
+	}
+
+	return ""
+}
+
+// NewRetryClient wraps client with retry policy
+func NewRetryClient(client Client, policy RetryPolicy) Client {
+	statusCodes := make(map[int]bool, len(policy.RetryStatusCodes))
+	for _, code := range policy.RetryStatusCodes {
+		statusCodes[code] = true
+	}
+	retryErrors := make(map[string]bool, len(policy.RetryErrors))
+	for _, class := range policy.RetryErrors {
+		retryErrors[class] = true
+	}
+	// POST и PATCH не повторяются без явного разрешения, даже если перечислены в политике
+	methods := make(map[string]bool, len(policy.RetryMethods))
+	for _, method := range policy.RetryMethods {
+		method = strings.ToUpper(method)
+		if method != http.MethodPost && method != http.MethodPatch {
+			methods[method] = true
+		}
+	}
+
+	return &retryClient{
+		client:      client,
+		policy:      policy,
+		statusCodes: statusCodes,
+		errors:      retryErrors,
+		methods:     methods,
+	}
+}
@@ -0,0 +1,179 @@
+package sites
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestErrorClass(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		class string
+	}{
+		{name: "connection reset", err: &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, class: RetryErrorConnectionReset},
+		{name: "broken pipe", err: fmt.Errorf("write: %w", syscall.EPIPE), class: RetryErrorConnectionReset},
+		{name: "connection refused", err: &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, class: RetryErrorConnectionRefused},
+		{name: "dns", err: fmt.Errorf("failed to make request: %w", &net.DNSError{Err: "no such host", Name: "example.invalid"}), class: RetryErrorDNS},
+		{name: "eof", err: fmt.Errorf("failed to make request: %w", io.EOF), class: RetryErrorEOF},
+		{name: "unexpected eof", err: io.ErrUnexpectedEOF, class: RetryErrorEOF},
+		{name: "timeout", err: fmt.Errorf("failed to make request: %w", timeoutError{}), class: RetryErrorTimeout},
+		{name: "other", err: errors.New("bad request")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.class, errorClass(tt.err))
+		})
+	}
+}
+
+func TestRetryClient_GetData(t *testing.T) {
+	refused := &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}
+	tests := []struct {
+		name string
+		// errs - ошибки попыток по порядку, после них запрос успешен
+		errs               []error
+		method             string
+		retryNonIdempotent bool
+		maxAttempts        int
+		ctxTimeout         time.Duration
+		wantAttempts       int
+		wantErr            bool
+	}{
+		{
+			name:         "success",
+			wantAttempts: 1,
+		},
+		{
+			name:         "retryable status then success",
+			errs:         []error{&StatusError{StatusCode: http.StatusServiceUnavailable}},
+			wantAttempts: 2,
+		},
+		{
+			name:         "status is not retryable",
+			errs:         []error{&StatusError{StatusCode: http.StatusNotFound}},
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "error class is retryable",
+			errs:         []error{refused, refused},
+			wantAttempts: 3,
+		},
+		{
+			name:         "error class is not in policy",
+			errs:         []error{&net.DNSError{Err: "no such host"}},
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "attempts are limited",
+			errs:         []error{refused, refused, refused, refused},
+			wantAttempts: 3,
+			wantErr:      true,
+		},
+		{
+			name:         "request overrides attempts",
+			errs:         []error{refused, refused},
+			maxAttempts:  1,
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "configured method is retried",
+			errs:         []error{refused},
+			method:       http.MethodPut,
+			wantAttempts: 2,
+		},
+		{
+			name:         "method is not in policy",
+			errs:         []error{refused},
+			method:       http.MethodDelete,
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "post is not retried even if it is in policy",
+			errs:         []error{refused},
+			method:       http.MethodPost,
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+		{
+			name:               "post is retried when request allows it",
+			errs:               []error{refused, refused},
+			method:             http.MethodPost,
+			retryNonIdempotent: true,
+			wantAttempts:       3,
+		},
+		{
+			name:         "pause after deadline is not waited",
+			errs:         []error{refused},
+			ctxTimeout:   time.Millisecond,
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			client := NewRetryClient(
+				clientFunc(func(ctx context.Context, request *Request) (*Response, error) {
+					calls++
+					if calls <= len(tt.errs) {
+						return nil, tt.errs[calls-1]
+					}
+					return &Response{}, nil
+				}),
+				RetryPolicy{
+					MaxAttempts:      3,
+					BaseBackoff:      time.Millisecond,
+					MaxBackoff:       2 * time.Millisecond,
+					RetryStatusCodes: []int{http.StatusServiceUnavailable},
+					RetryErrors:      []string{RetryErrorConnectionRefused},
+					RetryMethods:     []string{http.MethodGet, "put", http.MethodPost},
+				},
+			)
+			ctx := context.Background()
+			if tt.ctxTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.ctxTimeout)
+				defer cancel()
+				// Пауза повтора заведомо длиннее оставшегося времени
+				client.(*retryClient).policy.BaseBackoff = time.Hour
+			}
+
+			response, err := client.GetData(ctx, &Request{
+				Method:             tt.method,
+				URL:                limitedURL,
+				MaxAttempts:        tt.maxAttempts,
+				RetryNonIdempotent: tt.retryNonIdempotent,
+			})
+			assert.Equal(t, tt.wantAttempts, calls)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Equal(t, tt.wantAttempts, AttemptsOf(err))
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.wantAttempts, response.Attempts)
+			}
+		})
+	}
+}
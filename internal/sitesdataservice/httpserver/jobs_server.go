@@ -0,0 +1,124 @@
+package httpserver
+
+import (
+	"context"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/mts-test-task/pkg/sitesdataservice/api"
+	"github.com/mts-test-task/pkg/sitesdataservice/httperror"
+)
+
+type jobsService interface {
+	CreateJob(ctx context.Context, request *api.CreateJobRequest) (job *api.Job, err error)
+	GetJob(ctx context.Context, id string, offset int, limit int) (response *api.GetJobResponse, err error)
+	CancelJob(ctx context.Context, id string) (job *api.Job, err error)
+}
+
+type createJobServer struct {
+	transport      CreateJobTransport
+	service        jobsService
+	errorProcessor httperror.ErrorProcessor
+}
+
+// ServeHTTP implements http.Handler.
+func (c *createJobServer) ServeHTTP(ctx *fasthttp.RequestCtx) {
+	request, err := c.transport.DecodeRequest(ctx, &ctx.Request)
+	if err != nil {
+		c.errorProcessor.Encode(ctx, &ctx.Response, err)
+		return
+	}
+
+	job, err := c.service.CreateJob(ctx, request)
+	if err != nil {
+		c.errorProcessor.Encode(ctx, &ctx.Response, err)
+		return
+	}
+
+	if err := c.transport.EncodeResponse(ctx, &ctx.Response, job); err != nil {
+		c.errorProcessor.Encode(ctx, &ctx.Response, err)
+		return
+	}
+}
+
+// NewCreateJobServer the server creator
+func NewCreateJobServer(transport CreateJobTransport, service jobsService, errorProcessor httperror.ErrorProcessor) fasthttp.RequestHandler {
+	ls := createJobServer{
+		transport:      transport,
+		service:        service,
+		errorProcessor: errorProcessor,
+	}
+	return ls.ServeHTTP
+}
+
+type getJobServer struct {
+	transport      GetJobTransport
+	service        jobsService
+	errorProcessor httperror.ErrorProcessor
+}
+
+// ServeHTTP implements http.Handler.
+func (g *getJobServer) ServeHTTP(ctx *fasthttp.RequestCtx) {
+	id, offset, limit, err := g.transport.DecodeRequest(ctx, &ctx.Request)
+	if err != nil {
+		g.errorProcessor.Encode(ctx, &ctx.Response, err)
+		return
+	}
+
+	response, err := g.service.GetJob(ctx, id, offset, limit)
+	if err != nil {
+		g.errorProcessor.Encode(ctx, &ctx.Response, err)
+		return
+	}
+
+	if err := g.transport.EncodeResponse(ctx, &ctx.Response, response); err != nil {
+		g.errorProcessor.Encode(ctx, &ctx.Response, err)
+		return
+	}
+}
+
+// NewGetJobServer the server creator
+func NewGetJobServer(transport GetJobTransport, service jobsService, errorProcessor httperror.ErrorProcessor) fasthttp.RequestHandler {
+	ls := getJobServer{
+		transport:      transport,
+		service:        service,
+		errorProcessor: errorProcessor,
+	}
+	return ls.ServeHTTP
+}
+
+type cancelJobServer struct {
+	transport      CancelJobTransport
+	service        jobsService
+	errorProcessor httperror.ErrorProcessor
+}
+
+// ServeHTTP implements http.Handler.
+func (c *cancelJobServer) ServeHTTP(ctx *fasthttp.RequestCtx) {
+	id, err := c.transport.DecodeRequest(ctx, &ctx.Request)
+	if err != nil {
+		c.errorProcessor.Encode(ctx, &ctx.Response, err)
+		return
+	}
+
+	job, err := c.service.CancelJob(ctx, id)
+	if err != nil {
+		c.errorProcessor.Encode(ctx, &ctx.Response, err)
+		return
+	}
+
+	if err := c.transport.EncodeResponse(ctx, &ctx.Response, job); err != nil {
+		c.errorProcessor.Encode(ctx, &ctx.Response, err)
+		return
+	}
+}
+
+// NewCancelJobServer the server creator
+func NewCancelJobServer(transport CancelJobTransport, service jobsService, errorProcessor httperror.ErrorProcessor) fasthttp.RequestHandler {
+	ls := cancelJobServer{
+		transport:      transport,
+		service:        service,
+		errorProcessor: errorProcessor,
+	}
+	return ls.ServeHTTP
+}
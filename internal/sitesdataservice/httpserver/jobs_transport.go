@@ -0,0 +1,150 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/mts-test-task/pkg/sitesdataservice/api"
+	"github.com/mts-test-task/pkg/sitesdataservice/httperror"
+)
+
+// CreateJobTransport transport interface
+type CreateJobTransport interface {
+	DecodeRequest(ctx context.Context, r *fasthttp.Request) (request *api.CreateJobRequest, err error)
+	EncodeResponse(ctx context.Context, r *fasthttp.Response, job *api.Job) (err error)
+}
+
+type createJobTransport struct {
+	errorCreator httperror.ErrorCreator
+}
+
+// DecodeRequest method for decoding requests on server side
+func (c *createJobTransport) DecodeRequest(ctx context.Context, r *fasthttp.Request) (request *api.CreateJobRequest, err error) {
+	request = new(api.CreateJobRequest)
+	if err = json.Unmarshal(r.Body(), request); err != nil {
+		return request, c.errorCreator(
+			http.StatusBadRequest,
+			"Не удалось обработать запрос",
+			fmt.Sprintf("failed to decode JSON request: %v", err),
+		)
+	}
+	return
+}
+
+// EncodeResponse method for encoding response on server side
+func (c *createJobTransport) EncodeResponse(ctx context.Context, r *fasthttp.Response, job *api.Job) (err error) {
+	r.Header.Set("Content-Type", "application/json")
+	r.SetStatusCode(http.StatusAccepted)
+	return encodeJSON(r, job, c.errorCreator)
+}
+
+// NewCreateJobTransport the transport creator for http requests
+func NewCreateJobTransport(errorCreator httperror.ErrorCreator) CreateJobTransport {
+	return &createJobTransport{
+		errorCreator: errorCreator,
+	}
+}
+
+// GetJobTransport transport interface
+type GetJobTransport interface {
+	DecodeRequest(ctx context.Context, r *fasthttp.Request) (id string, offset int, limit int, err error)
+	EncodeResponse(ctx context.Context, r *fasthttp.Response, response *api.GetJobResponse) (err error)
+}
+
+type getJobTransport struct {
+	errorCreator httperror.ErrorCreator
+}
+
+// DecodeRequest method for decoding requests on server side
+func (g *getJobTransport) DecodeRequest(ctx context.Context, r *fasthttp.Request) (id string, offset int, limit int, err error) {
+	id = pathParam(ctx, PathParamID)
+
+	offset, limit, err = decodePage(r, g.errorCreator)
+	return
+}
+
+// EncodeResponse method for encoding response on server side
+func (g *getJobTransport) EncodeResponse(ctx context.Context, r *fasthttp.Response, response *api.GetJobResponse) (err error) {
+	r.Header.Set("Content-Type", "application/json")
+	return encodeJSON(r, response, g.errorCreator)
+}
+
+// NewGetJobTransport the transport creator for http requests
+func NewGetJobTransport(errorCreator httperror.ErrorCreator) GetJobTransport {
+	return &getJobTransport{
+		errorCreator: errorCreator,
+	}
+}
+
+// CancelJobTransport transport interface
+type CancelJobTransport interface {
+	DecodeRequest(ctx context.Context, r *fasthttp.Request) (id string, err error)
+	EncodeResponse(ctx context.Context, r *fasthttp.Response, job *api.Job) (err error)
+}
+
+type cancelJobTransport struct {
+	errorCreator httperror.ErrorCreator
+}
+
+// DecodeRequest method for decoding requests on server side
+func (c *cancelJobTransport) DecodeRequest(ctx context.Context, r *fasthttp.Request) (id string, err error) {
+	return pathParam(ctx, PathParamID), nil
+}
+
+// EncodeResponse method for encoding response on server side
+func (c *cancelJobTransport) EncodeResponse(ctx context.Context, r *fasthttp.Response, job *api.Job) (err error) {
+	r.Header.Set("Content-Type", "application/json")
+	return encodeJSON(r, job, c.errorCreator)
+}
+
+// NewCancelJobTransport the transport creator for http requests
+func NewCancelJobTransport(errorCreator httperror.ErrorCreator) CancelJobTransport {
+	return &cancelJobTransport{
+		errorCreator: errorCreator,
+	}
+}
+
+// decodePage разбирает параметры страницы из строки запроса
+func decodePage(r *fasthttp.Request, errorCreator httperror.ErrorCreator) (offset int, limit int, err error) {
+	args := r.URI().QueryArgs()
+	if args.Has(QueryArgOffset) {
+		if offset, err = args.GetUint(QueryArgOffset); err != nil {
+			return offset, limit, errorCreator(
+				http.StatusBadRequest,
+				fmt.Sprintf("Ошибка ввода: неверное значение %s", QueryArgOffset),
+				fmt.Sprintf("failed to decode query arg %s: %v", QueryArgOffset, err),
+			)
+		}
+	}
+	if args.Has(QueryArgLimit) {
+		if limit, err = args.GetUint(QueryArgLimit); err != nil {
+			return offset, limit, errorCreator(
+				http.StatusBadRequest,
+				fmt.Sprintf("Ошибка ввода: неверное значение %s", QueryArgLimit),
+				fmt.Sprintf("failed to decode query arg %s: %v", QueryArgLimit, err),
+			)
+		}
+	}
+	return
+}
+
+// pathParam возвращает параметр пути, который роутер кладет в контекст запроса
+func pathParam(ctx context.Context, name string) string {
+	value, _ := ctx.Value(name).(string)
+	return value
+}
+
+func encodeJSON(r *fasthttp.Response, response interface{}, errorCreator httperror.ErrorCreator) (err error) {
+	if err = json.NewEncoder(r.BodyWriter()).Encode(response); err != nil {
+		return errorCreator(
+			http.StatusInternalServerError,
+			"Не удалось обработать ответ",
+			fmt.Sprintf("failed to encode JSON response: %s", err),
+		)
+	}
+	return
+}
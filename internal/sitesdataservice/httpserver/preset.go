@@ -2,9 +2,60 @@ package httpserver
 
 // URIs
 const (
-	URIPrefix = "/api/v1"
+	URIPrefix   = "/api/v1"
+	URIPrefixV2 = "/api/v2"
 
 	URIPathGetDataFromURLs = URIPrefix + "/urls/data"
 
 	HTTPMethodGetDataFromURLs = "POST"
+
+	URIPathGetDataFromURLsV2 = URIPrefixV2 + "/urls/data"
+
+	HTTPMethodGetDataFromURLsV2 = "POST"
+
+	URIPathJobs = URIPrefix + "/jobs"
+	URIPathJob  = URIPathJobs + "/:" + PathParamID
+
+	HTTPMethodCreateJob = "POST"
+	HTTPMethodGetJob    = "GET"
+	HTTPMethodCancelJob = "DELETE"
+
+	URIPathWebhookDeliveries     = URIPrefix + "/webhooks/deliveries"
+	URIPathReplayWebhookDelivery = URIPathWebhookDeliveries + "/:" + PathParamID + "/replay"
+
+	HTTPMethodGetFailedWebhookDeliveries = "GET"
+	HTTPMethodReplayWebhookDelivery      = "POST"
+
+	URIPathSnapshots = URIPrefix + "/snapshots"
+	URIPathSnapshot  = URIPathSnapshots + "/:" + PathParamID
+	// Путь сравнения не вложен в URIPathSnapshots: он конфликтовал бы с параметром идентификатора снимка
+	URIPathSnapshotsDiff = URIPrefix + "/diff"
+
+	HTTPMethodGetSnapshots     = "GET"
+	HTTPMethodGetSnapshot      = "GET"
+	HTTPMethodGetSnapshotsDiff = "GET"
+)
+
+// Path params
+const (
+	PathParamID = "id"
+)
+
+// Content types
+const (
+	ContentTypeNDJSON = "application/x-ndjson"
+)
+
+// Query args
+const (
+	QueryArgFailureMode = "failure_mode"
+	QueryArgOffset      = "offset"
+	QueryArgLimit       = "limit"
+	QueryArgURL         = "url"
+	QueryArgFrom        = "from"
+	QueryArgTo          = "to"
+	QueryArgCursor      = "cursor"
+	QueryArgFromID      = "from_id"
+	QueryArgToID        = "to_id"
+	QueryArgFormat      = "format"
 )
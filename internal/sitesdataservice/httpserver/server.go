@@ -1,8 +1,11 @@
 package httpserver
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/buaazp/fasthttprouter"
 	"github.com/mts-test-task/pkg/sitesdataservice/httperror"
@@ -12,24 +15,34 @@ import (
 )
 
 type service interface {
-	GetDataFromURLs(ctx context.Context, request []string) (response []*api.SiteData, err error)
+	GetDataFromURLsWithOptions(ctx context.Context, requests []*api.SiteRequest, options *api.FetchOptions) (response []*api.SiteData, err error)
+	StreamDataFromURLs(ctx context.Context, requests []*api.SiteRequest, options *api.FetchOptions, handle func(siteData *api.SiteData) error) (err error)
+	jobsService
+	snapshotsService
 }
 
 type getDataFromURLsServer struct {
 	transport      GetDataFromURLsTransport
 	service        service
 	errorProcessor httperror.ErrorProcessor
+	// streamTimeout ограничивает обработку потокового ответа, который не покрывается таймаутом хендлера
+	streamTimeout time.Duration
 }
 
 // ServeHTTP implements http.Handler.
 func (g *getDataFromURLsServer) ServeHTTP(ctx *fasthttp.RequestCtx) {
-	request, err := g.transport.DecodeRequest(ctx, &ctx.Request)
+	requests, options, err := g.transport.DecodeRequest(ctx, &ctx.Request)
 	if err != nil {
 		g.errorProcessor.Encode(ctx, &ctx.Response, err)
 		return
 	}
 
-	response, err := g.service.GetDataFromURLs(ctx, request)
+	if bytes.Contains(ctx.Request.Header.Peek("Accept"), []byte(ContentTypeNDJSON)) {
+		g.serveStream(ctx, requests, options)
+		return
+	}
+
+	response, err := g.service.GetDataFromURLsWithOptions(ctx, requests, options)
 	if err != nil {
 		g.errorProcessor.Encode(ctx, &ctx.Response, err)
 		return
@@ -41,28 +54,156 @@ func (g *getDataFromURLsServer) ServeHTTP(ctx *fasthttp.RequestCtx) {
 	}
 }
 
-// NewGetDataFromURLsServer the server creator
-func NewGetDataFromURLsServer(transport GetDataFromURLsTransport, service service, errorProcessor httperror.ErrorProcessor) fasthttp.RequestHandler {
+// serveStream отдает результат по каждому урлу отдельной строкой NDJSON по мере готовности
+func (g *getDataFromURLsServer) serveStream(ctx *fasthttp.RequestCtx, requests []*api.SiteRequest, options *api.FetchOptions) {
+	// Обработка продолжается после выхода из хендлера, поэтому сам контекст запроса не используем: таймаут
+	// задается как у обычного ответа, а остановка сервера прерывает обработку через канал Done сервера
+	streamCtx, cancel := context.WithCancel(context.Background())
+	if g.streamTimeout > 0 {
+		streamCtx, cancel = context.WithTimeout(context.Background(), g.streamTimeout)
+	}
+	serverDone := ctx.Done()
+	go func() {
+		select {
+		case <-serverDone:
+			cancel()
+		case <-streamCtx.Done():
+		}
+	}()
+
+	// Буфер на все урлы, чтобы сервис не блокировался на медленном клиенте
+	results := make(chan *api.SiteData, len(requests))
+	done := make(chan error, 1)
+	go func() {
+		err := g.service.StreamDataFromURLs(streamCtx, requests, options, func(siteData *api.SiteData) error {
+			results <- siteData
+			return nil
+		})
+		close(results)
+		done <- err
+	}()
+
+	// Ошибку, полученную до первого результата (например, ошибку валидации), отдаем обычным ответом
+	first, ok := <-results
+	if !ok {
+		cancel()
+		if err := <-done; err != nil {
+			g.errorProcessor.Encode(ctx, &ctx.Response, err)
+		}
+		return
+	}
+
+	ctx.SetContentType(ContentTypeNDJSON)
+	ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+		for siteData := first; ok; siteData, ok = <-results {
+			if err := g.transport.EncodeStreamItem(streamCtx, w, siteData); err != nil {
+				return
+			}
+			// Клиент отключился, прекращаем обработку
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+		// Ошибка после начала передачи уже отдана строкой с результатом по урлу
+		<-done
+	})
+}
+
+// NewGetDataFromURLsServer the server creator, streamTimeout limits NDJSON responses, zero means no limit
+func NewGetDataFromURLsServer(
+	transport GetDataFromURLsTransport,
+	service service,
+	errorProcessor httperror.ErrorProcessor,
+	streamTimeout time.Duration,
+) fasthttp.RequestHandler {
 	ls := getDataFromURLsServer{
 		transport:      transport,
 		service:        service,
 		errorProcessor: errorProcessor,
+		streamTimeout:  streamTimeout,
 	}
 	return ls.ServeHTTP
 }
 
-// NewPreparedServer factory for server api handler
-func NewPreparedServer(svc service) *fasthttprouter.Router {
+// NewPreparedServer factory for server api handler, timeout should match the timeout of the handler
+func NewPreparedServer(svc service, timeout time.Duration) *fasthttprouter.Router {
 	errorProcessor := httperror.NewErrorProcessor(http.StatusInternalServerError, "Внутряння ошибка сервиса")
 
 	getDataFromURLsTransport := NewGetDataFromURLsTransport(httperror.NewError)
+	getDataFromURLsV2Transport := NewGetDataFromURLsV2Transport(httperror.NewError)
+	createJobTransport := NewCreateJobTransport(httperror.NewError)
+	getJobTransport := NewGetJobTransport(httperror.NewError)
+	cancelJobTransport := NewCancelJobTransport(httperror.NewError)
+	getSnapshotsTransport := NewGetSnapshotsTransport(httperror.NewError)
+	getSnapshotTransport := NewGetSnapshotTransport(httperror.NewError)
+	getSnapshotsDiffTransport := NewGetSnapshotsDiffTransport(httperror.NewError)
 
 	return MakeFastHTTPRouter(
 		[]*HandlerSettings{
 			{
 				Path:    URIPathGetDataFromURLs,
 				Method:  HTTPMethodGetDataFromURLs,
-				Handler: NewGetDataFromURLsServer(getDataFromURLsTransport, svc, errorProcessor),
+				Handler: NewGetDataFromURLsServer(getDataFromURLsTransport, svc, errorProcessor, timeout),
+			},
+			{
+				Path:    URIPathGetDataFromURLsV2,
+				Method:  HTTPMethodGetDataFromURLsV2,
+				Handler: NewGetDataFromURLsServer(getDataFromURLsV2Transport, svc, errorProcessor, timeout),
+			},
+			{
+				Path:    URIPathJobs,
+				Method:  HTTPMethodCreateJob,
+				Handler: NewCreateJobServer(createJobTransport, svc, errorProcessor),
+			},
+			{
+				Path:    URIPathJob,
+				Method:  HTTPMethodGetJob,
+				Handler: NewGetJobServer(getJobTransport, svc, errorProcessor),
+			},
+			{
+				Path:    URIPathJob,
+				Method:  HTTPMethodCancelJob,
+				Handler: NewCancelJobServer(cancelJobTransport, svc, errorProcessor),
+			},
+			{
+				Path:    URIPathSnapshots,
+				Method:  HTTPMethodGetSnapshots,
+				Handler: NewGetSnapshotsServer(getSnapshotsTransport, svc, errorProcessor),
+			},
+			{
+				Path:    URIPathSnapshot,
+				Method:  HTTPMethodGetSnapshot,
+				Handler: NewGetSnapshotServer(getSnapshotTransport, svc, errorProcessor),
+			},
+			{
+				Path:    URIPathSnapshotsDiff,
+				Method:  HTTPMethodGetSnapshotsDiff,
+				Handler: NewGetSnapshotsDiffServer(getSnapshotsDiffTransport, svc, errorProcessor),
+			},
+		},
+	)
+}
+
+// NewPreparedAdminServer factory for operator api handler. Undelivered webhooks contain callback urls and job ids
+// of all clients, so the handler is served on the admin address only, not on the public port
+func NewPreparedAdminServer(svc webhooksService) *fasthttprouter.Router {
+	errorProcessor := httperror.NewErrorProcessor(http.StatusInternalServerError, "Внутряння ошибка сервиса")
+
+	getFailedWebhookDeliveriesTransport := NewGetFailedWebhookDeliveriesTransport(httperror.NewError)
+	replayWebhookDeliveryTransport := NewReplayWebhookDeliveryTransport(httperror.NewError)
+
+	return MakeFastHTTPRouter(
+		[]*HandlerSettings{
+			{
+				Path:    URIPathWebhookDeliveries,
+				Method:  HTTPMethodGetFailedWebhookDeliveries,
+				Handler: NewGetFailedWebhookDeliveriesServer(getFailedWebhookDeliveriesTransport, svc, errorProcessor),
+			},
+			{
+				Path:    URIPathReplayWebhookDelivery,
+				Method:  HTTPMethodReplayWebhookDelivery,
+				Handler: NewReplayWebhookDeliveryServer(replayWebhookDeliveryTransport, svc, errorProcessor),
 			},
 		},
 	)
@@ -0,0 +1,95 @@
+package httpserver
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+
+	"github.com/mts-test-task/pkg/sitesdataservice/api"
+	"github.com/mts-test-task/pkg/sitesdataservice/httperror"
+)
+
+// streamServiceStub отдает первый результат и ждет отмены контекста потока
+type streamServiceStub struct {
+	service
+	ctxErr chan error
+}
+
+func (s *streamServiceStub) StreamDataFromURLs(ctx context.Context, requests []*api.SiteRequest, options *api.FetchOptions, handle func(siteData *api.SiteData) error) (err error) {
+	if err = handle(&api.SiteData{URL: requests[0].URL, Status: api.SiteDataStatusOK}); err != nil {
+		return
+	}
+	<-ctx.Done()
+	s.ctxErr <- ctx.Err()
+	return ctx.Err()
+}
+
+func TestGetDataFromURLsServer_StreamContext(t *testing.T) {
+	tests := []struct {
+		name          string
+		streamTimeout time.Duration
+		shutdown      bool
+		wantErr       error
+	}{
+		{
+			name:          "stream is limited by handler timeout",
+			streamTimeout: 50 * time.Millisecond,
+			wantErr:       context.DeadlineExceeded,
+		},
+		{
+			name:     "stream is cancelled on shutdown",
+			shutdown: true,
+			wantErr:  context.Canceled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &streamServiceStub{ctxErr: make(chan error, 1)}
+			server := &fasthttp.Server{Handler: NewGetDataFromURLsServer(
+				NewGetDataFromURLsTransport(httperror.NewError),
+				svc,
+				httperror.NewErrorProcessor(http.StatusInternalServerError, "error"),
+				tt.streamTimeout,
+			)}
+			listener := fasthttputil.NewInmemoryListener()
+			go func() {
+				_ = server.Serve(listener)
+			}()
+
+			go func() {
+				client := &fasthttp.Client{Dial: func(addr string) (net.Conn, error) {
+					return listener.Dial()
+				}}
+				request := fasthttp.AcquireRequest()
+				defer fasthttp.ReleaseRequest(request)
+				request.SetRequestURI("http://sites.example.com" + URIPathGetDataFromURLs)
+				request.Header.SetMethod(HTTPMethodGetDataFromURLs)
+				request.Header.Set("Accept", ContentTypeNDJSON)
+				request.SetBodyString(`["http://example.com"]`)
+				_ = client.Do(request, &fasthttp.Response{})
+			}()
+
+			if tt.shutdown {
+				// Даем потоку начаться, до остановки сервера контекст не отменяется
+				time.Sleep(50 * time.Millisecond)
+				assert.Empty(t, svc.ctxErr)
+				go func() {
+					_ = server.Shutdown()
+				}()
+			}
+			select {
+			case err := <-svc.ctxErr:
+				assert.Equal(t, tt.wantErr, err)
+			case <-time.After(5 * time.Second):
+				t.Fatal("stream context is not cancelled")
+			}
+			_ = listener.Close()
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package httpserver
+
+import (
+	"context"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/mts-test-task/pkg/sitesdataservice/api"
+	"github.com/mts-test-task/pkg/sitesdataservice/httperror"
+)
+
+type snapshotsService interface {
+	GetSnapshots(ctx context.Context, request *api.GetSnapshotsRequest) (response *api.GetSnapshotsResponse, err error)
+	GetSnapshot(ctx context.Context, id string) (snapshot *api.Snapshot, err error)
+	GetSnapshotsDiff(ctx context.Context, request *api.GetSnapshotsDiffRequest) (diff *api.SnapshotsDiff, err error)
+}
+
+type getSnapshotsServer struct {
+	transport      GetSnapshotsTransport
+	service        snapshotsService
+	errorProcessor httperror.ErrorProcessor
+}
+
+// ServeHTTP implements http.Handler.
+func (g *getSnapshotsServer) ServeHTTP(ctx *fasthttp.RequestCtx) {
+	request, err := g.transport.DecodeRequest(ctx, &ctx.Request)
+	if err != nil {
+		g.errorProcessor.Encode(ctx, &ctx.Response, err)
+		return
+	}
+
+	response, err := g.service.GetSnapshots(ctx, request)
+	if err != nil {
+		g.errorProcessor.Encode(ctx, &ctx.Response, err)
+		return
+	}
+
+	if err := g.transport.EncodeResponse(ctx, &ctx.Response, response); err != nil {
+		g.errorProcessor.Encode(ctx, &ctx.Response, err)
+		return
+	}
+}
+
+// NewGetSnapshotsServer the server creator
+func NewGetSnapshotsServer(
+	transport GetSnapshotsTransport,
+	service snapshotsService,
+	errorProcessor httperror.ErrorProcessor,
+) fasthttp.RequestHandler {
+	ls := getSnapshotsServer{
+		transport:      transport,
+		service:        service,
+		errorProcessor: errorProcessor,
+	}
+	return ls.ServeHTTP
+}
+
+type getSnapshotServer struct {
+	transport      GetSnapshotTransport
+	service        snapshotsService
+	errorProcessor httperror.ErrorProcessor
+}
+
+// ServeHTTP implements http.Handler.
+func (g *getSnapshotServer) ServeHTTP(ctx *fasthttp.RequestCtx) {
+	id, err := g.transport.DecodeRequest(ctx, &ctx.Request)
+	if err != nil {
+		g.errorProcessor.Encode(ctx, &ctx.Response, err)
+		return
+	}
+
+	snapshot, err := g.service.GetSnapshot(ctx, id)
+	if err != nil {
+		g.errorProcessor.Encode(ctx, &ctx.Response, err)
+		return
+	}
+
+	if err := g.transport.EncodeResponse(ctx, &ctx.Response, snapshot); err != nil {
+		g.errorProcessor.Encode(ctx, &ctx.Response, err)
+		return
+	}
+}
+
+// NewGetSnapshotServer the server creator
+func NewGetSnapshotServer(
+	transport GetSnapshotTransport,
+	service snapshotsService,
+	errorProcessor httperror.ErrorProcessor,
+) fasthttp.RequestHandler {
+	ls := getSnapshotServer{
+		transport:      transport,
+		service:        service,
+		errorProcessor: errorProcessor,
+	}
+	return ls.ServeHTTP
+}
+
+type getSnapshotsDiffServer struct {
+	transport      GetSnapshotsDiffTransport
+	service        snapshotsService
+	errorProcessor httperror.ErrorProcessor
+}
+
+// ServeHTTP implements http.Handler.
+func (g *getSnapshotsDiffServer) ServeHTTP(ctx *fasthttp.RequestCtx) {
+	request, err := g.transport.DecodeRequest(ctx, &ctx.Request)
+	if err != nil {
+		g.errorProcessor.Encode(ctx, &ctx.Response, err)
+		return
+	}
+
+	diff, err := g.service.GetSnapshotsDiff(ctx, request)
+	if err != nil {
+		g.errorProcessor.Encode(ctx, &ctx.Response, err)
+		return
+	}
+
+	if err := g.transport.EncodeResponse(ctx, &ctx.Response, diff); err != nil {
+		g.errorProcessor.Encode(ctx, &ctx.Response, err)
+		return
+	}
+}
+
+// NewGetSnapshotsDiffServer the server creator
+func NewGetSnapshotsDiffServer(
+	transport GetSnapshotsDiffTransport,
+	service snapshotsService,
+	errorProcessor httperror.ErrorProcessor,
+) fasthttp.RequestHandler {
+	ls := getSnapshotsDiffServer{
+		transport:      transport,
+		service:        service,
+		errorProcessor: errorProcessor,
+	}
+	return ls.ServeHTTP
+}
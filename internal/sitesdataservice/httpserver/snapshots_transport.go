@@ -0,0 +1,143 @@
+package httpserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/mts-test-task/pkg/sitesdataservice/api"
+	"github.com/mts-test-task/pkg/sitesdataservice/httperror"
+)
+
+// GetSnapshotsTransport transport interface
+type GetSnapshotsTransport interface {
+	DecodeRequest(ctx context.Context, r *fasthttp.Request) (request *api.GetSnapshotsRequest, err error)
+	EncodeResponse(ctx context.Context, r *fasthttp.Response, response *api.GetSnapshotsResponse) (err error)
+}
+
+type getSnapshotsTransport struct {
+	errorCreator httperror.ErrorCreator
+}
+
+// DecodeRequest method for decoding requests on server side
+func (g *getSnapshotsTransport) DecodeRequest(ctx context.Context, r *fasthttp.Request) (request *api.GetSnapshotsRequest, err error) {
+	args := r.URI().QueryArgs()
+	request = &api.GetSnapshotsRequest{
+		URL:    string(args.Peek(QueryArgURL)),
+		Cursor: string(args.Peek(QueryArgCursor)),
+	}
+	if request.From, err = decodeTime(args, QueryArgFrom, g.errorCreator); err != nil {
+		return
+	}
+	if request.To, err = decodeTime(args, QueryArgTo, g.errorCreator); err != nil {
+		return
+	}
+	if args.Has(QueryArgLimit) {
+		if request.Limit, err = args.GetUint(QueryArgLimit); err != nil {
+			return request, g.errorCreator(
+				http.StatusBadRequest,
+				fmt.Sprintf("Ошибка ввода: неверное значение %s", QueryArgLimit),
+				fmt.Sprintf("failed to decode query arg %s: %v", QueryArgLimit, err),
+			)
+		}
+	}
+	return
+}
+
+// decodeTime разбирает время в формате RFC 3339, отсутствующий параметр дает нулевое время
+func decodeTime(args *fasthttp.Args, name string, errorCreator httperror.ErrorCreator) (value time.Time, err error) {
+	if !args.Has(name) {
+		return
+	}
+	if value, err = time.Parse(time.RFC3339, string(args.Peek(name))); err != nil {
+		return value, errorCreator(
+			http.StatusBadRequest,
+			fmt.Sprintf("Ошибка ввода: неверное значение %s", name),
+			fmt.Sprintf("failed to decode query arg %s: %v", name, err),
+		)
+	}
+	return
+}
+
+// EncodeResponse method for encoding response on server side
+func (g *getSnapshotsTransport) EncodeResponse(ctx context.Context, r *fasthttp.Response, response *api.GetSnapshotsResponse) (err error) {
+	r.Header.Set("Content-Type", "application/json")
+	return encodeJSON(r, response, g.errorCreator)
+}
+
+// NewGetSnapshotsTransport the transport creator for http requests
+func NewGetSnapshotsTransport(errorCreator httperror.ErrorCreator) GetSnapshotsTransport {
+	return &getSnapshotsTransport{
+		errorCreator: errorCreator,
+	}
+}
+
+// GetSnapshotTransport transport interface
+type GetSnapshotTransport interface {
+	DecodeRequest(ctx context.Context, r *fasthttp.Request) (id string, err error)
+	EncodeResponse(ctx context.Context, r *fasthttp.Response, snapshot *api.Snapshot) (err error)
+}
+
+type getSnapshotTransport struct {
+	errorCreator httperror.ErrorCreator
+}
+
+// DecodeRequest method for decoding requests on server side
+func (g *getSnapshotTransport) DecodeRequest(ctx context.Context, r *fasthttp.Request) (id string, err error) {
+	return pathParam(ctx, PathParamID), nil
+}
+
+// EncodeResponse method for encoding response on server side
+func (g *getSnapshotTransport) EncodeResponse(ctx context.Context, r *fasthttp.Response, snapshot *api.Snapshot) (err error) {
+	r.Header.Set("Content-Type", "application/json")
+	return encodeJSON(r, snapshot, g.errorCreator)
+}
+
+// NewGetSnapshotTransport the transport creator for http requests
+func NewGetSnapshotTransport(errorCreator httperror.ErrorCreator) GetSnapshotTransport {
+	return &getSnapshotTransport{
+		errorCreator: errorCreator,
+	}
+}
+
+// GetSnapshotsDiffTransport transport interface
+type GetSnapshotsDiffTransport interface {
+	DecodeRequest(ctx context.Context, r *fasthttp.Request) (request *api.GetSnapshotsDiffRequest, err error)
+	EncodeResponse(ctx context.Context, r *fasthttp.Response, diff *api.SnapshotsDiff) (err error)
+}
+
+type getSnapshotsDiffTransport struct {
+	errorCreator httperror.ErrorCreator
+}
+
+// DecodeRequest method for decoding requests on server side
+func (g *getSnapshotsDiffTransport) DecodeRequest(ctx context.Context, r *fasthttp.Request) (request *api.GetSnapshotsDiffRequest, err error) {
+	args := r.URI().QueryArgs()
+	request = &api.GetSnapshotsDiffRequest{
+		FromID: string(args.Peek(QueryArgFromID)),
+		ToID:   string(args.Peek(QueryArgToID)),
+		URL:    string(args.Peek(QueryArgURL)),
+		Format: string(args.Peek(QueryArgFormat)),
+	}
+	if request.From, err = decodeTime(args, QueryArgFrom, g.errorCreator); err != nil {
+		return
+	}
+	request.To, err = decodeTime(args, QueryArgTo, g.errorCreator)
+	return
+}
+
+// EncodeResponse method for encoding response on server side
+func (g *getSnapshotsDiffTransport) EncodeResponse(ctx context.Context, r *fasthttp.Response, diff *api.SnapshotsDiff) (err error) {
+	r.Header.Set("Content-Type", "application/json")
+	return encodeJSON(r, diff, g.errorCreator)
+}
+
+// NewGetSnapshotsDiffTransport the transport creator for http requests
+func NewGetSnapshotsDiffTransport(errorCreator httperror.ErrorCreator) GetSnapshotsDiffTransport {
+	return &getSnapshotsDiffTransport{
+		errorCreator: errorCreator,
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 
 	"github.com/valyala/fasthttp"
@@ -14,8 +15,9 @@ import (
 
 // GetDataFromURLsTransport transport interface
 type GetDataFromURLsTransport interface {
-	DecodeRequest(ctx context.Context, r *fasthttp.Request) (urls []string, err error)
+	DecodeRequest(ctx context.Context, r *fasthttp.Request) (requests []*api.SiteRequest, options *api.FetchOptions, err error)
 	EncodeResponse(ctx context.Context, r *fasthttp.Response, response []*api.SiteData) (err error)
+	EncodeStreamItem(ctx context.Context, w io.Writer, siteData *api.SiteData) (err error)
 }
 
 type getDataFromURLsTransport struct {
@@ -23,14 +25,18 @@ type getDataFromURLsTransport struct {
 }
 
 // DecodeRequest method for decoding requests on server side
-func (g *getDataFromURLsTransport) DecodeRequest(ctx context.Context, r *fasthttp.Request) (urls []string, err error) {
-	if err = json.Unmarshal(r.Body(), &urls); err != nil {
-		return urls, g.errorCreator(
+func (g *getDataFromURLsTransport) DecodeRequest(ctx context.Context, r *fasthttp.Request) (requests []*api.SiteRequest, options *api.FetchOptions, err error) {
+	if err = json.Unmarshal(r.Body(), &requests); err != nil {
+		return requests, options, g.errorCreator(
 			http.StatusBadRequest,
 			"Не удалось обработать запрос",
 			fmt.Sprintf("failed to decode JSON request: %v", err),
 		)
 	}
+
+	options = &api.FetchOptions{
+		FailureMode: string(r.URI().QueryArgs().Peek(QueryArgFailureMode)),
+	}
 	return
 }
 
@@ -47,6 +53,12 @@ func (g *getDataFromURLsTransport) EncodeResponse(ctx context.Context, r *fastht
 	return
 }
 
+// EncodeStreamItem method for encoding one line of NDJSON stream on server side
+func (g *getDataFromURLsTransport) EncodeStreamItem(ctx context.Context, w io.Writer, siteData *api.SiteData) (err error) {
+	// Encode дописывает перевод строки после каждого объекта
+	return json.NewEncoder(w).Encode(siteData)
+}
+
 // NewGetDataFromURLsTransport the transport creator for http requests
 func NewGetDataFromURLsTransport(errorCreator httperror.ErrorCreator) GetDataFromURLsTransport {
 	return &getDataFromURLsTransport{
@@ -0,0 +1,61 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/mts-test-task/pkg/sitesdataservice/api"
+	"github.com/mts-test-task/pkg/sitesdataservice/httperror"
+)
+
+type getDataFromURLsV2Transport struct {
+	errorCreator httperror.ErrorCreator
+}
+
+// DecodeRequest method for decoding v2 envelope on server side
+func (g *getDataFromURLsV2Transport) DecodeRequest(ctx context.Context, r *fasthttp.Request) (requests []*api.SiteRequest, options *api.FetchOptions, err error) {
+	var request api.GetDataFromURLsRequest
+	if err = json.Unmarshal(r.Body(), &request); err != nil {
+		return requests, options, g.errorCreator(
+			http.StatusBadRequest,
+			"Не удалось обработать запрос",
+			fmt.Sprintf("failed to decode JSON request: %v", err),
+		)
+	}
+
+	options = request.Options
+	if options == nil {
+		options = &api.FetchOptions{}
+	}
+	return request.URLs, options, nil
+}
+
+// EncodeResponse method for encoding v2 envelope on server side
+func (g *getDataFromURLsV2Transport) EncodeResponse(ctx context.Context, r *fasthttp.Response, response []*api.SiteData) (err error) {
+	r.Header.Set("Content-Type", "application/json")
+	if err = json.NewEncoder(r.BodyWriter()).Encode(&api.GetDataFromURLsResponse{Results: response}); err != nil {
+		return g.errorCreator(
+			http.StatusInternalServerError,
+			"Не удалось обработать ответ",
+			fmt.Sprintf("failed to encode JSON response: %s", err),
+		)
+	}
+	return
+}
+
+// EncodeStreamItem method for encoding one line of NDJSON stream on server side
+func (g *getDataFromURLsV2Transport) EncodeStreamItem(ctx context.Context, w io.Writer, siteData *api.SiteData) (err error) {
+	return json.NewEncoder(w).Encode(siteData)
+}
+
+// NewGetDataFromURLsV2Transport the transport creator for v2 http requests
+func NewGetDataFromURLsV2Transport(errorCreator httperror.ErrorCreator) GetDataFromURLsTransport {
+	return &getDataFromURLsV2Transport{
+		errorCreator: errorCreator,
+	}
+}
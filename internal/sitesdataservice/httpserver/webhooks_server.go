@@ -0,0 +1,95 @@
+package httpserver
+
+import (
+	"context"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/mts-test-task/pkg/sitesdataservice/api"
+	"github.com/mts-test-task/pkg/sitesdataservice/httperror"
+)
+
+type webhooksService interface {
+	GetFailedWebhookDeliveries(ctx context.Context, offset int, limit int) (deliveries []*api.WebhookDelivery, err error)
+	ReplayWebhookDelivery(ctx context.Context, id string) (delivery *api.WebhookDelivery, err error)
+}
+
+type getFailedWebhookDeliveriesServer struct {
+	transport      GetFailedWebhookDeliveriesTransport
+	service        webhooksService
+	errorProcessor httperror.ErrorProcessor
+}
+
+// ServeHTTP implements http.Handler.
+func (g *getFailedWebhookDeliveriesServer) ServeHTTP(ctx *fasthttp.RequestCtx) {
+	offset, limit, err := g.transport.DecodeRequest(ctx, &ctx.Request)
+	if err != nil {
+		g.errorProcessor.Encode(ctx, &ctx.Response, err)
+		return
+	}
+
+	deliveries, err := g.service.GetFailedWebhookDeliveries(ctx, offset, limit)
+	if err != nil {
+		g.errorProcessor.Encode(ctx, &ctx.Response, err)
+		return
+	}
+
+	if err := g.transport.EncodeResponse(ctx, &ctx.Response, deliveries); err != nil {
+		g.errorProcessor.Encode(ctx, &ctx.Response, err)
+		return
+	}
+}
+
+// NewGetFailedWebhookDeliveriesServer the server creator
+func NewGetFailedWebhookDeliveriesServer(
+	transport GetFailedWebhookDeliveriesTransport,
+	service webhooksService,
+	errorProcessor httperror.ErrorProcessor,
+) fasthttp.RequestHandler {
+	ls := getFailedWebhookDeliveriesServer{
+		transport:      transport,
+		service:        service,
+		errorProcessor: errorProcessor,
+	}
+	return ls.ServeHTTP
+}
+
+type replayWebhookDeliveryServer struct {
+	transport      ReplayWebhookDeliveryTransport
+	service        webhooksService
+	errorProcessor httperror.ErrorProcessor
+}
+
+// ServeHTTP implements http.Handler.
+func (rw *replayWebhookDeliveryServer) ServeHTTP(ctx *fasthttp.RequestCtx) {
+	id, err := rw.transport.DecodeRequest(ctx, &ctx.Request)
+	if err != nil {
+		rw.errorProcessor.Encode(ctx, &ctx.Response, err)
+		return
+	}
+
+	delivery, err := rw.service.ReplayWebhookDelivery(ctx, id)
+	if err != nil {
+		rw.errorProcessor.Encode(ctx, &ctx.Response, err)
+		return
+	}
+
+	if err := rw.transport.EncodeResponse(ctx, &ctx.Response, delivery); err != nil {
+		rw.errorProcessor.Encode(ctx, &ctx.Response, err)
+		return
+	}
+}
+
+// NewReplayWebhookDeliveryServer the server creator
+func NewReplayWebhookDeliveryServer(
+	transport ReplayWebhookDeliveryTransport,
+	service webhooksService,
+	errorProcessor httperror.ErrorProcessor,
+) fasthttp.RequestHandler {
+	ls := replayWebhookDeliveryServer{
+		transport:      transport,
+		service:        service,
+		errorProcessor: errorProcessor,
+	}
+	return ls.ServeHTTP
+}
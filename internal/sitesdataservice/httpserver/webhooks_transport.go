@@ -0,0 +1,66 @@
+package httpserver
+
+import (
+	"context"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/mts-test-task/pkg/sitesdataservice/api"
+	"github.com/mts-test-task/pkg/sitesdataservice/httperror"
+)
+
+// GetFailedWebhookDeliveriesTransport transport interface
+type GetFailedWebhookDeliveriesTransport interface {
+	DecodeRequest(ctx context.Context, r *fasthttp.Request) (offset int, limit int, err error)
+	EncodeResponse(ctx context.Context, r *fasthttp.Response, deliveries []*api.WebhookDelivery) (err error)
+}
+
+type getFailedWebhookDeliveriesTransport struct {
+	errorCreator httperror.ErrorCreator
+}
+
+// DecodeRequest method for decoding requests on server side
+func (g *getFailedWebhookDeliveriesTransport) DecodeRequest(ctx context.Context, r *fasthttp.Request) (offset int, limit int, err error) {
+	return decodePage(r, g.errorCreator)
+}
+
+// EncodeResponse method for encoding response on server side
+func (g *getFailedWebhookDeliveriesTransport) EncodeResponse(ctx context.Context, r *fasthttp.Response, deliveries []*api.WebhookDelivery) (err error) {
+	r.Header.Set("Content-Type", "application/json")
+	return encodeJSON(r, deliveries, g.errorCreator)
+}
+
+// NewGetFailedWebhookDeliveriesTransport the transport creator for http requests
+func NewGetFailedWebhookDeliveriesTransport(errorCreator httperror.ErrorCreator) GetFailedWebhookDeliveriesTransport {
+	return &getFailedWebhookDeliveriesTransport{
+		errorCreator: errorCreator,
+	}
+}
+
+// ReplayWebhookDeliveryTransport transport interface
+type ReplayWebhookDeliveryTransport interface {
+	DecodeRequest(ctx context.Context, r *fasthttp.Request) (id string, err error)
+	EncodeResponse(ctx context.Context, r *fasthttp.Response, delivery *api.WebhookDelivery) (err error)
+}
+
+type replayWebhookDeliveryTransport struct {
+	errorCreator httperror.ErrorCreator
+}
+
+// DecodeRequest method for decoding requests on server side
+func (rw *replayWebhookDeliveryTransport) DecodeRequest(ctx context.Context, r *fasthttp.Request) (id string, err error) {
+	return pathParam(ctx, PathParamID), nil
+}
+
+// EncodeResponse method for encoding response on server side
+func (rw *replayWebhookDeliveryTransport) EncodeResponse(ctx context.Context, r *fasthttp.Response, delivery *api.WebhookDelivery) (err error) {
+	r.Header.Set("Content-Type", "application/json")
+	return encodeJSON(r, delivery, rw.errorCreator)
+}
+
+// NewReplayWebhookDeliveryTransport the transport creator for http requests
+func NewReplayWebhookDeliveryTransport(errorCreator httperror.ErrorCreator) ReplayWebhookDeliveryTransport {
+	return &replayWebhookDeliveryTransport{
+		errorCreator: errorCreator,
+	}
+}
@@ -2,6 +2,7 @@ package sitesdataservice
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/go-kit/kit/log"
@@ -29,6 +30,139 @@ func (s *loggingMiddleware) GetDataFromURLs(ctx context.Context, urls []string)
 	return s.svc.GetDataFromURLs(ctx, urls)
 }
 
+func (s *loggingMiddleware) GetDataFromURLsWithOptions(ctx context.Context, requests []*api.SiteRequest, options *api.FetchOptions) (response []*api.SiteData, err error) {
+	defer func(begin time.Time) {
+		_ = s.wrap(err).Log(
+			"method", "GetDataFromURLsWithOptions",
+			"urls", requestsForLog(requests),
+			"options", optionsForLog(options),
+			"err", err,
+			"elapsed", time.Since(begin),
+		)
+	}(time.Now())
+	return s.svc.GetDataFromURLsWithOptions(ctx, requests, options)
+}
+
+func (s *loggingMiddleware) StreamDataFromURLs(ctx context.Context, requests []*api.SiteRequest, options *api.FetchOptions, handle func(siteData *api.SiteData) error) (err error) {
+	defer func(begin time.Time) {
+		_ = s.wrap(err).Log(
+			"method", "StreamDataFromURLs",
+			"urls", requestsForLog(requests),
+			"options", optionsForLog(options),
+			"err", err,
+			"elapsed", time.Since(begin),
+		)
+	}(time.Now())
+	return s.svc.StreamDataFromURLs(ctx, requests, options, handle)
+}
+
+func (s *loggingMiddleware) CreateJob(ctx context.Context, request *api.CreateJobRequest) (job *api.Job, err error) {
+	defer func(begin time.Time) {
+		_ = s.wrap(err).Log(
+			"method", "CreateJob",
+			"urls_count", len(request.URLs),
+			"options", fmt.Sprintf("%+v", request.Options),
+			"err", err,
+			"elapsed", time.Since(begin),
+		)
+	}(time.Now())
+	return s.svc.CreateJob(ctx, request)
+}
+
+func (s *loggingMiddleware) GetJob(ctx context.Context, id string, offset int, limit int) (response *api.GetJobResponse, err error) {
+	defer func(begin time.Time) {
+		_ = s.wrap(err).Log(
+			"method", "GetJob",
+			"id", id,
+			"offset", offset,
+			"limit", limit,
+			"err", err,
+			"elapsed", time.Since(begin),
+		)
+	}(time.Now())
+	return s.svc.GetJob(ctx, id, offset, limit)
+}
+
+func (s *loggingMiddleware) CancelJob(ctx context.Context, id string) (job *api.Job, err error) {
+	defer func(begin time.Time) {
+		_ = s.wrap(err).Log(
+			"method", "CancelJob",
+			"id", id,
+			"err", err,
+			"elapsed", time.Since(begin),
+		)
+	}(time.Now())
+	return s.svc.CancelJob(ctx, id)
+}
+
+func (s *loggingMiddleware) GetFailedWebhookDeliveries(ctx context.Context, offset int, limit int) (deliveries []*api.WebhookDelivery, err error) {
+	defer func(begin time.Time) {
+		_ = s.wrap(err).Log(
+			"method", "GetFailedWebhookDeliveries",
+			"offset", offset,
+			"limit", limit,
+			"err", err,
+			"elapsed", time.Since(begin),
+		)
+	}(time.Now())
+	return s.svc.GetFailedWebhookDeliveries(ctx, offset, limit)
+}
+
+func (s *loggingMiddleware) ReplayWebhookDelivery(ctx context.Context, id string) (delivery *api.WebhookDelivery, err error) {
+	defer func(begin time.Time) {
+		_ = s.wrap(err).Log(
+			"method", "ReplayWebhookDelivery",
+			"id", id,
+			"err", err,
+			"elapsed", time.Since(begin),
+		)
+	}(time.Now())
+	return s.svc.ReplayWebhookDelivery(ctx, id)
+}
+
+func (s *loggingMiddleware) GetSnapshots(ctx context.Context, request *api.GetSnapshotsRequest) (response *api.GetSnapshotsResponse, err error) {
+	defer func(begin time.Time) {
+		_ = s.wrap(err).Log(
+			"method", "GetSnapshots",
+			"url", request.URL,
+			"cursor", request.Cursor,
+			"limit", request.Limit,
+			"err", err,
+			"elapsed", time.Since(begin),
+		)
+	}(time.Now())
+	return s.svc.GetSnapshots(ctx, request)
+}
+
+func (s *loggingMiddleware) GetSnapshot(ctx context.Context, id string) (snapshot *api.Snapshot, err error) {
+	defer func(begin time.Time) {
+		_ = s.wrap(err).Log(
+			"method", "GetSnapshot",
+			"id", id,
+			"err", err,
+			"elapsed", time.Since(begin),
+		)
+	}(time.Now())
+	return s.svc.GetSnapshot(ctx, id)
+}
+
+func (s *loggingMiddleware) GetSnapshotsDiff(ctx context.Context, request *api.GetSnapshotsDiffRequest) (diff *api.SnapshotsDiff, err error) {
+	defer func(begin time.Time) {
+		_ = s.wrap(err).Log(
+			"method", "GetSnapshotsDiff",
+			"fromID", request.FromID,
+			"toID", request.ToID,
+			"url", request.URL,
+			"from", request.From,
+			"to", request.To,
+			"format", request.Format,
+			"err", err,
+			"elapsed", time.Since(begin),
+		)
+	}(time.Now())
+	return s.svc.GetSnapshotsDiff(ctx, request)
+}
+
 func (s *loggingMiddleware) wrap(err error) log.Logger {
 	lvl := level.Debug
 	if err != nil {
@@ -37,6 +171,37 @@ func (s *loggingMiddleware) wrap(err error) log.Logger {
 	return lvl(s.logger)
 }
 
+// requestsForLog оставляет от запросов только метод и урл: в заголовках и теле могут быть секреты
+func requestsForLog(requests []*api.SiteRequest) (urls []string) {
+	urls = make([]string, 0, len(requests))
+	for _, request := range requests {
+		if request == nil {
+			continue
+		}
+		if request.Method == "" {
+			urls = append(urls, request.URL)
+			continue
+		}
+		urls = append(urls, request.Method+" "+request.URL)
+	}
+
+	return
+}
+
+// optionsForLog заменяет значения заголовков, чтобы не писать в лог токены авторизации
+func optionsForLog(options *api.FetchOptions) string {
+	if options == nil || len(options.Headers) == 0 {
+		return fmt.Sprintf("%+v", options)
+	}
+
+	masked := *options
+	masked.Headers = make(map[string]string, len(options.Headers))
+	for name := range options.Headers {
+		masked.Headers[name] = "***"
+	}
+	return fmt.Sprintf("%+v", &masked)
+}
+
 // NewLoggingMiddleware ...
 func NewLoggingMiddleware(logger log.Logger, svc svc.Service) svc.Service {
 	return &loggingMiddleware{
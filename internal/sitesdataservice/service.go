@@ -2,16 +2,25 @@ package sitesdataservice
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
-	"golang.org/x/sync/errgroup"
 
+	"github.com/mts-test-task/internal/cachepolicy"
+	"github.com/mts-test-task/internal/robots"
+	"github.com/mts-test-task/internal/siterules"
+	"github.com/mts-test-task/internal/sites"
 	"github.com/mts-test-task/internal/storages/mongodb/models"
 	svc "github.com/mts-test-task/pkg/sitesdataservice"
 	"github.com/mts-test-task/pkg/sitesdataservice/api"
@@ -19,52 +28,209 @@ import (
 )
 
 type inputValidator interface {
-	CheckURLs(urls []string) (err error)
+	CheckRequests(requests []*api.SiteRequest) (err error)
+	CheckJobURLs(urls []string) (err error)
+	CheckOptions(options *api.FetchOptions) (err error)
+	CheckCallbackURL(callbackURL string) (err error)
+	CheckSnapshotsRequest(request *api.GetSnapshotsRequest) (err error)
+	CheckSnapshotsDiffRequest(request *api.GetSnapshotsDiffRequest) (err error)
 }
 
 type sitesClient interface {
-	GetData(ctx context.Context, url string) (data string, err error)
+	GetData(ctx context.Context, request *sites.Request) (response *sites.Response, err error)
+}
+
+type robotsChecker interface {
+	Check(ctx context.Context, rawURL string) (crawlDelay time.Duration, err error)
+}
+
+type fetchPool interface {
+	Submit(tasks []func()) (err error)
+}
+
+type cacheKeyBuilder interface {
+	Key(request *sites.Request) (key string)
+	URL(request *sites.Request) (canonicalURL string)
+	VaryKey(request *sites.Request, varyHeaders []string) (varyKey string)
+}
+
+type ruleMatcher interface {
+	Match(rawURL string) (rule *siterules.Rule)
+	MaxTTL() (maxTTL time.Duration)
+}
+
+type cachePolicy interface {
+	Freshness(header http.Header, responseTime time.Time) (freshness *cachepolicy.Freshness)
+	MaxTTL() (maxTTL time.Duration)
 }
 
 type sitesDataMongoObjectsBuilder interface {
-	GetSitesDataFilter(createDate int64) (filter bson.M)
-	AddSitesData(sitesData []*api.SiteData, createTime int64) (data []interface{})
-	SortOptions(fieldName string, sortType int) (findOptions *options.FindOptions)
+	GetSitesDataPipeline(urls []string, keys []string, createDate int64) (pipeline mongo.Pipeline)
+	AddSitesData(sitesData []*api.SiteData, urls []string, keys []string, finalKeys []string, varyKeys []string, createTime int64) (data []interface{})
+	LatestSitesData(data []interface{}, keys []string) (latest []mongo.WriteModel)
+	ContentHash(siteData *api.SiteData) (hash string)
+	ContentHashesFilter(keys []string) (filter bson.M)
+	ContentHashesOptions() (findOptions *options.FindOptions)
+	SnapshotsFilter(url string, from int64, to int64, cursorCreateDate int64, cursorID string) (filter bson.M, err error)
+	SnapshotsPageOptions(limit int) (findOptions *options.FindOptions)
+	SnapshotFilter(id string) (filter bson.M, err error)
+	SnapshotAtFilter(url string, at int64) (filter bson.M)
+	SnapshotAtOptions() (findOptions *options.FindOneOptions)
 }
 
 type sitesDataMongoWrapper interface {
-	GetSitesData(ctx context.Context, filter bson.M, sortOptions *options.FindOptions) (sitesData []*models.SiteData, err error)
-	AddSitesData(ctx context.Context, data []interface{}) (err error)
+	GetSitesData(ctx context.Context, pipeline mongo.Pipeline) (sitesData []*models.SiteData, err error)
+	UpsertSitesData(ctx context.Context, latest []mongo.WriteModel) (err error)
+	FindSitesData(ctx context.Context, filter bson.M, findOptions *options.FindOptions) (sitesData []*models.SiteData, err error)
+	AddSitesDataHistory(ctx context.Context, data []interface{}) (err error)
+	GetSnapshots(ctx context.Context, filter bson.M, findOptions *options.FindOptions) (snapshots []*models.SiteData, err error)
+	GetSnapshot(ctx context.Context, filter bson.M, findOptions *options.FindOneOptions) (snapshot *models.SiteData, err error)
 }
 
 type sitesDataConverter interface {
 	SitesDataToMap(sitesData []*models.SiteData) (sitesDataMap map[string]*models.SiteData)
+	SiteResponseToSiteData(url string, response *sites.Response) (siteData *api.SiteData)
+	StoredSiteDataToSiteData(url string, storedSiteData *models.SiteData, now time.Time) (siteData *api.SiteData)
+	RevalidatedSiteDataToSiteData(url string, storedSiteData *models.SiteData, response *sites.Response) (siteData *api.SiteData)
+	SelectFields(siteData *api.SiteData, fields []string) (selected *api.SiteData)
+	StoredSiteDataToSnapshot(storedSiteData *models.SiteData, withData bool) (snapshot *api.Snapshot)
+}
+
+type snapshotsDiffer interface {
+	Unified(fromName string, toName string, from string, to string) (unified string)
+	JSON(from []byte, to []byte) (changes []*api.JSONChange, err error)
+}
+
+type jobsManager interface {
+	Create(ctx context.Context, request *api.CreateJobRequest) (job *api.Job, err error)
+	Get(ctx context.Context, id string, offset int, limit int) (response *api.GetJobResponse, err error)
+	Cancel(ctx context.Context, id string) (job *api.Job, err error)
+}
+
+type webhookSender interface {
+	GetFailed(ctx context.Context, offset int, limit int) (deliveries []*api.WebhookDelivery, err error)
+	Replay(ctx context.Context, id string) (delivery *api.WebhookDelivery, err error)
 }
 
 type service struct {
 	inputValidator               inputValidator
 	errorCreator                 httperror.ErrorCreator
 	sitesClient                  sitesClient
+	fetchPool                    fetchPool
+	robotsChecker                robotsChecker
+	cacheKeyBuilder              cacheKeyBuilder
+	cachePolicy                  cachePolicy
+	ruleMatcher                  ruleMatcher
 	sitesDataMongoObjectsBuilder sitesDataMongoObjectsBuilder
 	sitesDataMongoWrapper        sitesDataMongoWrapper
 	logger                       log.Logger
 	sitesDataConverter           sitesDataConverter
-	createDataFieldName          string
-	sortAsc                      int
+	maxCacheAge                  time.Duration
+	revalidateWindow             time.Duration
+	staleIfError                 time.Duration
+	staleWhileRevalidate         time.Duration
+	sitesClientTimeout           time.Duration
+	maxBodySize                  int64
+	bodyLimitMode                string
+	binaryContent                string
+	jobsManager                  jobsManager
+	webhookSender                webhookSender
+	snapshotsPageLimit           int
+	snapshotsMaxPageSize         int
+	snapshotsDiffer              snapshotsDiffer
+	snapshotsDiffMaxSize         int
+	// Ключи записей, которые обновляются в фоне
+	refreshing sync.Map
 }
 
 func (s *service) GetDataFromURLs(ctx context.Context, urls []string) (response []*api.SiteData, err error) {
+	return s.GetDataFromURLsWithOptions(ctx, api.SiteRequestsFromURLs(urls), &api.FetchOptions{FailureMode: api.FailureModeFailFast})
+}
+
+func (s *service) GetDataFromURLsWithOptions(ctx context.Context, requests []*api.SiteRequest, options *api.FetchOptions) (response []*api.SiteData, err error) {
+	response = make([]*api.SiteData, len(requests))
+
+	err = s.processURLs(ctx, requests, options, func(index int, siteData *api.SiteData) error {
+		response[index] = siteData
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return
+}
+
+func (s *service) StreamDataFromURLs(ctx context.Context, requests []*api.SiteRequest, options *api.FetchOptions, handle func(siteData *api.SiteData) error) (err error) {
+	return s.processURLs(ctx, requests, options, func(index int, siteData *api.SiteData) error {
+		return handle(siteData)
+	})
+}
+
+// processURLs получает данные по всем урлам параллельно и передает результат по каждому урлу в handle
+// по мере готовности. handle не вызывается конкурентно
+func (s *service) processURLs(ctx context.Context, requests []*api.SiteRequest, options *api.FetchOptions, handle func(index int, siteData *api.SiteData) error) (err error) {
 	// Проверяем входные данные
-	err = s.inputValidator.CheckURLs(urls)
+	err = s.inputValidator.CheckRequests(requests)
+	if err != nil {
+		return
+	}
+	err = s.inputValidator.CheckOptions(options)
 	if err != nil {
 		return
 	}
 
-	// Данные для запроса в монгу
-	filter := s.sitesDataMongoObjectsBuilder.GetSitesDataFilter(time.Now().Add(-time.Minute).Unix())
-	sortOptions := s.sitesDataMongoObjectsBuilder.SortOptions(s.createDataFieldName, s.sortAsc)
-	// Получаем результаты предыдущих запросов из монги
-	storedSitesData, err := s.sitesDataMongoWrapper.GetSitesData(ctx, filter, sortOptions)
+	if options == nil {
+		options = &api.FetchOptions{}
+	}
+	failureMode := api.FailureModeFailFast
+	if options.FailureMode != "" {
+		failureMode = options.FailureMode
+	}
+	maxCacheAge := s.maxCacheAge
+	if options.MaxCacheAgeMs > 0 {
+		maxCacheAge = time.Duration(options.MaxCacheAgeMs) * time.Millisecond
+	}
+
+	// Данные для запроса в монгу: устаревшие записи тоже нужны, их можно проверить условным запросом,
+	// а заголовки кэширования сайта и правила доменов могут продлить свежесть
+	storedMaxAge := maxCacheAge
+	for _, age := range []time.Duration{s.cachePolicy.MaxTTL(), s.ruleMatcher.MaxTTL()} {
+		if age > storedMaxAge {
+			storedMaxAge = age
+		}
+	}
+	// Устаревшие данные могут быть отданы в пределах окна после окончания срока свежести
+	staleWindow := s.staleIfError
+	if s.staleWhileRevalidate > staleWindow {
+		staleWindow = s.staleWhileRevalidate
+	}
+	storedMaxAge += staleWindow
+	if s.revalidateWindow > storedMaxAge {
+		storedMaxAge = s.revalidateWindow
+	}
+
+	// Одинаковые после канонизации запросы выполняются один раз, результат получает каждый из них
+	siteRequests := make([]*sites.Request, len(requests))
+	rules := make([]*siterules.Rule, len(requests))
+	keys := make([]string, len(requests))
+	duplicates := make(map[string][]int, len(requests))
+	uniques := make([]int, 0, len(requests))
+	canonicalURLs := make([]string, len(requests))
+	for i := range requests {
+		rules[i] = s.ruleMatcher.Match(requests[i].URL)
+		siteRequests[i] = s.siteRequest(requests[i], options, rules[i])
+		keys[i] = s.cacheKeyBuilder.Key(siteRequests[i])
+		canonicalURLs[i] = s.cacheKeyBuilder.URL(siteRequests[i])
+		if _, ok := duplicates[keys[i]]; !ok {
+			uniques = append(uniques, i)
+		}
+		duplicates[keys[i]] = append(duplicates[keys[i]], i)
+	}
+
+	// Получаем из монги последние результаты предыдущих запросов только по запрошенным урлам
+	pipeline := s.sitesDataMongoObjectsBuilder.GetSitesDataPipeline(canonicalURLs, keys, time.Now().Add(-storedMaxAge).Unix())
+	storedSitesData, err := s.sitesDataMongoWrapper.GetSitesData(ctx, pipeline)
 	if err != nil {
 		// Будем считать, что монгу использем как кэш, поэтому ошибку будем логировать,
 		// но не будем прерывать выполнение программы
@@ -75,69 +241,916 @@ func (s *service) GetDataFromURLs(ctx context.Context, urls []string) (response
 	// Конвертируем результаты предыдущих запросов в мапу, чтобы можно было быстрее их получать
 	storedSitesDataMap := s.sitesDataConverter.SitesDataToMap(storedSitesData)
 
-	// Создаем errgroup для работы с горутинами
-	group, ctx := errgroup.WithContext(ctx)
-
-	response = make([]*api.SiteData, len(urls))
-
-	for i := range urls {
-		iteration := i
-		group.Go(func() error {
-			if storedSiteData, ok := storedSitesDataMap[urls[iteration]]; ok {
-				response[iteration] = &api.SiteData{URL: urls[iteration], Data: storedSiteData.Data[:1]}
-			} else {
-				siteData, err := s.sitesClient.GetData(ctx, urls[iteration])
-				if err != nil {
-					return s.errorCreator(
-						http.StatusBadGateway,
-						fmt.Sprintf("Не удалось получить данные от %s", urls[iteration]),
-						fmt.Sprintf("failed to get data from %s: %s", urls[iteration], err),
-					)
+	// Урлы обрабатываются общим пулом воркеров, отмена контекста прерывает оставшиеся урлы
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg            sync.WaitGroup
+		mu            sync.Mutex
+		stopped       bool
+		groupErr      error
+		succeeded     = make([]*api.SiteData, 0, len(requests))
+		succeededURLs = make([]string, 0, len(requests))
+		succeededKeys = make([]string, 0, len(requests))
+		finalKeys     = make([]string, 0, len(requests))
+		varyKeys      = make([]string, 0, len(requests))
+	)
+
+	fetch := func(iteration int) error {
+		// Запрос уже прерван, пока урл ждал в очереди
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		siteRequest := siteRequests[iteration]
+		rule := rules[iteration]
+		key := keys[iteration]
+
+		siteData, finalKey, attempts, err := s.getSiteData(ctx, siteRequest, key, options, maxCacheAge, rule, storedSitesDataMap)
+		if err == nil {
+			err = s.checkBinaryContent(siteData, options)
+		}
+		if err == nil {
+			err = s.checkContentType(siteData, rule)
+		}
+		if err != nil {
+			_ = level.Error(s.logger).Log("msg", "Failed to get site data:", "url", siteRequest.URL, "attempts", attempts, "err", err)
+			siteData = s.siteDataError(siteRequest.URL, err)
+			siteData.Error.Attempts = attempts
+		}
+		// Сообщаем, по какому правилу домена получены данные
+		if rule != nil && siteData.Error != nil {
+			siteData.Error.Rule = rule.Name
+		} else if rule != nil {
+			siteData.Meta.Rule = rule.Name
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		// После прерывания обработки результаты остальных урлов уже не нужны
+		if stopped {
+			return nil
+		}
+		for _, index := range duplicates[key] {
+			// Каждый получает результат со своим исходным урлом
+			indexSiteData := siteData
+			if index != iteration {
+				duplicate := *siteData
+				duplicate.URL = requests[index].URL
+				indexSiteData = &duplicate
+			}
+			if handleErr := handle(index, s.sitesDataConverter.SelectFields(indexSiteData, options.Fields)); handleErr != nil {
+				stopped = true
+				return handleErr
+			}
+		}
+		// В режиме частичных результатов ошибка остается только в ответе по конкретному урлу
+		if err != nil && failureMode != api.FailureModePartial {
+			stopped = true
+			return err
+		}
+		// Обрезанные данные не кэшируем, иначе они достанутся запросам с большим лимитом.
+		// Не кэшируем и то, что сайт запретил хранить, устаревшие данные и данные, взятые из кэша без проверки
+		if err == nil && !siteData.Meta.Truncated && !siteData.Meta.NoStore && !siteData.Meta.Stale &&
+			(!siteData.Meta.Cached || siteData.Meta.Revalidated) {
+			succeeded = append(succeeded, siteData)
+			succeededURLs = append(succeededURLs, canonicalURLs[iteration])
+			succeededKeys = append(succeededKeys, key)
+			finalKeys = append(finalKeys, finalKey)
+			varyKeys = append(varyKeys, s.cacheKeyBuilder.VaryKey(siteRequest, siteData.Meta.Vary))
+		}
+		return nil
+	}
+
+	tasks := make([]func(), len(uniques))
+	for i := range uniques {
+		iteration := uniques[i]
+		tasks[i] = func() {
+			defer wg.Done()
+			if err := fetch(iteration); err != nil {
+				mu.Lock()
+				if groupErr == nil {
+					groupErr = err
 				}
-				response[iteration] = &api.SiteData{URL: urls[iteration], Data: siteData}
+				mu.Unlock()
+				cancel()
 			}
-			return nil
-		})
+		}
+	}
 
+	wg.Add(len(tasks))
+	// Причина сохраняется, чтобы перегрузку пула можно было отличить от недоступности отдельного сайта
+	if err = s.fetchPool.Submit(tasks); err != nil {
+		return httperror.WithCause(s.errorCreator(
+			http.StatusServiceUnavailable,
+			"Сервис перегружен, повторите запрос позже",
+			fmt.Sprintf("failed to queue urls: %s", err),
+		), err)
+	}
+	wg.Wait()
+	if groupErr != nil {
+		return groupErr
 	}
 
-	err = group.Wait()
-	if err != nil {
+	// Сохраняем в монгу только успешно полученные данные
+	s.storeSitesData(ctx, succeeded, succeededURLs, succeededKeys, finalKeys, varyKeys)
+
+	return
+}
+
+// storeSitesData заменяет последние записи по ключам кэша и добавляет в историю данные, содержимое которых
+// изменилось по сравнению с последней записью по ключу, какой бы старой она ни была.
+// Ошибки монги только логируются: она используется как кэш
+func (s *service) storeSitesData(
+	ctx context.Context,
+	sitesData []*api.SiteData,
+	urls []string,
+	keys []string,
+	finalKeys []string,
+	varyKeys []string,
+) {
+	if len(sitesData) == 0 {
 		return
 	}
 
-	// Подготавливаем данные для сохранения в монгу
-	siteDataToStore := s.sitesDataMongoObjectsBuilder.AddSitesData(response, time.Now().Unix())
-	err = s.sitesDataMongoWrapper.AddSitesData(ctx, siteDataToStore)
+	// Хэши читаются до замены записей. Без них история пополняется всеми данными: лишний снимок лучше пропущенного
+	storedHashes := make(map[string]string, len(keys))
+	stored, err := s.sitesDataMongoWrapper.FindSitesData(
+		ctx,
+		s.sitesDataMongoObjectsBuilder.ContentHashesFilter(keys),
+		s.sitesDataMongoObjectsBuilder.ContentHashesOptions(),
+	)
 	if err != nil {
+		_ = level.Error(s.logger).Log("msg", "Failed to get content hashes from sites data mongo:", "err", err)
+	}
+	for _, storedSiteData := range stored {
+		storedHashes[storedSiteData.Key] = storedSiteData.ContentHash
+	}
+
+	data := s.sitesDataMongoObjectsBuilder.AddSitesData(sitesData, urls, keys, finalKeys, varyKeys, time.Now().Unix())
+	if err := s.sitesDataMongoWrapper.UpsertSitesData(ctx, s.sitesDataMongoObjectsBuilder.LatestSitesData(data, keys)); err != nil {
 		_ = level.Error(s.logger).Log("msg", "Failed to put data to sites data mongo:", "err", err)
-		err = nil
+	}
+
+	history := make([]interface{}, 0, len(data))
+	for i := range sitesData {
+		if s.sitesDataMongoObjectsBuilder.ContentHash(sitesData[i]) != storedHashes[keys[i]] {
+			history = append(history, data[i])
+		}
+	}
+	if len(history) == 0 {
+		return
+	}
+	if err := s.sitesDataMongoWrapper.AddSitesDataHistory(ctx, history); err != nil {
+		_ = level.Error(s.logger).Log("msg", "Failed to put data to sites data history mongo:", "err", err)
+	}
+}
+
+func (s *service) CreateJob(ctx context.Context, request *api.CreateJobRequest) (job *api.Job, err error) {
+	// Проверяем входные данные
+	err = s.inputValidator.CheckJobURLs(request.URLs)
+	if err != nil {
+		return
+	}
+	err = s.inputValidator.CheckOptions(request.Options)
+	if err != nil {
+		return
+	}
+	err = s.inputValidator.CheckCallbackURL(request.CallbackURL)
+	if err != nil {
+		return
+	}
+
+	return s.jobsManager.Create(ctx, request)
+}
+
+func (s *service) GetJob(ctx context.Context, id string, offset int, limit int) (response *api.GetJobResponse, err error) {
+	return s.jobsManager.Get(ctx, id, offset, limit)
+}
+
+func (s *service) CancelJob(ctx context.Context, id string) (job *api.Job, err error) {
+	return s.jobsManager.Cancel(ctx, id)
+}
+
+func (s *service) GetFailedWebhookDeliveries(ctx context.Context, offset int, limit int) (deliveries []*api.WebhookDelivery, err error) {
+	return s.webhookSender.GetFailed(ctx, offset, limit)
+}
+
+func (s *service) ReplayWebhookDelivery(ctx context.Context, id string) (delivery *api.WebhookDelivery, err error) {
+	return s.webhookSender.Replay(ctx, id)
+}
+
+func (s *service) GetSnapshots(ctx context.Context, request *api.GetSnapshotsRequest) (response *api.GetSnapshotsResponse, err error) {
+	if err = s.inputValidator.CheckSnapshotsRequest(request); err != nil {
+		return
+	}
+
+	limit := request.Limit
+	if limit <= 0 {
+		limit = s.snapshotsPageLimit
+	}
+	if limit > s.snapshotsMaxPageSize {
+		limit = s.snapshotsMaxPageSize
+	}
+	var from, to int64
+	if !request.From.IsZero() {
+		from = request.From.Unix()
+	}
+	if !request.To.IsZero() {
+		to = request.To.Unix()
+	}
+
+	// История хранится по каноническим урлам
+	url := s.cacheKeyBuilder.URL(&sites.Request{URL: request.URL})
+	cursorCreateDate, cursorID, err := decodeSnapshotsCursor(request.Cursor)
+	var filter bson.M
+	if err == nil {
+		filter, err = s.sitesDataMongoObjectsBuilder.SnapshotsFilter(url, from, to, cursorCreateDate, cursorID)
+	}
+	if err != nil {
+		return nil, s.errorCreator(
+			http.StatusBadRequest,
+			"Ошибка ввода: неверный курсор",
+			fmt.Sprintf("input validation error: bad cursor %q: %s", request.Cursor, err),
+		)
+	}
+
+	storedSnapshots, err := s.sitesDataMongoWrapper.GetSnapshots(ctx, filter, s.sitesDataMongoObjectsBuilder.SnapshotsPageOptions(limit))
+	if err != nil {
+		return nil, s.errorCreator(
+			http.StatusInternalServerError,
+			"Не удалось получить историю урла",
+			fmt.Sprintf("failed to get snapshots of %s: %s", url, err),
+		)
+	}
+
+	response = &api.GetSnapshotsResponse{Snapshots: make([]*api.Snapshot, 0, len(storedSnapshots)), Limit: limit}
+	// Лишний снимок означает, что есть следующая страница
+	if len(storedSnapshots) > limit {
+		storedSnapshots = storedSnapshots[:limit]
+		last := storedSnapshots[limit-1]
+		response.NextCursor = encodeSnapshotsCursor(int64(last.CreateDate), last.ID)
+	}
+	for _, storedSnapshot := range storedSnapshots {
+		response.Snapshots = append(response.Snapshots, s.sitesDataConverter.StoredSiteDataToSnapshot(storedSnapshot, false))
+	}
+
+	return
+}
+
+func (s *service) GetSnapshot(ctx context.Context, id string) (snapshot *api.Snapshot, err error) {
+	storedSnapshot, err := s.getStoredSnapshot(ctx, id)
+	if err != nil {
+		return
+	}
+
+	return s.sitesDataConverter.StoredSiteDataToSnapshot(storedSnapshot, true), nil
+}
+
+func (s *service) GetSnapshotsDiff(ctx context.Context, request *api.GetSnapshotsDiffRequest) (diff *api.SnapshotsDiff, err error) {
+	if err = s.inputValidator.CheckSnapshotsDiffRequest(request); err != nil {
+		return
+	}
+
+	var from, to *models.SiteData
+	if request.FromID != "" {
+		if from, err = s.getStoredSnapshot(ctx, request.FromID); err != nil {
+			return
+		}
+		if to, err = s.getStoredSnapshot(ctx, request.ToID); err != nil {
+			return
+		}
+	} else {
+		url := s.cacheKeyBuilder.URL(&sites.Request{URL: request.URL})
+		if from, err = s.getStoredSnapshotAt(ctx, url, request.From); err != nil {
+			return
+		}
+		if to, err = s.getStoredSnapshotAt(ctx, url, request.To); err != nil {
+			return
+		}
+	}
+	if err = s.checkSnapshotDiffData(from); err != nil {
+		return
+	}
+	if err = s.checkSnapshotDiffData(to); err != nil {
+		return
+	}
+
+	diff = &api.SnapshotsDiff{
+		From:   s.sitesDataConverter.StoredSiteDataToSnapshot(from, false),
+		To:     s.sitesDataConverter.StoredSiteDataToSnapshot(to, false),
+		Format: request.Format,
+	}
+	if diff.Format == "" {
+		diff.Format = api.DiffFormatUnified
+	}
+	if diff.Format == api.DiffFormatJSON {
+		diff.Changes, err = s.snapshotsDiffer.JSON([]byte(from.Data), []byte(to.Data))
+		if err != nil {
+			return nil, s.errorCreator(
+				http.StatusUnprocessableEntity,
+				"Данные снимков не являются JSON",
+				fmt.Sprintf("failed to compare snapshots %s and %s as JSON: %s", from.ID, to.ID, err),
+			)
+		}
+		diff.Identical = len(diff.Changes) == 0
+		return
+	}
+
+	// Заголовки unified diff содержат идентификатор и время снимка
+	diff.Unified = s.snapshotsDiffer.Unified(
+		from.ID+"\t"+diff.From.CreatedAt.Format(time.RFC3339),
+		to.ID+"\t"+diff.To.CreatedAt.Format(time.RFC3339),
+		from.Data,
+		to.Data,
+	)
+	diff.Identical = diff.Unified == ""
+
+	return
+}
+
+func (s *service) getStoredSnapshot(ctx context.Context, id string) (storedSnapshot *models.SiteData, err error) {
+	filter, err := s.sitesDataMongoObjectsBuilder.SnapshotFilter(id)
+	if err != nil {
+		return nil, s.snapshotNotFound(id)
+	}
+
+	storedSnapshot, err = s.sitesDataMongoWrapper.GetSnapshot(ctx, filter, nil)
+	if err != nil {
+		return nil, s.errorCreator(
+			http.StatusInternalServerError,
+			"Не удалось получить снимок",
+			fmt.Sprintf("failed to get snapshot %s: %s", id, err),
+		)
+	}
+	if storedSnapshot == nil {
+		return nil, s.snapshotNotFound(id)
+	}
+
+	return
+}
+
+// getStoredSnapshotAt возвращает последний снимок урла, сохраненный не позже at
+func (s *service) getStoredSnapshotAt(ctx context.Context, url string, at time.Time) (storedSnapshot *models.SiteData, err error) {
+	storedSnapshot, err = s.sitesDataMongoWrapper.GetSnapshot(
+		ctx,
+		s.sitesDataMongoObjectsBuilder.SnapshotAtFilter(url, at.Unix()),
+		s.sitesDataMongoObjectsBuilder.SnapshotAtOptions(),
+	)
+	if err != nil {
+		return nil, s.errorCreator(
+			http.StatusInternalServerError,
+			"Не удалось получить снимок",
+			fmt.Sprintf("failed to get snapshot of %s at %s: %s", url, at.Format(time.RFC3339), err),
+		)
+	}
+	if storedSnapshot == nil {
+		return nil, s.errorCreator(
+			http.StatusNotFound,
+			fmt.Sprintf("Снимок %s на %s не найден", url, at.Format(time.RFC3339)),
+			fmt.Sprintf("snapshot of %s at %s not found", url, at.Format(time.RFC3339)),
+		)
 	}
 
 	return
 }
 
+// checkSnapshotDiffData сравниваются только текстовые данные, размер ограничен, так как сравнение
+// выполняется в памяти и его результат может быть больше самих данных
+func (s *service) checkSnapshotDiffData(storedSnapshot *models.SiteData) (err error) {
+	if len(storedSnapshot.BinaryData) > 0 {
+		return s.errorCreator(
+			http.StatusUnsupportedMediaType,
+			fmt.Sprintf("Снимок %s содержит двоичные данные, сравнение недоступно", storedSnapshot.ID),
+			fmt.Sprintf("snapshot %s has binary data", storedSnapshot.ID),
+		)
+	}
+	if len(storedSnapshot.Data) > s.snapshotsDiffMaxSize {
+		return s.errorCreator(
+			http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("Снимок %s больше %d байт, сравнение недоступно", storedSnapshot.ID, s.snapshotsDiffMaxSize),
+			fmt.Sprintf("snapshot %s data is %d bytes, diff limit is %d", storedSnapshot.ID, len(storedSnapshot.Data), s.snapshotsDiffMaxSize),
+		)
+	}
+
+	return nil
+}
+
+func (s *service) snapshotNotFound(id string) (err error) {
+	return s.errorCreator(
+		http.StatusNotFound,
+		fmt.Sprintf("Снимок %s не найден", id),
+		fmt.Sprintf("snapshot %s not found", id),
+	)
+}
+
+// encodeSnapshotsCursor кодирует позицию последнего снимка страницы: дату создания и идентификатор
+func encodeSnapshotsCursor(createDate int64, id string) (cursor string) {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(createDate, 10) + ":" + id))
+}
+
+func decodeSnapshotsCursor(cursor string) (createDate int64, id string, err error) {
+	if cursor == "" {
+		return
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return
+	}
+	parts := strings.SplitN(string(decoded), ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return 0, "", errors.New("unexpected cursor format")
+	}
+	createDate, err = strconv.ParseInt(parts[0], 10, 64)
+	return createDate, parts[1], err
+}
+
+// getSiteData возвращает данные из кэша или запрашивает их с сайта. finalKey - ключ кэша для конечного урла
+// после редиректов, если они были, attempts - число запросов к сайту
+func (s *service) getSiteData(
+	ctx context.Context,
+	siteRequest *sites.Request,
+	key string,
+	options *api.FetchOptions,
+	maxCacheAge time.Duration,
+	rule *siterules.Rule,
+	storedSitesDataMap map[string]*models.SiteData,
+) (siteData *api.SiteData, finalKey string, attempts int, err error) {
+	storedSiteData, ok := storedSitesDataMap[key]
+	// Запись с Vary подходит, только если совпадают значения перечисленных заголовков запроса
+	if ok && storedSiteData.Meta != nil && len(storedSiteData.Meta.Vary) > 0 &&
+		s.cacheKeyBuilder.VaryKey(siteRequest, storedSiteData.Meta.Vary) != storedSiteData.VaryKey {
+		ok = false
+	}
+	if !ok {
+		return s.fetchSiteData(ctx, siteRequest, options, rule, nil, nil)
+	}
+
+	ruleTTL := time.Duration(0)
+	if rule != nil {
+		ruleTTL = rule.TTL
+	}
+	stale := staleness(storedSiteData, maxCacheAge, options.MaxCacheAgeMs, ruleTTL, time.Now())
+	if stale <= 0 {
+		siteData, finalKey, err = s.cachedSiteData(siteRequest, key, storedSiteData)
+		return siteData, finalKey, 0, err
+	}
+
+	// Недавно устаревшие данные отдаем сразу, а обновляем их в фоне, если сайт не требует проверки перед использованием
+	mustRevalidate := storedSiteData.Meta != nil && storedSiteData.Meta.MustRevalidate
+	if stale <= s.staleWhileRevalidate && !mustRevalidate {
+		siteData, finalKey, err = s.cachedSiteData(siteRequest, key, storedSiteData)
+		if err != nil {
+			return nil, "", 0, err
+		}
+		siteData.Meta.Stale = true
+		s.refreshSiteData(siteRequest, key, options, rule, storedSiteData)
+		return siteData, finalKey, 0, nil
+	}
+
+	// Устаревшую запись по этому же запросу проверяем условным запросом, если сайт прислал валидаторы
+	var revalidated *models.SiteData
+	if storedSiteData.Key == key && (storedSiteData.ETag != "" || storedSiteData.LastModified != "") {
+		revalidated = storedSiteData
+	}
+	// При ошибке сайта можно отдать устаревшие данные, если они устарели не слишком давно
+	// и сайт не запретил использовать их без проверки
+	var fallback func() (siteData *api.SiteData, finalKey string, err error)
+	if stale <= s.staleIfError && !mustRevalidate {
+		fallback = func() (siteData *api.SiteData, finalKey string, err error) {
+			return s.cachedSiteData(siteRequest, key, storedSiteData)
+		}
+	}
+
+	return s.fetchSiteData(ctx, siteRequest, options, rule, revalidated, fallback)
+}
+
+// cachedSiteData возвращает данные из записи кэша
+func (s *service) cachedSiteData(siteRequest *sites.Request, key string, storedSiteData *models.SiteData) (siteData *api.SiteData, finalKey string, err error) {
+	siteData = s.sitesDataConverter.StoredSiteDataToSiteData(siteRequest.URL, storedSiteData, time.Now())
+	// В кэше могли остаться данные, полученные с большим лимитом
+	if err = s.applyBodyLimit(siteData, siteRequest); err != nil {
+		return nil, "", err
+	}
+	if storedSiteData.Key != key {
+		// Запись найдена по конечному урлу другого запроса, его редиректы к этому урлу не относятся
+		siteData.Meta.Redirects = nil
+		siteData.Meta.FinalURL = ""
+		return siteData, "", nil
+	}
+
+	return siteData, storedSiteData.FinalKey, nil
+}
+
+// fetchSiteData запрашивает данные с сайта. revalidated - устаревшая запись, которую можно проверить условным
+// запросом, fallback возвращает устаревшие данные, если сайт не ответил
+func (s *service) fetchSiteData(
+	ctx context.Context,
+	siteRequest *sites.Request,
+	options *api.FetchOptions,
+	rule *siterules.Rule,
+	revalidated *models.SiteData,
+	fallback func() (siteData *api.SiteData, finalKey string, err error),
+) (siteData *api.SiteData, finalKey string, attempts int, err error) {
+	url := siteRequest.URL
+
+	timeout := s.sitesClientTimeout
+	if rule != nil && rule.Timeout > 0 {
+		timeout = rule.Timeout
+	}
+	if options.TimeoutMs > 0 {
+		timeout = time.Duration(options.TimeoutMs) * time.Millisecond
+	}
+	// Таймаут отсчитывается для каждой попытки после ожидания очереди хоста, у очереди свой срок
+	siteRequest.Timeout = timeout
+
+	if revalidated != nil {
+		siteRequest.IfNoneMatch = revalidated.ETag
+		siteRequest.IfModifiedSince = revalidated.LastModified
+	}
+
+	// Запрещенные robots.txt урлы не запрашиваются, crawl-delay увеличивает паузу между запросами к хосту
+	crawlDelay, err := s.robotsChecker.Check(ctx, url)
+	if errors.Is(err, robots.ErrDisallowed) {
+		return nil, "", 0, s.errorCreator(
+			http.StatusForbidden,
+			fmt.Sprintf("Получение данных от %s запрещено robots.txt", url),
+			fmt.Sprintf("failed to get data from %s: %s", url, err),
+		)
+	}
+	// Недоступный robots.txt - ошибка сайта, поэтому можно отдать устаревшие данные. Запрет правилами - нельзя
+	if errors.Is(err, robots.ErrUnavailable) {
+		if siteData, finalKey, ok := s.staleFallback(url, err, fallback); ok {
+			return siteData, finalKey, 0, nil
+		}
+		return nil, "", 0, s.errorCreator(
+			http.StatusServiceUnavailable,
+			fmt.Sprintf("robots.txt сайта %s недоступен, повторите запрос позже", url),
+			fmt.Sprintf("failed to check robots.txt for %s: %s", url, err),
+		)
+	}
+	if err != nil {
+		return nil, "", 0, s.errorCreator(
+			http.StatusBadGateway,
+			fmt.Sprintf("Не удалось получить данные от %s", url),
+			fmt.Sprintf("failed to check robots.txt for %s: %s", url, err),
+		)
+	}
+	siteRequest.MinDelay = crawlDelay
+
+	siteResponse, err := s.sitesClient.GetData(ctx, siteRequest)
+	if errors.Is(err, sites.ErrBodyTooLarge) {
+		return nil, "", sites.AttemptsOf(err), s.bodyTooLargeError(url, siteRequest.MaxBodySize)
+	}
+	if err != nil {
+		if siteData, finalKey, ok := s.staleFallback(url, err, fallback); ok {
+			return siteData, finalKey, sites.AttemptsOf(err), nil
+		}
+	}
+	if errors.Is(err, sites.ErrHostBusy) {
+		return nil, "", sites.AttemptsOf(err), s.errorCreator(
+			http.StatusServiceUnavailable,
+			fmt.Sprintf("Слишком много запросов к хосту %s, повторите запрос позже", url),
+			fmt.Sprintf("failed to get data from %s: %s", url, err),
+		)
+	}
+	if err != nil {
+		return nil, "", sites.AttemptsOf(err), s.errorCreator(
+			http.StatusBadGateway,
+			fmt.Sprintf("Не удалось получить данные от %s", url),
+			fmt.Sprintf("failed to get data from %s: %s", url, err),
+		)
+	}
+
+	if siteResponse.Coalesced {
+		_ = level.Debug(s.logger).Log("msg", "Site data shared with concurrent request:", "url", url)
+	}
+
+	// Данные не изменились: возвращаем запись из кэша, при сохранении она снова станет свежей
+	if siteResponse.NotModified {
+		siteData = s.sitesDataConverter.RevalidatedSiteDataToSiteData(url, revalidated, siteResponse)
+		// Без заголовков кэширования в 304 сохраняется политика исходного ответа
+		if freshness := s.cachePolicy.Freshness(siteResponse.AllHeaders, siteResponse.FetchedAt); freshness.Known {
+			setFreshness(siteData.Meta, freshness)
+		}
+		if err = s.applyBodyLimit(siteData, siteRequest); err != nil {
+			return nil, "", siteResponse.Attempts, err
+		}
+		return siteData, revalidated.FinalKey, siteResponse.Attempts, nil
+	}
+
+	if len(siteResponse.Redirects) > 0 {
+		finalKey = s.cacheKeyBuilder.Key(s.finalSiteRequest(siteRequest, siteResponse))
+	}
+
+	siteData = s.sitesDataConverter.SiteResponseToSiteData(url, siteResponse)
+	setFreshness(siteData.Meta, s.cachePolicy.Freshness(siteResponse.AllHeaders, siteResponse.FetchedAt))
+
+	return siteData, finalKey, siteResponse.Attempts, nil
+}
+
+// staleFallback возвращает устаревшие данные вместо ошибки сайта, если fallback задан и данные есть в кэше
+func (s *service) staleFallback(
+	url string,
+	fetchErr error,
+	fallback func() (siteData *api.SiteData, finalKey string, err error),
+) (siteData *api.SiteData, finalKey string, ok bool) {
+	if fallback == nil {
+		return nil, "", false
+	}
+
+	_ = level.Warn(s.logger).Log("msg", "Serving stale site data after fetch error:", "url", url, "err", fetchErr)
+	siteData, finalKey, err := fallback()
+	if err != nil {
+		return nil, "", false
+	}
+	siteData.Meta.Stale = true
+
+	return siteData, finalKey, true
+}
+
+// refreshSiteData обновляет устаревшую запись кэша в фоне. Одна запись обновляется одним запросом,
+// при перегрузке пула обновление пропускается
+func (s *service) refreshSiteData(siteRequest *sites.Request, key string, options *api.FetchOptions, rule *siterules.Rule, storedSiteData *models.SiteData) {
+	if _, loaded := s.refreshing.LoadOrStore(key, struct{}{}); loaded {
+		return
+	}
+
+	// Запрос копируем: исходный продолжает использоваться обработкой урла
+	refreshRequest := *siteRequest
+	var revalidated *models.SiteData
+	if storedSiteData.Key == key && (storedSiteData.ETag != "" || storedSiteData.LastModified != "") {
+		revalidated = storedSiteData
+	}
+
+	refresh := func() {
+		defer s.refreshing.Delete(key)
+
+		siteData, finalKey, _, err := s.fetchSiteData(context.Background(), &refreshRequest, options, rule, revalidated, nil)
+		if err == nil {
+			err = s.checkContentType(siteData, rule)
+		}
+		if err != nil {
+			_ = level.Error(s.logger).Log("msg", "Failed to refresh stale site data:", "url", refreshRequest.URL, "err", err)
+			return
+		}
+		if siteData.Meta.Truncated || siteData.Meta.NoStore {
+			return
+		}
+
+		s.storeSitesData(
+			context.Background(),
+			[]*api.SiteData{siteData},
+			[]string{s.cacheKeyBuilder.URL(&refreshRequest)},
+			[]string{key},
+			[]string{finalKey},
+			[]string{s.cacheKeyBuilder.VaryKey(&refreshRequest, siteData.Meta.Vary)},
+		)
+	}
+	if err := s.fetchPool.Submit([]func(){refresh}); err != nil {
+		s.refreshing.Delete(key)
+		_ = level.Warn(s.logger).Log("msg", "Skipped refresh of stale site data:", "url", siteRequest.URL, "err", err)
+	}
+}
+
+// setFreshness записывает в метаданные политику кэширования ответа сайта
+func setFreshness(meta *api.SiteDataMeta, freshness *cachepolicy.Freshness) {
+	meta.CacheTTLMs = nil
+	if freshness.Known {
+		ttl := freshness.TTL.Milliseconds()
+		meta.CacheTTLMs = &ttl
+	}
+	meta.NoStore = freshness.NoStore
+	meta.MustRevalidate = freshness.MustRevalidate
+	meta.Vary = freshness.Vary
+}
+
+// staleness возвращает, насколько данные пережили срок свежести, неположительное значение означает свежие данные.
+// Возраст считается по времени получения от сайта, а не по дате сохранения записи: запись, подтвержденная
+// условным запросом, пересохраняется с прежними данными. Срок свежести из заголовков сайта заменяет возраст по умолчанию, срок из правила
+// домена заменяет их оба, а возраст из запроса может его только сократить
+func staleness(storedSiteData *models.SiteData, maxCacheAge time.Duration, maxCacheAgeMs int64, ruleTTL time.Duration, now time.Time) (stale time.Duration) {
+	fetchedAt := time.Unix(int64(storedSiteData.CreateDate), 0)
+	if storedSiteData.Meta != nil && storedSiteData.Meta.FetchedAt > 0 {
+		fetchedAt = time.Unix(0, storedSiteData.Meta.FetchedAt*int64(time.Millisecond))
+	}
+
+	ttl := maxCacheAge
+	if storedSiteData.Meta != nil && storedSiteData.Meta.CacheTTL != nil {
+		ttl = time.Duration(*storedSiteData.Meta.CacheTTL) * time.Millisecond
+	}
+	if ruleTTL > 0 {
+		ttl = ruleTTL
+	}
+	if maxCacheAgeMs > 0 && maxCacheAge < ttl {
+		ttl = maxCacheAge
+	}
+
+	return now.Sub(fetchedAt) - ttl
+}
+
+// finalSiteRequest описывает запрос к конечному урлу: тело сохраняется, только если не сменился метод
+func (s *service) finalSiteRequest(siteRequest *sites.Request, siteResponse *sites.Response) (finalRequest *sites.Request) {
+	finalRequest = &sites.Request{
+		Method:         siteResponse.FinalMethod,
+		URL:            siteResponse.FinalURL,
+		Headers:        siteRequest.Headers,
+		RedirectPolicy: siteRequest.RedirectPolicy,
+	}
+	if finalRequest.Method == siteRequest.Method {
+		finalRequest.Body = siteRequest.Body
+	}
+
+	return
+}
+
+// siteRequest собирает запрос к сайту: заголовки урла дополняют и переопределяют общие заголовки из options,
+// а те - заголовки правила домена
+func (s *service) siteRequest(request *api.SiteRequest, options *api.FetchOptions, rule *siterules.Rule) (siteRequest *sites.Request) {
+	siteRequest = &sites.Request{
+		Method:         request.Method,
+		URL:            request.URL,
+		Body:           request.Body,
+		RedirectPolicy: options.RedirectPolicy,
+		MaxRedirects:   options.MaxRedirects,
+		MaxBodySize:    s.maxBodySize,
+		BodyLimitMode:  s.bodyLimitMode,
+		RawBody:        options.RawBody,
+	}
+	// Лимит из запроса может только уменьшить лимит сервиса
+	if options.MaxBodySize > 0 && (s.maxBodySize <= 0 || options.MaxBodySize < s.maxBodySize) {
+		siteRequest.MaxBodySize = options.MaxBodySize
+	}
+	if options.BodyLimitMode != "" {
+		siteRequest.BodyLimitMode = options.BodyLimitMode
+	}
+	if siteRequest.Method == "" {
+		siteRequest.Method = http.MethodGet
+	}
+
+	var ruleHeaders map[string]string
+	if rule != nil {
+		siteRequest.MaxAttempts = rule.MaxAttempts
+		siteRequest.RetryNonIdempotent = rule.RetryNonIdempotent
+		ruleHeaders = rule.Headers
+	}
+
+	if len(ruleHeaders) == 0 && len(options.Headers) == 0 && len(request.Headers) == 0 {
+		return
+	}
+	siteRequest.Headers = make(map[string]string, len(ruleHeaders)+len(options.Headers)+len(request.Headers))
+	for name, value := range ruleHeaders {
+		siteRequest.Headers[http.CanonicalHeaderKey(name)] = value
+	}
+	for name, value := range options.Headers {
+		siteRequest.Headers[http.CanonicalHeaderKey(name)] = value
+	}
+	for name, value := range request.Headers {
+		siteRequest.Headers[http.CanonicalHeaderKey(name)] = value
+	}
+
+	return
+}
+
+// applyBodyLimit применяет лимит размера ответа к данным из кэша
+func (s *service) applyBodyLimit(siteData *api.SiteData, siteRequest *sites.Request) (err error) {
+	// Лимит относится к байтам ответа, поэтому данные в base64 сначала декодируем
+	data := []byte(siteData.Data)
+	if siteData.Encoding == api.SiteDataEncodingBase64 {
+		if data, err = base64.StdEncoding.DecodeString(siteData.Data); err != nil {
+			return s.errorCreator(
+				http.StatusInternalServerError,
+				fmt.Sprintf("Не удалось прочитать данные %s из кэша", siteData.URL),
+				fmt.Sprintf("failed to decode cached data of %s: %s", siteData.URL, err),
+			)
+		}
+	}
+
+	if siteRequest.MaxBodySize <= 0 || int64(len(data)) <= siteRequest.MaxBodySize {
+		return
+	}
+	if siteRequest.BodyLimitMode != api.BodyLimitModeTruncate {
+		return s.bodyTooLargeError(siteData.URL, siteRequest.MaxBodySize)
+	}
+
+	data = data[:siteRequest.MaxBodySize]
+	siteData.Data = string(data)
+	if siteData.Encoding == api.SiteDataEncodingBase64 {
+		siteData.Data = base64.StdEncoding.EncodeToString(data)
+	}
+	siteData.Meta.ContentLength = siteRequest.MaxBodySize
+	siteData.Meta.Truncated = true
+	return
+}
+
+// checkBinaryContent отклоняет двоичные данные, если запрос их не принимает
+func (s *service) checkBinaryContent(siteData *api.SiteData, options *api.FetchOptions) (err error) {
+	binaryContent := s.binaryContent
+	if options.BinaryContent != "" {
+		binaryContent = options.BinaryContent
+	}
+	if siteData.Encoding != api.SiteDataEncodingBase64 || binaryContent != api.BinaryContentReject {
+		return
+	}
+
+	return s.errorCreator(
+		http.StatusUnsupportedMediaType,
+		fmt.Sprintf("Ответ от %s содержит двоичные данные", siteData.URL),
+		fmt.Sprintf("binary content from %s is rejected, content type %q", siteData.URL, siteData.Meta.ContentType),
+	)
+}
+
+// checkContentType отклоняет данные, тип которых не разрешен правилом домена
+func (s *service) checkContentType(siteData *api.SiteData, rule *siterules.Rule) (err error) {
+	// У ответа 204 нет содержимого, проверять нечего
+	if rule == nil || siteData.Meta.StatusCode == http.StatusNoContent || rule.AllowsContentType(siteData.Meta.ContentType) {
+		return
+	}
+
+	return s.errorCreator(
+		http.StatusUnsupportedMediaType,
+		fmt.Sprintf("Тип содержимого %q от %s не разрешен правилом %s", siteData.Meta.ContentType, siteData.URL, rule.Name),
+		fmt.Sprintf("content type %q from %s is not allowed by rule %s", siteData.Meta.ContentType, siteData.URL, rule.Name),
+	)
+}
+
+func (s *service) bodyTooLargeError(url string, maxBodySize int64) (err error) {
+	return s.errorCreator(
+		http.StatusRequestEntityTooLarge,
+		fmt.Sprintf("Ответ от %s больше %d байт", url, maxBodySize),
+		fmt.Sprintf("response from %s is larger than %d bytes", url, maxBodySize),
+	)
+}
+
+// siteDataError формирует ответ по урлу, данные по которому получить не удалось
+func (s *service) siteDataError(url string, err error) (siteData *api.SiteData) {
+	siteDataErr := &api.SiteDataError{Code: http.StatusInternalServerError, Message: err.Error()}
+	if e, ok := err.(*httperror.Error); ok {
+		siteDataErr.Code = e.Code
+		siteDataErr.Message = e.Message
+	}
+
+	return &api.SiteData{URL: url, Status: api.SiteDataStatusError, Error: siteDataErr}
+}
+
 // NewService ...
 func NewService(
 	inputValidator inputValidator,
 	errorCreator httperror.ErrorCreator,
 	sitesClient sitesClient,
+	fetchPool fetchPool,
+	robotsChecker robotsChecker,
+	cacheKeyBuilder cacheKeyBuilder,
+	cachePolicy cachePolicy,
+	ruleMatcher ruleMatcher,
 	sitesDataMongoObjectsBuilder sitesDataMongoObjectsBuilder,
 	sitesDataMongoWrapper sitesDataMongoWrapper,
 	logger log.Logger,
 	sitesDataConverter sitesDataConverter,
-	createDataFieldName string,
-	sortAsc int,
+	maxCacheAge time.Duration,
+	revalidateWindow time.Duration,
+	staleIfError time.Duration,
+	staleWhileRevalidate time.Duration,
+	sitesClientTimeout time.Duration,
+	maxBodySize int64,
+	bodyLimitMode string,
+	binaryContent string,
+	jobsManager jobsManager,
+	webhookSender webhookSender,
+	snapshotsPageLimit int,
+	snapshotsMaxPageSize int,
+	snapshotsDiffer snapshotsDiffer,
+	snapshotsDiffMaxSize int,
 ) svc.Service {
 	return &service{
 		inputValidator:               inputValidator,
 		errorCreator:                 errorCreator,
 		sitesClient:                  sitesClient,
+		fetchPool:                    fetchPool,
+		robotsChecker:                robotsChecker,
+		cacheKeyBuilder:              cacheKeyBuilder,
+		cachePolicy:                  cachePolicy,
+		ruleMatcher:                  ruleMatcher,
 		sitesDataMongoObjectsBuilder: sitesDataMongoObjectsBuilder,
 		sitesDataMongoWrapper:        sitesDataMongoWrapper,
 		logger:                       logger,
 		sitesDataConverter:           sitesDataConverter,
-		createDataFieldName:          createDataFieldName,
-		sortAsc:                      sortAsc,
+		maxCacheAge:                  maxCacheAge,
+		revalidateWindow:             revalidateWindow,
+		staleIfError:                 staleIfError,
+		staleWhileRevalidate:         staleWhileRevalidate,
+		sitesClientTimeout:           sitesClientTimeout,
+		maxBodySize:                  maxBodySize,
+		bodyLimitMode:                bodyLimitMode,
+		binaryContent:                binaryContent,
+		jobsManager:                  jobsManager,
+		webhookSender:                webhookSender,
+		snapshotsPageLimit:           snapshotsPageLimit,
+		snapshotsMaxPageSize:         snapshotsMaxPageSize,
+		snapshotsDiffer:              snapshotsDiffer,
+		snapshotsDiffMaxSize:         snapshotsDiffMaxSize,
 	}
 }
@@ -21,3 +21,97 @@ func (s *MockService) GetDataFromURLs(ctx context.Context, urls []string) (respo
 	}
 	return nil, args.Error(1)
 }
+
+// GetDataFromURLsWithOptions ...
+func (s *MockService) GetDataFromURLsWithOptions(ctx context.Context, requests []*api.SiteRequest, options *api.FetchOptions) (response []*api.SiteData, err error) {
+	args := s.Called(context.Background(), requests, options)
+	if a, ok := args.Get(0).([]*api.SiteData); ok {
+		return a, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+// StreamDataFromURLs ...
+func (s *MockService) StreamDataFromURLs(ctx context.Context, requests []*api.SiteRequest, options *api.FetchOptions, handle func(siteData *api.SiteData) error) (err error) {
+	args := s.Called(context.Background(), requests, options)
+	if a, ok := args.Get(0).([]*api.SiteData); ok {
+		for i := range a {
+			if err = handle(a[i]); err != nil {
+				return
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+// CreateJob ...
+func (s *MockService) CreateJob(ctx context.Context, request *api.CreateJobRequest) (job *api.Job, err error) {
+	args := s.Called(context.Background(), request)
+	if a, ok := args.Get(0).(*api.Job); ok {
+		return a, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+// GetJob ...
+func (s *MockService) GetJob(ctx context.Context, id string, offset int, limit int) (response *api.GetJobResponse, err error) {
+	args := s.Called(context.Background(), id, offset, limit)
+	if a, ok := args.Get(0).(*api.GetJobResponse); ok {
+		return a, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+// CancelJob ...
+func (s *MockService) CancelJob(ctx context.Context, id string) (job *api.Job, err error) {
+	args := s.Called(context.Background(), id)
+	if a, ok := args.Get(0).(*api.Job); ok {
+		return a, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+// GetFailedWebhookDeliveries ...
+func (s *MockService) GetFailedWebhookDeliveries(ctx context.Context, offset int, limit int) (deliveries []*api.WebhookDelivery, err error) {
+	args := s.Called(context.Background(), offset, limit)
+	if a, ok := args.Get(0).([]*api.WebhookDelivery); ok {
+		return a, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+// ReplayWebhookDelivery ...
+func (s *MockService) ReplayWebhookDelivery(ctx context.Context, id string) (delivery *api.WebhookDelivery, err error) {
+	args := s.Called(context.Background(), id)
+	if a, ok := args.Get(0).(*api.WebhookDelivery); ok {
+		return a, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+// GetSnapshots ...
+func (s *MockService) GetSnapshots(ctx context.Context, request *api.GetSnapshotsRequest) (response *api.GetSnapshotsResponse, err error) {
+	args := s.Called(context.Background(), request)
+	if a, ok := args.Get(0).(*api.GetSnapshotsResponse); ok {
+		return a, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+// GetSnapshot ...
+func (s *MockService) GetSnapshot(ctx context.Context, id string) (snapshot *api.Snapshot, err error) {
+	args := s.Called(context.Background(), id)
+	if a, ok := args.Get(0).(*api.Snapshot); ok {
+		return a, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+// GetSnapshotsDiff ...
+func (s *MockService) GetSnapshotsDiff(ctx context.Context, request *api.GetSnapshotsDiffRequest) (diff *api.SnapshotsDiff, err error) {
+	args := s.Called(context.Background(), request)
+	if a, ok := args.Get(0).(*api.SnapshotsDiff); ok {
+		return a, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
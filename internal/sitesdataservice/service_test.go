@@ -0,0 +1,587 @@
+package sitesdataservice
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/mts-test-task/internal/cachekey"
+	"github.com/mts-test-task/internal/cachepolicy"
+	"github.com/mts-test-task/internal/converter"
+	"github.com/mts-test-task/internal/robots"
+	"github.com/mts-test-task/internal/siterules"
+	"github.com/mts-test-task/internal/sites"
+	"github.com/mts-test-task/internal/storages/mongodb/builder"
+	"github.com/mts-test-task/internal/storages/mongodb/models"
+	"github.com/mts-test-task/internal/urlcanon"
+	"github.com/mts-test-task/internal/workerpool"
+	"github.com/mts-test-task/pkg/sitesdataservice/api"
+	"github.com/mts-test-task/pkg/sitesdataservice/httperror"
+)
+
+const testURL = "http://example.com/page"
+
+var (
+	testKeyBuilder   = cachekey.NewBuilder(urlcanon.NewCanonicalizer(nil), nil, api.RedirectPolicyFollow)
+	testMongoObjects = builder.NewSitesDataMongoObjects(
+		"create_date", "url", "key", "final_key", "data", "binary_data", "meta", "etag", "last_modified", "vary_key", "content_hash",
+	)
+)
+
+type inputValidatorStub struct{}
+
+func (v inputValidatorStub) CheckRequests(requests []*api.SiteRequest) (err error) { return nil }
+
+func (v inputValidatorStub) CheckJobURLs(urls []string) (err error) { return nil }
+
+func (v inputValidatorStub) CheckOptions(options *api.FetchOptions) (err error) { return nil }
+
+func (v inputValidatorStub) CheckCallbackURL(callbackURL string) (err error) { return nil }
+
+func (v inputValidatorStub) CheckSnapshotsRequest(request *api.GetSnapshotsRequest) (err error) {
+	return nil
+}
+
+func (v inputValidatorStub) CheckSnapshotsDiffRequest(request *api.GetSnapshotsDiffRequest) (err error) {
+	return nil
+}
+
+type sitesClientFunc func(ctx context.Context, request *sites.Request) (*sites.Response, error)
+
+func (f sitesClientFunc) GetData(ctx context.Context, request *sites.Request) (*sites.Response, error) {
+	return f(ctx, request)
+}
+
+type robotsCheckerStub struct {
+	err error
+}
+
+func (r *robotsCheckerStub) Check(ctx context.Context, rawURL string) (crawlDelay time.Duration, err error) {
+	return 0, r.err
+}
+
+// inlinePool выполняет задачи сразу, поэтому фоновое обновление кэша завершается до проверок теста
+type inlinePool struct{}
+
+func (p inlinePool) Submit(tasks []func()) (err error) {
+	for _, task := range tasks {
+		task()
+	}
+	return nil
+}
+
+// overloadedPool отклоняет все задачи
+type overloadedPool struct{}
+
+func (p overloadedPool) Submit(tasks []func()) (err error) {
+	return workerpool.ErrOverloaded
+}
+
+type sitesDataWrapperStub struct {
+	// cached - записи кэша, hashes - последние записи по ключам для сравнения содержимого
+	cached    []*models.SiteData
+	hashes    []*models.SiteData
+	hashesErr error
+	latest    []mongo.WriteModel
+	history   []interface{}
+}
+
+func (w *sitesDataWrapperStub) GetSitesData(ctx context.Context, pipeline mongo.Pipeline) (sitesData []*models.SiteData, err error) {
+	return w.cached, nil
+}
+
+func (w *sitesDataWrapperStub) UpsertSitesData(ctx context.Context, latest []mongo.WriteModel) (err error) {
+	w.latest = append(w.latest, latest...)
+	return nil
+}
+
+func (w *sitesDataWrapperStub) FindSitesData(ctx context.Context, filter bson.M, findOptions *options.FindOptions) (sitesData []*models.SiteData, err error) {
+	return w.hashes, w.hashesErr
+}
+
+func (w *sitesDataWrapperStub) AddSitesDataHistory(ctx context.Context, data []interface{}) (err error) {
+	w.history = append(w.history, data...)
+	return nil
+}
+
+func (w *sitesDataWrapperStub) GetSnapshots(ctx context.Context, filter bson.M, findOptions *options.FindOptions) (snapshots []*models.SiteData, err error) {
+	return nil, nil
+}
+
+func (w *sitesDataWrapperStub) GetSnapshot(ctx context.Context, filter bson.M, findOptions *options.FindOneOptions) (snapshot *models.SiteData, err error) {
+	return nil, nil
+}
+
+// newTestService создает сервис с кэшем на 10 минут без окон отдачи устаревших данных
+func newTestService(client sitesClient, robotsErr error, wrapper *sitesDataWrapperStub, rules []*siterules.Rule) *service {
+	return NewService(
+		inputValidatorStub{},
+		httperror.NewError,
+		client,
+		inlinePool{},
+		&robotsCheckerStub{err: robotsErr},
+		testKeyBuilder,
+		cachepolicy.NewPolicy(0, 24*time.Hour),
+		siterules.NewMatcher(rules),
+		testMongoObjects,
+		wrapper,
+		log.NewNopLogger(),
+		converter.NewSitesData(),
+		10*time.Minute,
+		0,
+		0,
+		0,
+		time.Second,
+		0,
+		api.BodyLimitModeFail,
+		api.BinaryContentAllow,
+		nil,
+		nil,
+		10,
+		100,
+		nil,
+		1024,
+	).(*service)
+}
+
+// testStoredSiteData - запись кэша по testURL, полученная с сайта age назад
+func testStoredSiteData(data string, age time.Duration) *models.SiteData {
+	return &models.SiteData{
+		URL:         testURL,
+		Key:         testKeyBuilder.Key(&sites.Request{Method: http.MethodGet, URL: testURL}),
+		Data:        data,
+		CreateDate:  int(time.Now().Add(-age).Unix()),
+		ContentHash: testMongoObjects.ContentHash(&api.SiteData{Data: data}),
+		Meta: &models.SiteDataMeta{
+			StatusCode:  http.StatusOK,
+			ContentType: "text/plain",
+			FetchedAt:   time.Now().Add(-age).UnixNano() / int64(time.Millisecond),
+		},
+	}
+}
+
+// testResponse - ответ сайта с данными data и заголовками headers
+func testResponse(data string, headers http.Header) *sites.Response {
+	return &sites.Response{
+		Data:        data,
+		StatusCode:  http.StatusOK,
+		ContentType: "text/plain",
+		FetchedAt:   time.Now(),
+		Attempts:    1,
+		AllHeaders:  headers,
+	}
+}
+
+func TestService_StaleIfError(t *testing.T) {
+	tests := []struct {
+		name           string
+		age            time.Duration
+		mustRevalidate bool
+		robotsErr      error
+		wantStale      bool
+		wantCode       int
+	}{
+		{
+			name:      "site error within window",
+			age:       12 * time.Minute,
+			wantStale: true,
+		},
+		{
+			name:      "robots.txt unavailable within window",
+			age:       12 * time.Minute,
+			robotsErr: robots.ErrUnavailable,
+			wantStale: true,
+		},
+		{
+			name:      "disallowed by robots.txt",
+			age:       12 * time.Minute,
+			robotsErr: robots.ErrDisallowed,
+			wantCode:  http.StatusForbidden,
+		},
+		{
+			name:     "site error after window",
+			age:      20 * time.Minute,
+			wantCode: http.StatusBadGateway,
+		},
+		{
+			name:           "site error for response that must be revalidated",
+			age:            12 * time.Minute,
+			mustRevalidate: true,
+			wantCode:       http.StatusBadGateway,
+		},
+		{
+			name:           "robots.txt unavailable for response that must be revalidated",
+			age:            12 * time.Minute,
+			mustRevalidate: true,
+			robotsErr:      robots.ErrUnavailable,
+			wantCode:       http.StatusServiceUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stored := testStoredSiteData("stored", tt.age)
+			stored.Meta.MustRevalidate = tt.mustRevalidate
+			wrapper := &sitesDataWrapperStub{cached: []*models.SiteData{stored}}
+			client := sitesClientFunc(func(ctx context.Context, request *sites.Request) (*sites.Response, error) {
+				return nil, errors.New("connection refused")
+			})
+			s := newTestService(client, tt.robotsErr, wrapper, nil)
+			s.staleIfError = 5 * time.Minute
+
+			response, err := s.GetDataFromURLsWithOptions(
+				context.Background(),
+				[]*api.SiteRequest{{URL: testURL}},
+				&api.FetchOptions{FailureMode: api.FailureModePartial},
+			)
+			if !assert.NoError(t, err) || !assert.Len(t, response, 1) {
+				return
+			}
+			if tt.wantCode != 0 {
+				if assert.NotNil(t, response[0].Error) {
+					assert.Equal(t, tt.wantCode, response[0].Error.Code)
+				}
+				return
+			}
+			assert.Nil(t, response[0].Error)
+			assert.Equal(t, "stored", response[0].Data)
+			assert.Equal(t, tt.wantStale, response[0].Meta.Stale)
+			// Устаревшие данные не пересохраняются
+			assert.Empty(t, wrapper.latest)
+		})
+	}
+}
+
+func TestService_PoolOverloaded(t *testing.T) {
+	s := newTestService(nil, nil, &sitesDataWrapperStub{}, nil)
+	s.fetchPool = overloadedPool{}
+
+	_, err := s.GetDataFromURLs(context.Background(), []string{testURL})
+	// Перегрузку пула можно отличить от 503 по отдельному урлу, код ответа при этом прежний
+	assert.True(t, errors.Is(err, workerpool.ErrOverloaded), "unexpected error: %v", err)
+	if assert.IsType(t, &httperror.Error{}, err) {
+		assert.Equal(t, http.StatusServiceUnavailable, err.(*httperror.Error).StatusCode())
+	}
+}
+
+// replacementField возвращает поле документа, которым заменяется последняя запись по ключу
+func replacementField(model mongo.WriteModel, name string) interface{} {
+	for _, field := range model.(*mongo.ReplaceOneModel).Replacement.(bson.D) {
+		if field.Key == name {
+			return field.Value
+		}
+	}
+	return nil
+}
+
+func TestStaleness(t *testing.T) {
+	now := time.Now()
+	headerTTL := (20 * time.Minute).Milliseconds()
+	tests := []struct {
+		name          string
+		cacheTTL      *int64
+		noMeta        bool
+		maxCacheAge   time.Duration
+		maxCacheAgeMs int64
+		ruleTTL       time.Duration
+		want          time.Duration
+	}{
+		{
+			name:        "default age without caching headers",
+			maxCacheAge: 5 * time.Minute,
+			want:        5 * time.Minute,
+		},
+		{
+			name:        "headers replace default age",
+			cacheTTL:    &headerTTL,
+			maxCacheAge: 5 * time.Minute,
+			want:        -10 * time.Minute,
+		},
+		{
+			name:        "rule replaces headers",
+			cacheTTL:    &headerTTL,
+			maxCacheAge: 5 * time.Minute,
+			ruleTTL:     time.Minute,
+			want:        9 * time.Minute,
+		},
+		{
+			name:          "requested age shortens rule",
+			maxCacheAge:   2 * time.Minute,
+			maxCacheAgeMs: (2 * time.Minute).Milliseconds(),
+			ruleTTL:       20 * time.Minute,
+			want:          8 * time.Minute,
+		},
+		{
+			name:          "requested age does not extend headers",
+			cacheTTL:      &headerTTL,
+			maxCacheAge:   30 * time.Minute,
+			maxCacheAgeMs: (30 * time.Minute).Milliseconds(),
+			want:          -10 * time.Minute,
+		},
+		{
+			name:        "record without metadata is aged by create date",
+			noMeta:      true,
+			maxCacheAge: 5 * time.Minute,
+			want:        5 * time.Minute,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stored := &models.SiteData{
+				CreateDate: int(now.Add(-10 * time.Minute).Unix()),
+				Meta: &models.SiteDataMeta{
+					FetchedAt: now.Add(-10*time.Minute).UnixNano() / int64(time.Millisecond),
+					CacheTTL:  tt.cacheTTL,
+				},
+			}
+			if tt.noMeta {
+				stored.Meta = nil
+			}
+			// Дата создания хранится в секундах
+			assert.InDelta(t, tt.want, staleness(stored, tt.maxCacheAge, tt.maxCacheAgeMs, tt.ruleTTL, now), float64(time.Second))
+		})
+	}
+}
+
+func TestService_Revalidation(t *testing.T) {
+	storedTTL := time.Second.Milliseconds()
+	tests := []struct {
+		name             string
+		etag             string
+		lastModified     string
+		response         *sites.Response
+		wantIfNoneMatch  string
+		wantData         string
+		wantRevalidated  bool
+		wantETag         string
+		wantLastModified string
+		wantCacheTTLMs   int64
+		wantHistory      int
+	}{
+		{
+			name:             "304 keeps data and stored validators",
+			etag:             `"v1"`,
+			lastModified:     "Wed, 21 Oct 2015 07:28:00 GMT",
+			response:         &sites.Response{NotModified: true, FetchedAt: time.Now(), Attempts: 1},
+			wantIfNoneMatch:  `"v1"`,
+			wantData:         "stored",
+			wantRevalidated:  true,
+			wantETag:         `"v1"`,
+			wantLastModified: "Wed, 21 Oct 2015 07:28:00 GMT",
+			wantCacheTTLMs:   storedTTL,
+		},
+		{
+			name:         "304 with new validators and caching headers",
+			etag:         `"v1"`,
+			lastModified: "Wed, 21 Oct 2015 07:28:00 GMT",
+			response: &sites.Response{
+				NotModified: true,
+				ETag:        `"v2"`,
+				FetchedAt:   time.Now(),
+				Attempts:    1,
+				AllHeaders:  http.Header{"Cache-Control": []string{"max-age=600"}},
+			},
+			wantIfNoneMatch:  `"v1"`,
+			wantData:         "stored",
+			wantRevalidated:  true,
+			wantETag:         `"v2"`,
+			wantLastModified: "Wed, 21 Oct 2015 07:28:00 GMT",
+			wantCacheTTLMs:   (10 * time.Minute).Milliseconds(),
+		},
+		{
+			name:           "record without validators is fetched unconditionally",
+			response:       testResponse("fresh", http.Header{"Cache-Control": []string{"max-age=60"}}),
+			wantData:       "fresh",
+			wantCacheTTLMs: time.Minute.Milliseconds(),
+			wantHistory:    1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stored := testStoredSiteData("stored", 12*time.Minute)
+			stored.ETag = tt.etag
+			stored.LastModified = tt.lastModified
+			stored.Meta.CacheTTL = &storedTTL
+			wrapper := &sitesDataWrapperStub{cached: []*models.SiteData{stored}, hashes: []*models.SiteData{stored}}
+			client := sitesClientFunc(func(ctx context.Context, request *sites.Request) (*sites.Response, error) {
+				assert.Equal(t, tt.wantIfNoneMatch, request.IfNoneMatch)
+				assert.Equal(t, tt.lastModified, request.IfModifiedSince)
+				return tt.response, nil
+			})
+			s := newTestService(client, nil, wrapper, nil)
+
+			response, err := s.GetDataFromURLs(context.Background(), []string{testURL})
+			if !assert.NoError(t, err) || !assert.Len(t, response, 1) {
+				return
+			}
+			meta := response[0].Meta
+			assert.Equal(t, tt.wantData, response[0].Data)
+			assert.Equal(t, tt.wantRevalidated, meta.Revalidated)
+			assert.Equal(t, tt.wantETag, meta.ETag)
+			assert.Equal(t, tt.wantLastModified, meta.LastModified)
+			if assert.NotNil(t, meta.CacheTTLMs) {
+				assert.Equal(t, tt.wantCacheTTLMs, *meta.CacheTTLMs)
+			}
+			// Подтвержденная запись пересохраняется с новыми валидаторами, история пополняется только новым содержимым
+			if assert.Len(t, wrapper.latest, 1) && tt.wantETag != "" {
+				assert.Equal(t, tt.wantETag, replacementField(wrapper.latest[0], "etag"))
+			}
+			assert.Len(t, wrapper.history, tt.wantHistory)
+		})
+	}
+}
+
+func TestService_StaleWhileRevalidate(t *testing.T) {
+	tests := []struct {
+		name           string
+		age            time.Duration
+		mustRevalidate bool
+		fetchErr       error
+		wantData       string
+		wantStale      bool
+		wantFetches    int
+		wantStored     int
+	}{
+		{
+			name:     "fresh record",
+			age:      time.Minute,
+			wantData: "stored",
+		},
+		{
+			name:        "stale record within window is refreshed in background",
+			age:         12 * time.Minute,
+			wantData:    "stored",
+			wantStale:   true,
+			wantFetches: 1,
+			wantStored:  1,
+		},
+		{
+			name:        "failed refresh keeps record",
+			age:         12 * time.Minute,
+			fetchErr:    errors.New("connection refused"),
+			wantData:    "stored",
+			wantStale:   true,
+			wantFetches: 1,
+		},
+		{
+			name:           "response that must be revalidated is fetched first",
+			age:            12 * time.Minute,
+			mustRevalidate: true,
+			wantData:       "fresh",
+			wantFetches:    1,
+			wantStored:     1,
+		},
+		{
+			name:        "record after window is fetched first",
+			age:         20 * time.Minute,
+			wantData:    "fresh",
+			wantFetches: 1,
+			wantStored:  1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stored := testStoredSiteData("stored", tt.age)
+			stored.Meta.MustRevalidate = tt.mustRevalidate
+			wrapper := &sitesDataWrapperStub{cached: []*models.SiteData{stored}, hashes: []*models.SiteData{stored}}
+			fetches := 0
+			client := sitesClientFunc(func(ctx context.Context, request *sites.Request) (*sites.Response, error) {
+				fetches++
+				if tt.fetchErr != nil {
+					return nil, tt.fetchErr
+				}
+				return testResponse("fresh", nil), nil
+			})
+			s := newTestService(client, nil, wrapper, nil)
+			s.staleWhileRevalidate = 5 * time.Minute
+
+			response, err := s.GetDataFromURLs(context.Background(), []string{testURL})
+			if !assert.NoError(t, err) || !assert.Len(t, response, 1) {
+				return
+			}
+			assert.Equal(t, tt.wantData, response[0].Data)
+			assert.Equal(t, tt.wantStale, response[0].Meta.Stale)
+			assert.Equal(t, tt.wantFetches, fetches)
+			// Сохраняются только данные сайта: устаревшая запись остается прежней до успешного обновления
+			if assert.Len(t, wrapper.latest, tt.wantStored) && tt.wantStored > 0 {
+				assert.Equal(t, "fresh", replacementField(wrapper.latest[0], "data"))
+			}
+		})
+	}
+}
+
+func TestService_StoreSitesData(t *testing.T) {
+	latest := testStoredSiteData("fresh", time.Hour)
+	changed := testStoredSiteData("old", time.Hour)
+	tests := []struct {
+		name        string
+		response    *sites.Response
+		hashes      []*models.SiteData
+		hashesErr   error
+		wantStored  int
+		wantHistory int
+	}{
+		{
+			name:        "first content of key",
+			response:    testResponse("fresh", nil),
+			wantStored:  1,
+			wantHistory: 1,
+		},
+		{
+			name:       "unchanged content of old record",
+			response:   testResponse("fresh", nil),
+			hashes:     []*models.SiteData{latest},
+			wantStored: 1,
+		},
+		{
+			name:        "changed content",
+			response:    testResponse("fresh", nil),
+			hashes:      []*models.SiteData{changed},
+			wantStored:  1,
+			wantHistory: 1,
+		},
+		{
+			name:        "hashes are unavailable",
+			response:    testResponse("fresh", nil),
+			hashesErr:   errors.New("mongo is down"),
+			wantStored:  1,
+			wantHistory: 1,
+		},
+		{
+			name: "truncated data is not stored",
+			response: func() *sites.Response {
+				response := testResponse("fresh", nil)
+				response.Truncated = true
+				return response
+			}(),
+		},
+		{
+			name:     "no-store response is not stored",
+			response: testResponse("fresh", http.Header{"Cache-Control": []string{"no-store"}}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wrapper := &sitesDataWrapperStub{hashes: tt.hashes, hashesErr: tt.hashesErr}
+			client := sitesClientFunc(func(ctx context.Context, request *sites.Request) (*sites.Response, error) {
+				return tt.response, nil
+			})
+			s := newTestService(client, nil, wrapper, nil)
+
+			response, err := s.GetDataFromURLs(context.Background(), []string{testURL})
+			if !assert.NoError(t, err) || !assert.Len(t, response, 1) {
+				return
+			}
+			assert.Equal(t, "fresh", response[0].Data)
+			assert.Len(t, wrapper.latest, tt.wantStored)
+			assert.Len(t, wrapper.history, tt.wantHistory)
+		})
+	}
+}
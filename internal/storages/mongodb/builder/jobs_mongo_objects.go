@@ -0,0 +1,179 @@
+package builder
+
+import (
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/mts-test-task/internal/storages/mongodb/models"
+	"github.com/mts-test-task/pkg/sitesdataservice/api"
+)
+
+// Название полей заданий в mongodb, должны совпадать с тегами models.Job и models.JobResult
+const (
+	jobIDNameField         = "_id"
+	jobStatusNameField     = "status"
+	jobURLsNameField       = "urls"
+	jobProcessedNameField  = "processed"
+	jobFailedNameField     = "failed"
+	jobErrorNameField      = "error"
+	jobUpdateDateNameField = "update_date"
+
+	jobResultJobIDNameField = "job_id"
+	jobResultIndexNameField = "index"
+)
+
+// JobsMongoObjects build necessary objects for requests to jobs collections in mongodb
+type JobsMongoObjects interface {
+	NewJob(id string, request *api.CreateJobRequest, createTime int64) (job *models.Job)
+	JobFilter(id string, statuses ...string) (filter bson.M)
+	JobsFilter(statuses ...string) (filter bson.M)
+	JobIDsOptions() (findOptions *options.FindOptions)
+	JobWithoutURLsOptions() (findOptions *options.FindOneOptions)
+	JobUpdateOptions(withURLs bool) (updateOptions *options.FindOneAndUpdateOptions)
+	JobStatusUpdate(status string, jobError string, updateTime int64) (update bson.M)
+	JobProgressUpdate(processed int, failed int, updateTime int64) (update bson.M)
+	JobResults(jobID string, offset int, sitesData []*api.SiteData) (results []mongo.WriteModel)
+	JobResultsFilter(jobID string) (filter bson.M)
+	JobResultsPageOptions(offset int, limit int) (findOptions *options.FindOptions)
+	JobResultsIndexes() (indexes []mongo.IndexModel)
+}
+
+type jobsMongoObjects struct{}
+
+func (j *jobsMongoObjects) NewJob(id string, request *api.CreateJobRequest, createTime int64) (job *models.Job) {
+	job = &models.Job{
+		ID:          id,
+		Status:      api.JobStatusPending,
+		URLs:        request.URLs,
+		Options:     &models.JobOptions{},
+		Total:       len(request.URLs),
+		CallbackURL: request.CallbackURL,
+		CreateDate:  createTime,
+		UpdateDate:  createTime,
+	}
+	if request.Options != nil {
+		job.Options.FailureMode = request.Options.FailureMode
+		job.Options.TimeoutMs = request.Options.TimeoutMs
+		job.Options.MaxCacheAgeMs = request.Options.MaxCacheAgeMs
+		job.Options.RedirectPolicy = request.Options.RedirectPolicy
+		job.Options.MaxRedirects = request.Options.MaxRedirects
+		job.Options.MaxBodySize = request.Options.MaxBodySize
+		job.Options.BodyLimitMode = request.Options.BodyLimitMode
+		job.Options.RawBody = request.Options.RawBody
+		job.Options.BinaryContent = request.Options.BinaryContent
+		job.Options.Headers = request.Options.Headers
+	}
+
+	return
+}
+
+func (j *jobsMongoObjects) JobFilter(id string, statuses ...string) (filter bson.M) {
+	filter = bson.M{jobIDNameField: id}
+	if len(statuses) != 0 {
+		filter[jobStatusNameField] = bson.M{"$in": statuses}
+	}
+
+	return
+}
+
+func (j *jobsMongoObjects) JobsFilter(statuses ...string) (filter bson.M) {
+	return bson.M{
+		jobStatusNameField: bson.M{"$in": statuses},
+	}
+}
+
+func (j *jobsMongoObjects) JobIDsOptions() (findOptions *options.FindOptions) {
+	return options.Find().SetProjection(bson.M{jobIDNameField: 1})
+}
+
+func (j *jobsMongoObjects) JobWithoutURLsOptions() (findOptions *options.FindOneOptions) {
+	return options.FindOne().SetProjection(bson.M{jobURLsNameField: 0})
+}
+
+func (j *jobsMongoObjects) JobUpdateOptions(withURLs bool) (updateOptions *options.FindOneAndUpdateOptions) {
+	updateOptions = options.FindOneAndUpdate().SetReturnDocument(options.After)
+	if !withURLs {
+		updateOptions.SetProjection(bson.M{jobURLsNameField: 0})
+	}
+
+	return
+}
+
+func (j *jobsMongoObjects) JobStatusUpdate(status string, jobError string, updateTime int64) (update bson.M) {
+	return bson.M{
+		"$set": bson.M{
+			jobStatusNameField:     status,
+			jobErrorNameField:      jobError,
+			jobUpdateDateNameField: updateTime,
+		},
+	}
+}
+
+func (j *jobsMongoObjects) JobProgressUpdate(processed int, failed int, updateTime int64) (update bson.M) {
+	return bson.M{
+		"$set": bson.M{
+			jobProcessedNameField:  processed,
+			jobUpdateDateNameField: updateTime,
+		},
+		"$inc": bson.M{
+			jobFailedNameField: failed,
+		},
+	}
+}
+
+func (j *jobsMongoObjects) JobResults(jobID string, offset int, sitesData []*api.SiteData) (results []mongo.WriteModel) {
+	results = make([]mongo.WriteModel, len(sitesData))
+	for i := 0; i < len(sitesData); i++ {
+		result := &models.JobResult{
+			// Идентификатор зависит от позиции урла, чтобы повторная обработка после перезапуска не создавала дублей
+			ID:     fmt.Sprintf("%s-%d", jobID, offset+i),
+			JobID:  jobID,
+			Index:  offset + i,
+			URL:    sitesData[i].URL,
+			Status: sitesData[i].Status,
+			Meta:   siteDataMeta(sitesData[i].Meta),
+		}
+		result.Data, result.BinaryData = siteDataContent(sitesData[i])
+		if sitesData[i].Error != nil {
+			result.ErrorCode = sitesData[i].Error.Code
+			result.ErrorMessage = sitesData[i].Error.Message
+		}
+		results[i] = mongo.NewReplaceOneModel().
+			SetFilter(bson.M{jobIDNameField: result.ID}).
+			SetReplacement(result).
+			SetUpsert(true)
+	}
+
+	return
+}
+
+func (j *jobsMongoObjects) JobResultsFilter(jobID string) (filter bson.M) {
+	return bson.M{jobResultJobIDNameField: jobID}
+}
+
+// JobResultsPageOptions returns all results starting from offset if limit is zero
+func (j *jobsMongoObjects) JobResultsPageOptions(offset int, limit int) (findOptions *options.FindOptions) {
+	return options.Find().
+		SetSort(bson.D{{Key: jobResultIndexNameField, Value: 1}}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit))
+}
+
+func (j *jobsMongoObjects) JobResultsIndexes() (indexes []mongo.IndexModel) {
+	return []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: jobResultJobIDNameField, Value: 1},
+				{Key: jobResultIndexNameField, Value: 1},
+			},
+		},
+	}
+}
+
+// NewJobsMongoObjects ...
+func NewJobsMongoObjects() JobsMongoObjects {
+	return &jobsMongoObjects{}
+}
@@ -0,0 +1,68 @@
+package builder
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/mts-test-task/internal/storages/mongodb/models"
+)
+
+// Название полей robots.txt в mongodb, должны совпадать с тегами models.Robots
+const (
+	robotsIDNameField       = "_id"
+	robotsExpireAtNameField = "expire_at"
+)
+
+// RobotsMongoObjects build necessary objects for requests to robots.txt collection in mongodb
+type RobotsMongoObjects interface {
+	NewRobots(origin string, statusCode int, body string, createTime time.Time, ttl time.Duration) (robots *models.Robots)
+	RobotsFilter(origin string, now time.Time) (filter bson.M)
+	RobotsIDFilter(origin string) (filter bson.M)
+	RobotsUpsertOptions() (replaceOptions *options.ReplaceOptions)
+	RobotsIndexes() (indexes []mongo.IndexModel)
+}
+
+type robotsMongoObjects struct{}
+
+func (r *robotsMongoObjects) NewRobots(origin string, statusCode int, body string, createTime time.Time, ttl time.Duration) (robots *models.Robots) {
+	return &models.Robots{
+		ID:         origin,
+		StatusCode: statusCode,
+		Body:       body,
+		CreateDate: createTime.Unix(),
+		ExpireAt:   createTime.Add(ttl),
+	}
+}
+
+// RobotsFilter отсекает устаревшие записи, которые TTL индекс еще не успел удалить
+func (r *robotsMongoObjects) RobotsFilter(origin string, now time.Time) (filter bson.M) {
+	return bson.M{
+		robotsIDNameField:       origin,
+		robotsExpireAtNameField: bson.M{"$gt": now},
+	}
+}
+
+func (r *robotsMongoObjects) RobotsIDFilter(origin string) (filter bson.M) {
+	return bson.M{robotsIDNameField: origin}
+}
+
+func (r *robotsMongoObjects) RobotsUpsertOptions() (replaceOptions *options.ReplaceOptions) {
+	return options.Replace().SetUpsert(true)
+}
+
+func (r *robotsMongoObjects) RobotsIndexes() (indexes []mongo.IndexModel) {
+	return []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: robotsExpireAtNameField, Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}
+}
+
+// NewRobotsMongoObjects ...
+func NewRobotsMongoObjects() RobotsMongoObjects {
+	return &robotsMongoObjects{}
+}
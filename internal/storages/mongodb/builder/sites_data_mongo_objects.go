@@ -1,59 +1,312 @@
 package builder
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
+	"github.com/mts-test-task/internal/storages/mongodb/models"
 	"github.com/mts-test-task/pkg/sitesdataservice/api"
 )
 
 // SitesDataMongoObjects build necessary objects for requests to mongodb
 type SitesDataMongoObjects interface {
-	GetSitesDataFilter(createDate int64) (filter bson.M)
-	AddSitesData(sitesData []*api.SiteData, createTime int64) (data []interface{})
-	SortOptions(fieldName string, sortType int) (findOptions *options.FindOptions)
+	GetSitesDataPipeline(urls []string, keys []string, createDate int64) (pipeline mongo.Pipeline)
+	AddSitesData(sitesData []*api.SiteData, urls []string, keys []string, finalKeys []string, varyKeys []string, createTime int64) (data []interface{})
+	LatestSitesData(data []interface{}, keys []string) (latest []mongo.WriteModel)
+	ContentHash(siteData *api.SiteData) (hash string)
+	ContentHashesFilter(keys []string) (filter bson.M)
+	ContentHashesOptions() (findOptions *options.FindOptions)
+	SitesDataIndexes() (indexes []mongo.IndexModel)
+	SitesDataHistoryIndexes() (indexes []mongo.IndexModel)
+	SnapshotsFilter(url string, from int64, to int64, cursorCreateDate int64, cursorID string) (filter bson.M, err error)
+	SnapshotsPageOptions(limit int) (findOptions *options.FindOptions)
+	SnapshotFilter(id string) (filter bson.M, err error)
+	SnapshotAtFilter(url string, at int64) (filter bson.M)
+	SnapshotAtOptions() (findOptions *options.FindOneOptions)
 }
 
 type sitesDataMongoObjects struct {
-	createDateNameField string
-	urlsNameField       string
-	dataNameFiled       string
+	createDateNameField   string
+	urlsNameField         string
+	keyNameField          string
+	finalKeyNameField     string
+	dataNameFiled         string
+	binaryDataNameField   string
+	metaNameField         string
+	etagNameField         string
+	lastModifiedNameField string
+	varyKeyNameField      string
+	contentHashNameField  string
 }
 
-func (s *sitesDataMongoObjects) GetSitesDataFilter(createDate int64) (filter bson.M) {
-	return bson.M{
-		s.createDateNameField: bson.M{
-			"$gt": createDate,
-		},
+// GetSitesDataPipeline выбирает записи новее createDate по запрошенным урлам, а также записи, конечный урл
+// которых совпадает с запросом, и оставляет последнюю запись для каждого ключа кэша
+func (s *sitesDataMongoObjects) GetSitesDataPipeline(urls []string, keys []string, createDate int64) (pipeline mongo.Pipeline) {
+	return mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			s.createDateNameField: bson.M{"$gt": createDate},
+			"$or": bson.A{
+				bson.M{s.urlsNameField: bson.M{"$in": urls}},
+				bson.M{s.finalKeyNameField: bson.M{"$in": keys}},
+			},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: s.createDateNameField, Value: -1}}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$" + s.keyNameField},
+			{Key: "doc", Value: bson.M{"$first": "$$ROOT"}},
+		}}},
+		{{Key: "$replaceRoot", Value: bson.M{"newRoot": "$doc"}}},
 	}
 }
 
-func (s *sitesDataMongoObjects) AddSitesData(sitesData []*api.SiteData, createTime int64) (data []interface{}) {
+// AddSitesData urls[i] is the canonical url of sitesData[i], keys[i] and finalKeys[i] are its cache keys,
+// final key is empty without redirects, vary key is empty without Vary
+func (s *sitesDataMongoObjects) AddSitesData(
+	sitesData []*api.SiteData,
+	urls []string,
+	keys []string,
+	finalKeys []string,
+	varyKeys []string,
+	createTime int64,
+) (data []interface{}) {
 	data = make([]interface{}, len(sitesData))
 	for i := 0; i < len(sitesData); i++ {
-		data[i] = bson.D{
+		content, binaryContent := siteDataContent(sitesData[i])
+		doc := bson.D{
 			{Key: s.createDateNameField, Value: createTime},
-			{Key: s.urlsNameField, Value: sitesData[i].URL},
-			{Key: s.dataNameFiled, Value: sitesData[i].Data},
+			{Key: s.urlsNameField, Value: urls[i]},
+			{Key: s.keyNameField, Value: keys[i]},
+			{Key: s.dataNameFiled, Value: content},
+			{Key: s.metaNameField, Value: siteDataMeta(sitesData[i].Meta)},
+			{Key: s.contentHashNameField, Value: contentHash(content, binaryContent)},
+		}
+		if binaryContent != nil {
+			doc = append(doc, bson.E{Key: s.binaryDataNameField, Value: binaryContent})
+		}
+		if finalKeys[i] != "" {
+			doc = append(doc, bson.E{Key: s.finalKeyNameField, Value: finalKeys[i]})
 		}
+		if varyKeys[i] != "" {
+			doc = append(doc, bson.E{Key: s.varyKeyNameField, Value: varyKeys[i]})
+		}
+		if meta := sitesData[i].Meta; meta != nil {
+			if meta.ETag != "" {
+				doc = append(doc, bson.E{Key: s.etagNameField, Value: meta.ETag})
+			}
+			if meta.LastModified != "" {
+				doc = append(doc, bson.E{Key: s.lastModifiedNameField, Value: meta.LastModified})
+			}
+		}
+		data[i] = doc
+	}
+
+	return
+}
+
+// LatestSitesData заменяет последнюю запись по ключу кэша документом data[i], keys[i] - ключ кэша документа
+func (s *sitesDataMongoObjects) LatestSitesData(data []interface{}, keys []string) (latest []mongo.WriteModel) {
+	latest = make([]mongo.WriteModel, len(data))
+	for i := 0; i < len(data); i++ {
+		latest[i] = mongo.NewReplaceOneModel().
+			SetFilter(bson.M{s.keyNameField: keys[i]}).
+			SetReplacement(data[i]).
+			SetUpsert(true)
+	}
+
+	return
+}
+
+func (s *sitesDataMongoObjects) ContentHash(siteData *api.SiteData) (hash string) {
+	return contentHash(siteDataContent(siteData))
+}
+
+// ContentHashesFilter выбирает последние записи по ключам кэша независимо от их возраста
+func (s *sitesDataMongoObjects) ContentHashesFilter(keys []string) (filter bson.M) {
+	return bson.M{s.keyNameField: bson.M{"$in": keys}}
+}
+
+// ContentHashesOptions оставляет в записях только ключ и хэш содержимого
+func (s *sitesDataMongoObjects) ContentHashesOptions() (findOptions *options.FindOptions) {
+	return options.Find().SetProjection(bson.M{s.keyNameField: 1, s.contentHashNameField: 1})
+}
+
+// contentHash - хэш хранимых данных, текст и двоичные данные хэшируются одинаково
+func contentHash(data string, binaryData []byte) (hash string) {
+	sum := sha256.New()
+	sum.Write([]byte(data))
+	sum.Write(binaryData)
+	return hex.EncodeToString(sum.Sum(nil))
+}
+
+// siteDataContent возвращает данные для хранения: данные в base64 декодируются и сохраняются как BSON binary
+func siteDataContent(siteData *api.SiteData) (data string, binaryData []byte) {
+	if siteData.Encoding != api.SiteDataEncodingBase64 {
+		return siteData.Data, nil
+	}
+
+	binaryData, err := base64.StdEncoding.DecodeString(siteData.Data)
+	if err != nil {
+		// Не должно случаться: данные кодирует сам сервис
+		return siteData.Data, nil
+	}
+	return "", binaryData
+}
+
+// siteDataMeta переводит метаданные ответа в формат хранения
+func siteDataMeta(meta *api.SiteDataMeta) (storedMeta *models.SiteDataMeta) {
+	if meta == nil {
+		return
+	}
+
+	return &models.SiteDataMeta{
+		StatusCode:     meta.StatusCode,
+		Headers:        meta.Headers,
+		ContentType:    meta.ContentType,
+		ContentLength:  meta.ContentLength,
+		Charset:        meta.Charset,
+		FetchDuration:  meta.FetchDurationMs,
+		FetchedAt:      meta.FetchedAt.UnixNano() / int64(time.Millisecond),
+		Redirects:      siteDataRedirects(meta.Redirects),
+		FinalURL:       meta.FinalURL,
+		CacheTTL:       meta.CacheTTLMs,
+		MustRevalidate: meta.MustRevalidate,
+		Vary:           meta.Vary,
+	}
+}
+
+// siteDataRedirects переводит цепочку редиректов в формат хранения
+func siteDataRedirects(redirects []*api.SiteDataRedirect) (storedRedirects []*models.SiteDataRedirect) {
+	if len(redirects) == 0 {
+		return
+	}
+
+	storedRedirects = make([]*models.SiteDataRedirect, len(redirects))
+	for i := range redirects {
+		storedRedirects[i] = &models.SiteDataRedirect{URL: redirects[i].URL, StatusCode: redirects[i].StatusCode}
+	}
+
+	return
+}
+
+// SitesDataIndexes индексы для замены последних записей по ключу и выборки по урлу и по ключу конечного урла.
+// Ключ уникален, чтобы одновременные upsert не создали две записи; записи прежних версий сервиса без ключа
+// в индекс не попадают
+func (s *sitesDataMongoObjects) SitesDataIndexes() (indexes []mongo.IndexModel) {
+	return []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: s.keyNameField, Value: 1}},
+			Options: options.Index().
+				SetName(s.keyNameField + "_unique").
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{s.keyNameField: bson.M{"$exists": true}}),
+		},
+		{Keys: bson.D{{Key: s.urlsNameField, Value: 1}, {Key: s.createDateNameField, Value: -1}}},
+		{Keys: bson.D{{Key: s.finalKeyNameField, Value: 1}, {Key: s.createDateNameField, Value: -1}}},
+	}
+}
+
+// SitesDataHistoryIndexes индексы для выборки истории урла по времени
+func (s *sitesDataMongoObjects) SitesDataHistoryIndexes() (indexes []mongo.IndexModel) {
+	return []mongo.IndexModel{
+		{Keys: bson.D{{Key: s.urlsNameField, Value: 1}, {Key: s.createDateNameField, Value: -1}}},
+	}
+}
+
+// SnapshotsFilter выбирает историю урла за период [from, to], нулевые границы не ограничивают период.
+// Курсор - дата создания и идентификатор последнего снимка предыдущей страницы, пустой для первой страницы
+func (s *sitesDataMongoObjects) SnapshotsFilter(
+	url string,
+	from int64,
+	to int64,
+	cursorCreateDate int64,
+	cursorID string,
+) (filter bson.M, err error) {
+	filter = bson.M{s.urlsNameField: url}
+	createDate := bson.M{}
+	if from > 0 {
+		createDate["$gte"] = from
+	}
+	if to > 0 {
+		createDate["$lte"] = to
+	}
+	if len(createDate) > 0 {
+		filter[s.createDateNameField] = createDate
+	}
+	if cursorID == "" {
+		return
 	}
 
+	id, err := primitive.ObjectIDFromHex(cursorID)
+	if err != nil {
+		return nil, err
+	}
+	// Страницы идут от новых снимков к старым, при равной дате - по убыванию идентификатора
+	filter["$or"] = bson.A{
+		bson.M{s.createDateNameField: bson.M{"$lt": cursorCreateDate}},
+		bson.M{s.createDateNameField: cursorCreateDate, "_id": bson.M{"$lt": id}},
+	}
 	return
 }
 
-func (s *sitesDataMongoObjects) SortOptions(fieldName string, sortType int) (findOptions *options.FindOptions) {
-	return options.Find().SetSort(bson.D{{Key: fieldName, Value: sortType}})
+// SnapshotsPageOptions сортирует снимки от новых к старым без данных. Запрашивается на один снимок больше
+// страницы, чтобы узнать, есть ли следующая
+func (s *sitesDataMongoObjects) SnapshotsPageOptions(limit int) (findOptions *options.FindOptions) {
+	return options.Find().
+		SetSort(bson.D{{Key: s.createDateNameField, Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(limit) + 1).
+		SetProjection(bson.M{s.dataNameFiled: 0, s.binaryDataNameField: 0})
+}
+
+func (s *sitesDataMongoObjects) SnapshotFilter(id string) (filter bson.M, err error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	return bson.M{"_id": objectID}, nil
+}
+
+// SnapshotAtFilter выбирает снимки урла, сохраненные не позже at
+func (s *sitesDataMongoObjects) SnapshotAtFilter(url string, at int64) (filter bson.M) {
+	return bson.M{s.urlsNameField: url, s.createDateNameField: bson.M{"$lte": at}}
+}
+
+// SnapshotAtOptions выбирает самый новый из снимков
+func (s *sitesDataMongoObjects) SnapshotAtOptions() (findOptions *options.FindOneOptions) {
+	return options.FindOne().SetSort(bson.D{{Key: s.createDateNameField, Value: -1}, {Key: "_id", Value: -1}})
 }
 
 // NewSitesDataMongoObjects ...
 func NewSitesDataMongoObjects(
 	createDateNameField string,
 	urlsNameField string,
+	keyNameField string,
+	finalKeyNameField string,
 	dataNameFiled string,
+	binaryDataNameField string,
+	metaNameField string,
+	etagNameField string,
+	lastModifiedNameField string,
+	varyKeyNameField string,
+	contentHashNameField string,
 ) SitesDataMongoObjects {
 	return &sitesDataMongoObjects{
-		createDateNameField: createDateNameField,
-		urlsNameField:       urlsNameField,
-		dataNameFiled:       dataNameFiled,
+		createDateNameField:   createDateNameField,
+		urlsNameField:         urlsNameField,
+		keyNameField:          keyNameField,
+		finalKeyNameField:     finalKeyNameField,
+		dataNameFiled:         dataNameFiled,
+		binaryDataNameField:   binaryDataNameField,
+		metaNameField:         metaNameField,
+		etagNameField:         etagNameField,
+		lastModifiedNameField: lastModifiedNameField,
+		varyKeyNameField:      varyKeyNameField,
+		contentHashNameField:  contentHashNameField,
 	}
 }
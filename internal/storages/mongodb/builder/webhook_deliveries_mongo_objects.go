@@ -0,0 +1,83 @@
+package builder
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/mts-test-task/internal/storages/mongodb/models"
+	"github.com/mts-test-task/pkg/sitesdataservice/api"
+)
+
+// Название полей доставок вебхуков в mongodb, должны совпадать с тегами models.WebhookDelivery
+const (
+	webhookDeliveryIDNameField         = "_id"
+	webhookDeliveryStatusNameField     = "status"
+	webhookDeliveryAttemptsNameField   = "attempts"
+	webhookDeliveryLastErrorNameField  = "last_error"
+	webhookDeliveryCreateDateNameField = "create_date"
+	webhookDeliveryUpdateDateNameField = "update_date"
+)
+
+// WebhookDeliveriesMongoObjects build necessary objects for requests to webhook deliveries collection in mongodb
+type WebhookDeliveriesMongoObjects interface {
+	FailedDelivery(id string, url string, payload []byte, attempts int, lastError string, createTime int64) (delivery *models.WebhookDelivery)
+	DeliveryFilter(id string) (filter bson.M)
+	FailedDeliveriesFilter() (filter bson.M)
+	DeliveriesPageOptions(offset int, limit int) (findOptions *options.FindOptions)
+	DeliveryAttemptUpdate(status string, attempts int, lastError string, updateTime int64) (update bson.M)
+}
+
+type webhookDeliveriesMongoObjects struct{}
+
+func (w *webhookDeliveriesMongoObjects) FailedDelivery(
+	id string,
+	url string,
+	payload []byte,
+	attempts int,
+	lastError string,
+	createTime int64,
+) (delivery *models.WebhookDelivery) {
+	return &models.WebhookDelivery{
+		ID:         id,
+		URL:        url,
+		Status:     api.WebhookDeliveryStatusFailed,
+		Attempts:   attempts,
+		LastError:  lastError,
+		Payload:    string(payload),
+		CreateDate: createTime,
+		UpdateDate: createTime,
+	}
+}
+
+func (w *webhookDeliveriesMongoObjects) DeliveryFilter(id string) (filter bson.M) {
+	return bson.M{webhookDeliveryIDNameField: id}
+}
+
+func (w *webhookDeliveriesMongoObjects) FailedDeliveriesFilter() (filter bson.M) {
+	return bson.M{webhookDeliveryStatusNameField: api.WebhookDeliveryStatusFailed}
+}
+
+func (w *webhookDeliveriesMongoObjects) DeliveriesPageOptions(offset int, limit int) (findOptions *options.FindOptions) {
+	return options.Find().
+		SetSort(bson.D{{Key: webhookDeliveryCreateDateNameField, Value: 1}}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit))
+}
+
+func (w *webhookDeliveriesMongoObjects) DeliveryAttemptUpdate(status string, attempts int, lastError string, updateTime int64) (update bson.M) {
+	return bson.M{
+		"$set": bson.M{
+			webhookDeliveryStatusNameField:     status,
+			webhookDeliveryLastErrorNameField:  lastError,
+			webhookDeliveryUpdateDateNameField: updateTime,
+		},
+		"$inc": bson.M{
+			webhookDeliveryAttemptsNameField: attempts,
+		},
+	}
+}
+
+// NewWebhookDeliveriesMongoObjects ...
+func NewWebhookDeliveriesMongoObjects() WebhookDeliveriesMongoObjects {
+	return &webhookDeliveriesMongoObjects{}
+}
@@ -0,0 +1,44 @@
+package models
+
+// Job is a struct to save batch job in mongo
+type Job struct {
+	ID          string      `bson:"_id"`
+	Status      string      `bson:"status"`
+	URLs        []string    `bson:"urls,omitempty"`
+	Options     *JobOptions `bson:"options,omitempty"`
+	Total       int         `bson:"total"`
+	Processed   int         `bson:"processed"`
+	Failed      int         `bson:"failed"`
+	Error       string      `bson:"error,omitempty"`
+	CallbackURL string      `bson:"callback_url,omitempty"`
+	CreateDate  int64       `bson:"create_date"`
+	UpdateDate  int64       `bson:"update_date"`
+}
+
+// JobOptions is a struct to save options of batch job in mongo
+type JobOptions struct {
+	FailureMode    string            `bson:"failure_mode"`
+	TimeoutMs      int64             `bson:"timeout_ms,omitempty"`
+	MaxCacheAgeMs  int64             `bson:"max_cache_age_ms,omitempty"`
+	RedirectPolicy string            `bson:"redirect_policy,omitempty"`
+	MaxRedirects   int               `bson:"max_redirects,omitempty"`
+	MaxBodySize    int64             `bson:"max_body_size,omitempty"`
+	BodyLimitMode  string            `bson:"body_limit_mode,omitempty"`
+	RawBody        bool              `bson:"raw_body,omitempty"`
+	BinaryContent  string            `bson:"binary_content,omitempty"`
+	Headers        map[string]string `bson:"headers,omitempty"`
+}
+
+// JobResult is a struct to save result of one url of batch job in mongo
+type JobResult struct {
+	ID           string        `bson:"_id"`
+	JobID        string        `bson:"job_id"`
+	Index        int           `bson:"index"`
+	URL          string        `bson:"url"`
+	Data         string        `bson:"data"`
+	BinaryData   []byte        `bson:"binary_data,omitempty"`
+	Status       string        `bson:"status"`
+	ErrorCode    int           `bson:"error_code,omitempty"`
+	ErrorMessage string        `bson:"error_message,omitempty"`
+	Meta         *SiteDataMeta `bson:"meta,omitempty"`
+}
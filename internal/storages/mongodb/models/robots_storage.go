@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// Robots is a struct to cache robots.txt of a host in mongo
+type Robots struct {
+	ID         string    `bson:"_id"` // схема и хост, например https://example.com
+	StatusCode int       `bson:"status_code"`
+	Body       string    `bson:"body"`
+	CreateDate int64     `bson:"create_date"`
+	ExpireAt   time.Time `bson:"expire_at"` // TTL индекс работает только с датами
+}
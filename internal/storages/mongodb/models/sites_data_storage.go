@@ -2,8 +2,42 @@ package models
 
 // SiteData is a struct to save data in mongo
 type SiteData struct {
-	ID         string `bson:"_id"`
+	ID         string        `bson:"_id"`
+	URL        string        `bson:"url"`
+	Key        string        `bson:"key,omitempty"`
+	FinalKey   string        `bson:"final_key,omitempty"` // ключ запроса к конечному урлу после редиректов
+	Data       string        `bson:"data"`
+	BinaryData []byte        `bson:"binary_data,omitempty"` // двоичные данные хранятся как BSON binary
+	CreateDate int           `bson:"create_date"`
+	Meta       *SiteDataMeta `bson:"meta,omitempty"`
+	// Валидаторы ответа сайта для условных запросов
+	ETag         string `bson:"etag,omitempty"`
+	LastModified string `bson:"last_modified,omitempty"`
+	// Хэш значений заголовков запроса из Vary ответа
+	VaryKey string `bson:"vary_key,omitempty"`
+	// Хэш данных, по нему определяется, изменилось ли содержимое
+	ContentHash string `bson:"content_hash,omitempty"`
+}
+
+// SiteDataMeta is a struct to save metadata of site response in mongo
+type SiteDataMeta struct {
+	StatusCode    int                 `bson:"status_code"`
+	Headers       map[string]string   `bson:"headers,omitempty"`
+	ContentType   string              `bson:"content_type"`
+	ContentLength int64               `bson:"content_length"`
+	Charset       string              `bson:"charset,omitempty"`
+	FetchDuration int64               `bson:"fetch_duration"` // миллисекунды
+	FetchedAt     int64               `bson:"fetched_at"`     // unix время в миллисекундах
+	Redirects     []*SiteDataRedirect `bson:"redirects,omitempty"`
+	FinalURL      string              `bson:"final_url,omitempty"`
+	CacheTTL      *int64              `bson:"cache_ttl,omitempty"` // миллисекунды, нет без заголовков кэширования
+	// Сайт запретил отдавать устаревшие данные без проверки (no-cache, must-revalidate)
+	MustRevalidate bool     `bson:"must_revalidate,omitempty"`
+	Vary           []string `bson:"vary,omitempty"`
+}
+
+// SiteDataRedirect is a struct to save one hop of redirect chain in mongo
+type SiteDataRedirect struct {
 	URL        string `bson:"url"`
-	Data       string `bson:"data"`
-	CreateDate int    `bson:"create_date"`
+	StatusCode int    `bson:"status_code"`
 }
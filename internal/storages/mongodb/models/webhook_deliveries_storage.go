@@ -0,0 +1,13 @@
+package models
+
+// WebhookDelivery is a struct to save failed webhook delivery in mongo
+type WebhookDelivery struct {
+	ID         string `bson:"_id"`
+	URL        string `bson:"url"`
+	Status     string `bson:"status"`
+	Attempts   int    `bson:"attempts"`
+	LastError  string `bson:"last_error"`
+	Payload    string `bson:"payload"` // тело запроса, которое подписывается при каждой отправке
+	CreateDate int64  `bson:"create_date"`
+	UpdateDate int64  `bson:"update_date"`
+}
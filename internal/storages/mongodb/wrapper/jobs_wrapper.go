@@ -0,0 +1,169 @@
+package wrapper
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/mts-test-task/internal/storages/mongodb/models"
+)
+
+const (
+	errorInsertOne        = "InsertOne() err: %s"
+	errorFindOne          = "FindOne() err: %s"
+	errorFindOneAndUpdate = "FindOneAndUpdate() err: %s"
+	errorBulkWrite        = "BulkWrite() err: %s"
+	errorCreateIndexes    = "CreateMany() err: %s"
+)
+
+// JobsWrapper ...
+type JobsWrapper interface {
+	AddJob(ctx context.Context, job interface{}) (err error)
+	GetJob(ctx context.Context, filter bson.M, findOptions *options.FindOneOptions) (job *models.Job, err error)
+	GetJobs(ctx context.Context, filter bson.M, findOptions *options.FindOptions) (jobs []*models.Job, err error)
+	UpdateJob(ctx context.Context, filter bson.M, update bson.M, updateOptions *options.FindOneAndUpdateOptions) (job *models.Job, err error)
+	UpsertJobResults(ctx context.Context, results []mongo.WriteModel) (err error)
+	GetJobResults(ctx context.Context, filter bson.M, findOptions *options.FindOptions) (results []*models.JobResult, err error)
+	CreateJobResultsIndexes(ctx context.Context, indexes []mongo.IndexModel) (err error)
+}
+
+type jobsWrapper struct {
+	database                 *mongo.Database
+	jobsCollectionName       string
+	jobResultsCollectionName string
+	timeout                  time.Duration
+}
+
+func (j *jobsWrapper) AddJob(ctx context.Context, job interface{}) (err error) {
+	ctxTimeOut, cancel := context.WithTimeout(ctx, j.timeout)
+	defer cancel()
+
+	_, err = j.database.Collection(j.jobsCollectionName).InsertOne(ctxTimeOut, job)
+	if err != nil {
+		err = fmt.Errorf(errorInsertOne, err)
+	}
+
+	return
+}
+
+// GetJob returns nil job without error if there is no job matching the filter
+func (j *jobsWrapper) GetJob(ctx context.Context, filter bson.M, findOptions *options.FindOneOptions) (job *models.Job, err error) {
+	ctxTimeOut, cancel := context.WithTimeout(ctx, j.timeout)
+	defer cancel()
+
+	job = new(models.Job)
+	err = j.database.Collection(j.jobsCollectionName).FindOne(ctxTimeOut, filter, findOptions).Decode(job)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf(errorFindOne, err)
+	}
+
+	return
+}
+
+func (j *jobsWrapper) GetJobs(ctx context.Context, filter bson.M, findOptions *options.FindOptions) (jobs []*models.Job, err error) {
+	jobs = make([]*models.Job, 0)
+
+	ctxTimeOut, cancel := context.WithTimeout(ctx, j.timeout)
+	defer cancel()
+
+	res, err := j.database.Collection(j.jobsCollectionName).Find(ctxTimeOut, filter, findOptions)
+	if err != nil {
+		err = fmt.Errorf(errorFind, err)
+		return
+	}
+
+	if err = res.All(ctxTimeOut, &jobs); err != nil {
+		err = fmt.Errorf(errorDecode, err)
+		return
+	}
+
+	return
+}
+
+// UpdateJob returns nil job without error if there is no job matching the filter
+func (j *jobsWrapper) UpdateJob(
+	ctx context.Context,
+	filter bson.M,
+	update bson.M,
+	updateOptions *options.FindOneAndUpdateOptions,
+) (job *models.Job, err error) {
+	ctxTimeOut, cancel := context.WithTimeout(ctx, j.timeout)
+	defer cancel()
+
+	job = new(models.Job)
+	err = j.database.Collection(j.jobsCollectionName).FindOneAndUpdate(ctxTimeOut, filter, update, updateOptions).Decode(job)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf(errorFindOneAndUpdate, err)
+	}
+
+	return
+}
+
+func (j *jobsWrapper) UpsertJobResults(ctx context.Context, results []mongo.WriteModel) (err error) {
+	ctxTimeOut, cancel := context.WithTimeout(ctx, j.timeout)
+	defer cancel()
+
+	_, err = j.database.Collection(j.jobResultsCollectionName).BulkWrite(ctxTimeOut, results)
+	if err != nil {
+		err = fmt.Errorf(errorBulkWrite, err)
+	}
+
+	return
+}
+
+func (j *jobsWrapper) GetJobResults(ctx context.Context, filter bson.M, findOptions *options.FindOptions) (results []*models.JobResult, err error) {
+	results = make([]*models.JobResult, 0)
+
+	ctxTimeOut, cancel := context.WithTimeout(ctx, j.timeout)
+	defer cancel()
+
+	res, err := j.database.Collection(j.jobResultsCollectionName).Find(ctxTimeOut, filter, findOptions)
+	if err != nil {
+		err = fmt.Errorf(errorFind, err)
+		return
+	}
+
+	if err = res.All(ctxTimeOut, &results); err != nil {
+		err = fmt.Errorf(errorDecode, err)
+		return
+	}
+
+	return
+}
+
+func (j *jobsWrapper) CreateJobResultsIndexes(ctx context.Context, indexes []mongo.IndexModel) (err error) {
+	ctxTimeOut, cancel := context.WithTimeout(ctx, j.timeout)
+	defer cancel()
+
+	_, err = j.database.Collection(j.jobResultsCollectionName).Indexes().CreateMany(ctxTimeOut, indexes)
+	if err != nil {
+		err = fmt.Errorf(errorCreateIndexes, err)
+	}
+
+	return
+}
+
+// NewJobsWrapper ...
+func NewJobsWrapper(
+	database *mongo.Database,
+	jobsCollectionName string,
+	jobResultsCollectionName string,
+	timeout time.Duration,
+) JobsWrapper {
+	return &jobsWrapper{
+		database:                 database,
+		jobsCollectionName:       jobsCollectionName,
+		jobResultsCollectionName: jobResultsCollectionName,
+		timeout:                  timeout,
+	}
+}
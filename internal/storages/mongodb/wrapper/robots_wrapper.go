@@ -0,0 +1,87 @@
+package wrapper
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/mts-test-task/internal/storages/mongodb/models"
+)
+
+const errorReplaceOne = "ReplaceOne() err: %s"
+
+// RobotsWrapper ...
+type RobotsWrapper interface {
+	GetRobots(ctx context.Context, filter bson.M) (robots *models.Robots, err error)
+	UpsertRobots(ctx context.Context, filter bson.M, robots interface{}, replaceOptions *options.ReplaceOptions) (err error)
+	CreateRobotsIndexes(ctx context.Context, indexes []mongo.IndexModel) (err error)
+}
+
+type robotsWrapper struct {
+	database       *mongo.Database
+	collectionName string
+	timeout        time.Duration
+}
+
+// GetRobots returns nil robots without error if there is no document matching the filter
+func (r *robotsWrapper) GetRobots(ctx context.Context, filter bson.M) (robots *models.Robots, err error) {
+	ctxTimeOut, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	robots = new(models.Robots)
+	err = r.database.Collection(r.collectionName).FindOne(ctxTimeOut, filter).Decode(robots)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf(errorFindOne, err)
+	}
+
+	return
+}
+
+func (r *robotsWrapper) UpsertRobots(
+	ctx context.Context,
+	filter bson.M,
+	robots interface{},
+	replaceOptions *options.ReplaceOptions,
+) (err error) {
+	ctxTimeOut, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err = r.database.Collection(r.collectionName).ReplaceOne(ctxTimeOut, filter, robots, replaceOptions)
+	if err != nil {
+		err = fmt.Errorf(errorReplaceOne, err)
+	}
+
+	return
+}
+
+func (r *robotsWrapper) CreateRobotsIndexes(ctx context.Context, indexes []mongo.IndexModel) (err error) {
+	ctxTimeOut, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err = r.database.Collection(r.collectionName).Indexes().CreateMany(ctxTimeOut, indexes)
+	if err != nil {
+		err = fmt.Errorf(errorCreateIndexes, err)
+	}
+
+	return
+}
+
+// NewRobotsWrapper ...
+func NewRobotsWrapper(
+	database *mongo.Database,
+	collectionName string,
+	timeout time.Duration,
+) RobotsWrapper {
+	return &robotsWrapper{
+		database:       database,
+		collectionName: collectionName,
+		timeout:        timeout,
+	}
+}
@@ -13,32 +13,41 @@ import (
 )
 
 const (
-	errorFind   = "Find() err: %s"
-	errorDecode = "Decode() err: %s"
-	errorInsert = "InsertMany() err: %s"
+	errorFind      = "Find() err: %s"
+	errorAggregate = "Aggregate() err: %s"
+	errorDecode    = "Decode() err: %s"
+	errorInsert    = "InsertMany() err: %s"
 )
 
-// SitesDataWrapper ...
+// SitesDataWrapper works with the collection of latest site data, one document per cache key,
+// and the append-only collection of its history
 type SitesDataWrapper interface {
-	GetSitesData(ctx context.Context, filter bson.M, sortOptions *options.FindOptions) (sitesData []*models.SiteData, err error)
-	AddSitesData(ctx context.Context, data []interface{}) (err error)
+	GetSitesData(ctx context.Context, pipeline mongo.Pipeline) (sitesData []*models.SiteData, err error)
+	UpsertSitesData(ctx context.Context, latest []mongo.WriteModel) (err error)
+	FindSitesData(ctx context.Context, filter bson.M, findOptions *options.FindOptions) (sitesData []*models.SiteData, err error)
+	AddSitesDataHistory(ctx context.Context, data []interface{}) (err error)
+	CreateSitesDataIndexes(ctx context.Context, indexes []mongo.IndexModel) (err error)
+	CreateSitesDataHistoryIndexes(ctx context.Context, indexes []mongo.IndexModel) (err error)
+	GetSnapshots(ctx context.Context, filter bson.M, findOptions *options.FindOptions) (snapshots []*models.SiteData, err error)
+	GetSnapshot(ctx context.Context, filter bson.M, findOptions *options.FindOneOptions) (snapshot *models.SiteData, err error)
 }
 
 type sitesDataWrapper struct {
-	database       *mongo.Database
-	collectionName string
-	timeout        time.Duration
+	database              *mongo.Database
+	collectionName        string
+	historyCollectionName string
+	timeout               time.Duration
 }
 
-func (s *sitesDataWrapper) GetSitesData(ctx context.Context, filter bson.M, sortOptions *options.FindOptions) (sitesData []*models.SiteData, err error) {
+func (s *sitesDataWrapper) GetSitesData(ctx context.Context, pipeline mongo.Pipeline) (sitesData []*models.SiteData, err error) {
 	sitesData = make([]*models.SiteData, 0)
 
 	ctxTimeOut, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
-	res, err := s.database.Collection(s.collectionName).Find(ctxTimeOut, filter, sortOptions)
+	res, err := s.database.Collection(s.collectionName).Aggregate(ctxTimeOut, pipeline)
 	if err != nil {
-		err = fmt.Errorf(errorFind, err)
+		err = fmt.Errorf(errorAggregate, err)
 		return
 	}
 
@@ -50,11 +59,44 @@ func (s *sitesDataWrapper) GetSitesData(ctx context.Context, filter bson.M, sort
 	return
 }
 
-func (s *sitesDataWrapper) AddSitesData(ctx context.Context, data []interface{}) (err error) {
+func (s *sitesDataWrapper) UpsertSitesData(ctx context.Context, latest []mongo.WriteModel) (err error) {
+	ctxTimeOut, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	// Записи независимы, ошибка в одной не должна мешать остальным
+	_, err = s.database.Collection(s.collectionName).BulkWrite(ctxTimeOut, latest, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		err = fmt.Errorf(errorBulkWrite, err)
+	}
+
+	return
+}
+
+func (s *sitesDataWrapper) FindSitesData(ctx context.Context, filter bson.M, findOptions *options.FindOptions) (sitesData []*models.SiteData, err error) {
+	sitesData = make([]*models.SiteData, 0)
+
+	ctxTimeOut, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	res, err := s.database.Collection(s.collectionName).Find(ctxTimeOut, filter, findOptions)
+	if err != nil {
+		err = fmt.Errorf(errorFind, err)
+		return
+	}
+
+	if err = res.All(ctxTimeOut, &sitesData); err != nil {
+		err = fmt.Errorf(errorDecode, err)
+		return
+	}
+
+	return
+}
+
+func (s *sitesDataWrapper) AddSitesDataHistory(ctx context.Context, data []interface{}) (err error) {
 	ctxTimeOut, cancel := context.WithTimeout(context.Background(), s.timeout)
 	defer cancel()
 
-	_, err = s.database.Collection(s.collectionName).InsertMany(ctxTimeOut, data)
+	_, err = s.database.Collection(s.historyCollectionName).InsertMany(ctxTimeOut, data)
 	if err != nil {
 		err = fmt.Errorf(errorInsert, err)
 	}
@@ -62,15 +104,82 @@ func (s *sitesDataWrapper) AddSitesData(ctx context.Context, data []interface{})
 	return
 }
 
+func (s *sitesDataWrapper) CreateSitesDataIndexes(ctx context.Context, indexes []mongo.IndexModel) (err error) {
+	ctxTimeOut, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	_, err = s.database.Collection(s.collectionName).Indexes().CreateMany(ctxTimeOut, indexes)
+	if err != nil {
+		err = fmt.Errorf(errorCreateIndexes, err)
+	}
+
+	return
+}
+
+func (s *sitesDataWrapper) CreateSitesDataHistoryIndexes(ctx context.Context, indexes []mongo.IndexModel) (err error) {
+	ctxTimeOut, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	_, err = s.database.Collection(s.historyCollectionName).Indexes().CreateMany(ctxTimeOut, indexes)
+	if err != nil {
+		err = fmt.Errorf(errorCreateIndexes, err)
+	}
+
+	return
+}
+
+func (s *sitesDataWrapper) GetSnapshots(ctx context.Context, filter bson.M, findOptions *options.FindOptions) (snapshots []*models.SiteData, err error) {
+	snapshots = make([]*models.SiteData, 0)
+
+	ctxTimeOut, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	res, err := s.database.Collection(s.historyCollectionName).Find(ctxTimeOut, filter, findOptions)
+	if err != nil {
+		err = fmt.Errorf(errorFind, err)
+		return
+	}
+
+	if err = res.All(ctxTimeOut, &snapshots); err != nil {
+		err = fmt.Errorf(errorDecode, err)
+		return
+	}
+
+	return
+}
+
+// GetSnapshot returns nil snapshot without error if there is no document matching the filter
+func (s *sitesDataWrapper) GetSnapshot(
+	ctx context.Context,
+	filter bson.M,
+	findOptions *options.FindOneOptions,
+) (snapshot *models.SiteData, err error) {
+	ctxTimeOut, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	snapshot = new(models.SiteData)
+	err = s.database.Collection(s.historyCollectionName).FindOne(ctxTimeOut, filter, findOptions).Decode(snapshot)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf(errorFindOne, err)
+	}
+
+	return
+}
+
 // NewSitesDataWrapper ...
 func NewSitesDataWrapper(
 	database *mongo.Database,
 	collectionName string,
+	historyCollectionName string,
 	timeout time.Duration,
 ) SitesDataWrapper {
 	return &sitesDataWrapper{
-		database:       database,
-		collectionName: collectionName,
-		timeout:        timeout,
+		database:              database,
+		collectionName:        collectionName,
+		historyCollectionName: historyCollectionName,
+		timeout:               timeout,
 	}
 }
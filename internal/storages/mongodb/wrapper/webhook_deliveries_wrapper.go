@@ -0,0 +1,112 @@
+package wrapper
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/mts-test-task/internal/storages/mongodb/models"
+)
+
+// WebhookDeliveriesWrapper ...
+type WebhookDeliveriesWrapper interface {
+	AddDelivery(ctx context.Context, delivery interface{}) (err error)
+	GetDelivery(ctx context.Context, filter bson.M) (delivery *models.WebhookDelivery, err error)
+	GetDeliveries(ctx context.Context, filter bson.M, findOptions *options.FindOptions) (deliveries []*models.WebhookDelivery, err error)
+	UpdateDelivery(ctx context.Context, filter bson.M, update bson.M) (delivery *models.WebhookDelivery, err error)
+}
+
+type webhookDeliveriesWrapper struct {
+	database       *mongo.Database
+	collectionName string
+	timeout        time.Duration
+}
+
+func (w *webhookDeliveriesWrapper) AddDelivery(ctx context.Context, delivery interface{}) (err error) {
+	ctxTimeOut, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+
+	_, err = w.database.Collection(w.collectionName).InsertOne(ctxTimeOut, delivery)
+	if err != nil {
+		err = fmt.Errorf(errorInsertOne, err)
+	}
+
+	return
+}
+
+// GetDelivery returns nil delivery without error if there is no delivery matching the filter
+func (w *webhookDeliveriesWrapper) GetDelivery(ctx context.Context, filter bson.M) (delivery *models.WebhookDelivery, err error) {
+	ctxTimeOut, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+
+	delivery = new(models.WebhookDelivery)
+	err = w.database.Collection(w.collectionName).FindOne(ctxTimeOut, filter).Decode(delivery)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf(errorFindOne, err)
+	}
+
+	return
+}
+
+func (w *webhookDeliveriesWrapper) GetDeliveries(
+	ctx context.Context,
+	filter bson.M,
+	findOptions *options.FindOptions,
+) (deliveries []*models.WebhookDelivery, err error) {
+	deliveries = make([]*models.WebhookDelivery, 0)
+
+	ctxTimeOut, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+
+	res, err := w.database.Collection(w.collectionName).Find(ctxTimeOut, filter, findOptions)
+	if err != nil {
+		err = fmt.Errorf(errorFind, err)
+		return
+	}
+
+	if err = res.All(ctxTimeOut, &deliveries); err != nil {
+		err = fmt.Errorf(errorDecode, err)
+		return
+	}
+
+	return
+}
+
+// UpdateDelivery returns nil delivery without error if there is no delivery matching the filter
+func (w *webhookDeliveriesWrapper) UpdateDelivery(ctx context.Context, filter bson.M, update bson.M) (delivery *models.WebhookDelivery, err error) {
+	ctxTimeOut, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+
+	delivery = new(models.WebhookDelivery)
+	err = w.database.Collection(w.collectionName).
+		FindOneAndUpdate(ctxTimeOut, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).
+		Decode(delivery)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf(errorFindOneAndUpdate, err)
+	}
+
+	return
+}
+
+// NewWebhookDeliveriesWrapper ...
+func NewWebhookDeliveriesWrapper(
+	database *mongo.Database,
+	collectionName string,
+	timeout time.Duration,
+) WebhookDeliveriesWrapper {
+	return &webhookDeliveriesWrapper{
+		database:       database,
+		collectionName: collectionName,
+		timeout:        timeout,
+	}
+}
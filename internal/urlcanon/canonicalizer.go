@@ -0,0 +1,173 @@
+package urlcanon
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Порты по умолчанию, которые убираются из хоста
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// Canonicalizer makes equal strings of urls pointing to the same resource
+type Canonicalizer interface {
+	// Canonicalize returns url unchanged if it can not be parsed
+	Canonicalize(rawURL string) (canonical string)
+}
+
+type canonicalizer struct {
+	// trackingParams - точные имена параметров, trackingPrefixes - префиксы из шаблонов вида utm_*
+	trackingParams   map[string]bool
+	trackingPrefixes []string
+}
+
+func (c *canonicalizer) Canonicalize(rawURL string) (canonical string) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Opaque != "" {
+		return rawURL
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	// Hostname возвращает IPv6 без скобок
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port := u.Port(); port != "" && port != defaultPorts[scheme] {
+		host += ":" + port
+	}
+
+	path := normalizeEscapes(u.EscapedPath())
+	// Путь без слэша в конце и корень без пути считаются одним ресурсом
+	if path == "" {
+		path = "/"
+	}
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+
+	var b strings.Builder
+	b.WriteString(scheme)
+	b.WriteString("://")
+	if u.User != nil {
+		b.WriteString(u.User.String())
+		b.WriteByte('@')
+	}
+	b.WriteString(host)
+	b.WriteString(path)
+	// Фрагмент на сервер не передается и в каноническую форму не входит
+	if query := c.query(u.RawQuery); query != "" {
+		b.WriteByte('?')
+		b.WriteString(query)
+	}
+
+	return b.String()
+}
+
+// query убирает параметры отслеживания и сортирует остальные по имени, а затем по значению
+func (c *canonicalizer) query(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+
+	params := make([]string, 0, strings.Count(rawQuery, "&")+1)
+	for _, param := range strings.Split(rawQuery, "&") {
+		if param == "" {
+			continue
+		}
+		param = normalizeEscapes(param)
+		name := param
+		if i := strings.IndexByte(param, '='); i >= 0 {
+			name = param[:i]
+		}
+		if c.isTracking(name) {
+			continue
+		}
+		params = append(params, param)
+	}
+	sort.Strings(params)
+
+	return strings.Join(params, "&")
+}
+
+func (c *canonicalizer) isTracking(name string) bool {
+	name, err := url.QueryUnescape(name)
+	if err != nil {
+		return false
+	}
+	name = strings.ToLower(name)
+	if c.trackingParams[name] {
+		return true
+	}
+	for _, prefix := range c.trackingPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// normalizeEscapes декодирует незарезервированные символы и приводит остальные коды к верхнему регистру
+func normalizeEscapes(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' || i+2 >= len(s) || !isHex(s[i+1]) || !isHex(s[i+2]) {
+			b.WriteByte(s[i])
+			continue
+		}
+		c := unhex(s[i+1])<<4 | unhex(s[i+2])
+		if isUnreserved(c) {
+			b.WriteByte(c)
+		} else {
+			b.WriteByte('%')
+			b.WriteString(strings.ToUpper(s[i+1 : i+3]))
+		}
+		i += 2
+	}
+
+	return b.String()
+}
+
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
+
+// NewCanonicalizer ...
+func NewCanonicalizer(trackingParams []string) Canonicalizer {
+	c := &canonicalizer{trackingParams: make(map[string]bool, len(trackingParams))}
+	for _, param := range trackingParams {
+		param = strings.ToLower(param)
+		if strings.HasSuffix(param, "*") {
+			c.trackingPrefixes = append(c.trackingPrefixes, strings.TrimSuffix(param, "*"))
+			continue
+		}
+		c.trackingParams[param] = true
+	}
+
+	return c
+}
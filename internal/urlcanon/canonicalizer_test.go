@@ -0,0 +1,58 @@
+package urlcanon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanonicalizer_Canonicalize(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{name: "scheme and host case", url: "HTTP://Example.COM/Path", want: "http://example.com/Path"},
+		{name: "default http port", url: "http://example.com:80/a", want: "http://example.com/a"},
+		{name: "default https port", url: "https://example.com:443/a", want: "https://example.com/a"},
+		{name: "other port", url: "https://example.com:8443/a", want: "https://example.com:8443/a"},
+		{name: "ipv6 host", url: "http://[2001:DB8::1]:8080/", want: "http://[2001:db8::1]:8080/"},
+		{name: "empty path", url: "http://example.com", want: "http://example.com/"},
+		{name: "trailing slash", url: "http://example.com/a/", want: "http://example.com/a"},
+		{name: "fragment", url: "http://example.com/a#top", want: "http://example.com/a"},
+		{name: "sorted query", url: "http://example.com/?b=2&a=1&a=0", want: "http://example.com/?a=0&a=1&b=2"},
+		{name: "empty query params", url: "http://example.com/?&a=1&", want: "http://example.com/?a=1"},
+		{name: "tracking params", url: "http://example.com/?utm_source=x&UTM_Medium=y&fbclid=z&id=1", want: "http://example.com/?id=1"},
+		{name: "only tracking params", url: "http://example.com/a?utm_source=x", want: "http://example.com/a"},
+		{name: "unreserved escapes", url: "http://example.com/%7Euser/%61", want: "http://example.com/~user/a"},
+		{name: "reserved escapes uppercased", url: "http://example.com/a%2fb?q=a%2bb", want: "http://example.com/a%2Fb?q=a%2Bb"},
+		{name: "user info", url: "http://user@example.com/", want: "http://user@example.com/"},
+		{name: "unparsable", url: "http://[::1", want: "http://[::1"},
+		{name: "opaque", url: "mailto:user@example.com", want: "mailto:user@example.com"},
+	}
+	canonicalizer := NewCanonicalizer([]string{"utm_*", "FBCLID"})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, canonicalizer.Canonicalize(tt.url))
+		})
+	}
+}
+
+func TestNormalizeEscapes(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "plain", want: "plain"},
+		{in: "%41%42", want: "AB"},
+		{in: "%e2%82%ac", want: "%E2%82%AC"},
+		{in: "bad%zz", want: "bad%zz"},
+		{in: "end%4", want: "end%4"},
+		{in: "end%", want: "end%"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			assert.Equal(t, tt.want, normalizeEscapes(tt.in))
+		})
+	}
+}
@@ -4,21 +4,69 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strings"
+	"time"
 
+	"github.com/mts-test-task/pkg/sitesdataservice/api"
 	"github.com/mts-test-task/pkg/sitesdataservice/httperror"
 )
 
+type callbackGuard interface {
+	CheckHost(host string) (err error)
+}
+
 // Input validate input data
 type Input interface {
-	CheckURLs(urls []string) (err error)
+	CheckRequests(requests []*api.SiteRequest) (err error)
+	CheckJobURLs(urls []string) (err error)
+	CheckOptions(options *api.FetchOptions) (err error)
+	CheckCallbackURL(callbackURL string) (err error)
+	CheckSnapshotsRequest(request *api.GetSnapshotsRequest) (err error)
+	CheckSnapshotsDiffRequest(request *api.GetSnapshotsDiffRequest) (err error)
 }
 
 type input struct {
-	maxURLsCount int
-	errorCreator httperror.ErrorCreator
+	maxURLsCount     int
+	maxJobURLsCount  int
+	maxTimeout       time.Duration
+	callbacksEnabled bool
+	callbackGuard    callbackGuard
+	errorCreator     httperror.ErrorCreator
+}
+
+func (i *input) CheckRequests(requests []*api.SiteRequest) (err error) {
+	urls := make([]string, len(requests))
+	for j := 0; j < len(requests); j++ {
+		if requests[j] == nil {
+			return i.errorCreator(
+				http.StatusBadRequest,
+				"Ошибка ввода: пустой запрос",
+				fmt.Sprintf("input validation error: %s", "null request"),
+			)
+		}
+		urls[j] = requests[j].URL
+	}
+
+	err = i.checkURLs(urls, i.maxURLsCount)
+	if err != nil {
+		return
+	}
+
+	for j := 0; j < len(requests); j++ {
+		err = i.checkRequest(requests[j])
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func (i *input) CheckJobURLs(urls []string) (err error) {
+	return i.checkURLs(urls, i.maxJobURLsCount)
 }
 
-func (i *input) CheckURLs(urls []string) (err error) {
+func (i *input) checkURLs(urls []string, maxURLsCount int) (err error) {
 	if len(urls) == 0 {
 		return i.errorCreator(
 			http.StatusBadRequest,
@@ -27,7 +75,7 @@ func (i *input) CheckURLs(urls []string) (err error) {
 		)
 	}
 
-	if len(urls) > i.maxURLsCount {
+	if len(urls) > maxURLsCount {
 		return i.errorCreator(
 			http.StatusBadRequest,
 			"Ошибка ввода: слишком много урлов",
@@ -49,10 +97,256 @@ func (i *input) CheckURLs(urls []string) (err error) {
 	return
 }
 
+// checkRequest проверяет метод, заголовки и тело запроса к сайту
+func (i *input) checkRequest(request *api.SiteRequest) (err error) {
+	switch request.Method {
+	case "", http.MethodGet, http.MethodHead:
+		if request.Body != "" {
+			return i.errorCreator(
+				http.StatusBadRequest,
+				fmt.Sprintf("Ошибка ввода: тело запроса не поддерживается методом %s: %s", request.Method, request.URL),
+				fmt.Sprintf("input validation error: %s %s", "body is not allowed for url:", request.URL),
+			)
+		}
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions:
+	default:
+		return i.errorCreator(
+			http.StatusBadRequest,
+			fmt.Sprintf("Ошибка ввода: неподдерживаемый метод %s: %s", request.Method, request.URL),
+			fmt.Sprintf("input validation error: %s %s", "unsupported method:", request.Method),
+		)
+	}
+
+	return i.checkHeaders(request.Headers)
+}
+
+// checkHeaders проверяет заголовки, которые будут отправлены сайтам
+func (i *input) checkHeaders(headers map[string]string) (err error) {
+	for name, value := range headers {
+		// Такие заголовки net/http отклонит при отправке, поэтому проверяем их заранее
+		if name == "" || strings.ContainsAny(name, " \t\r\n:") || strings.ContainsAny(value, "\r\n") {
+			return i.errorCreator(
+				http.StatusBadRequest,
+				fmt.Sprintf("Ошибка ввода: неверный заголовок: %s", name),
+				fmt.Sprintf("input validation error: %s %s", "bad header:", name),
+			)
+		}
+	}
+
+	return
+}
+
+func (i *input) CheckOptions(options *api.FetchOptions) (err error) {
+	if options == nil {
+		return
+	}
+
+	switch options.FailureMode {
+	case "", api.FailureModeFailFast, api.FailureModePartial:
+	default:
+		return i.errorCreator(
+			http.StatusBadRequest,
+			fmt.Sprintf("Ошибка ввода: неизвестный режим обработки ошибок: %s", options.FailureMode),
+			fmt.Sprintf("input validation error: %s %s", "unknown failure mode:", options.FailureMode),
+		)
+	}
+
+	if options.TimeoutMs < 0 || time.Duration(options.TimeoutMs)*time.Millisecond > i.maxTimeout {
+		return i.errorCreator(
+			http.StatusBadRequest,
+			fmt.Sprintf("Ошибка ввода: таймаут должен быть от 0 до %d мс", i.maxTimeout.Milliseconds()),
+			fmt.Sprintf("input validation error: %s %d", "bad timeout:", options.TimeoutMs),
+		)
+	}
+
+	switch options.RedirectPolicy {
+	case "", api.RedirectPolicyFollow, api.RedirectPolicyNone, api.RedirectPolicySameHost:
+	default:
+		return i.errorCreator(
+			http.StatusBadRequest,
+			fmt.Sprintf("Ошибка ввода: неизвестная политика редиректов: %s", options.RedirectPolicy),
+			fmt.Sprintf("input validation error: %s %s", "unknown redirect policy:", options.RedirectPolicy),
+		)
+	}
+
+	if options.MaxRedirects < 0 {
+		return i.errorCreator(
+			http.StatusBadRequest,
+			"Ошибка ввода: отрицательное число редиректов",
+			fmt.Sprintf("input validation error: %s", "negative max redirects"),
+		)
+	}
+
+	if options.MaxBodySize < 0 {
+		return i.errorCreator(
+			http.StatusBadRequest,
+			"Ошибка ввода: отрицательный лимит размера ответа",
+			fmt.Sprintf("input validation error: %s", "negative max body size"),
+		)
+	}
+
+	switch options.BodyLimitMode {
+	case "", api.BodyLimitModeFail, api.BodyLimitModeTruncate:
+	default:
+		return i.errorCreator(
+			http.StatusBadRequest,
+			fmt.Sprintf("Ошибка ввода: неизвестный режим лимита размера ответа: %s", options.BodyLimitMode),
+			fmt.Sprintf("input validation error: %s %s", "unknown body limit mode:", options.BodyLimitMode),
+		)
+	}
+
+	switch options.BinaryContent {
+	case "", api.BinaryContentAllow, api.BinaryContentReject:
+	default:
+		return i.errorCreator(
+			http.StatusBadRequest,
+			fmt.Sprintf("Ошибка ввода: неизвестный режим двоичных данных: %s", options.BinaryContent),
+			fmt.Sprintf("input validation error: %s %s", "unknown binary content mode:", options.BinaryContent),
+		)
+	}
+
+	if options.MaxCacheAgeMs < 0 {
+		return i.errorCreator(
+			http.StatusBadRequest,
+			"Ошибка ввода: отрицательный возраст кэша",
+			fmt.Sprintf("input validation error: %s", "negative max cache age"),
+		)
+	}
+
+	err = i.checkHeaders(options.Headers)
+	if err != nil {
+		return
+	}
+
+	for _, field := range options.Fields {
+		switch field {
+		case api.SiteDataFieldData, api.SiteDataFieldStatus, api.SiteDataFieldError, api.SiteDataFieldMeta:
+		default:
+			return i.errorCreator(
+				http.StatusBadRequest,
+				fmt.Sprintf("Ошибка ввода: неизвестное поле ответа: %s", field),
+				fmt.Sprintf("input validation error: %s %s", "unknown field:", field),
+			)
+		}
+	}
+
+	return
+}
+
+func (i *input) CheckCallbackURL(callbackURL string) (err error) {
+	if callbackURL == "" {
+		return
+	}
+
+	if !i.callbacksEnabled {
+		return i.errorCreator(
+			http.StatusBadRequest,
+			"Ошибка ввода: обратные вызовы не настроены",
+			fmt.Sprintf("input validation error: %s", "callbacks are disabled"),
+		)
+	}
+
+	u, err := url.ParseRequestURI(callbackURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return i.errorCreator(
+			http.StatusBadRequest,
+			fmt.Sprintf("Ошибка ввода: неверный адрес обратного вызова: %s", callbackURL),
+			fmt.Sprintf("input validation error: %s %s", "bad callback url:", callbackURL),
+		)
+	}
+	// Адреса внутренних сетей запрещены, после разрешения имени адрес проверяется еще раз при отправке
+	if err = i.callbackGuard.CheckHost(u.Hostname()); err != nil {
+		return i.errorCreator(
+			http.StatusBadRequest,
+			fmt.Sprintf("Ошибка ввода: адрес обратного вызова во внутренней сети: %s", callbackURL),
+			fmt.Sprintf("input validation error: %s %s: %s", "forbidden callback url:", callbackURL, err),
+		)
+	}
+
+	return nil
+}
+
+func (i *input) CheckSnapshotsRequest(request *api.GetSnapshotsRequest) (err error) {
+	if request.URL == "" {
+		return i.errorCreator(
+			http.StatusBadRequest,
+			"Ошибка ввода: не указан урл",
+			fmt.Sprintf("input validation error: %s", "no url"),
+		)
+	}
+	if _, err = url.ParseRequestURI(request.URL); err != nil {
+		return i.errorCreator(
+			http.StatusBadRequest,
+			fmt.Sprintf("Ошибка ввода: неверный урл: %s", request.URL),
+			fmt.Sprintf("input validation error: %s %s", "bad url:", request.URL),
+		)
+	}
+	if !request.From.IsZero() && !request.To.IsZero() && request.From.After(request.To) {
+		return i.errorCreator(
+			http.StatusBadRequest,
+			"Ошибка ввода: начало периода позже его конца",
+			fmt.Sprintf("input validation error: %s", "from is after to"),
+		)
+	}
+
+	return nil
+}
+
+// CheckSnapshotsDiffRequest требует либо идентификаторы обоих снимков, либо урл и оба момента времени
+func (i *input) CheckSnapshotsDiffRequest(request *api.GetSnapshotsDiffRequest) (err error) {
+	if request.Format != "" && request.Format != api.DiffFormatUnified && request.Format != api.DiffFormatJSON {
+		return i.errorCreator(
+			http.StatusBadRequest,
+			fmt.Sprintf("Ошибка ввода: неизвестный формат сравнения: %s", request.Format),
+			fmt.Sprintf("input validation error: %s %s", "unknown diff format:", request.Format),
+		)
+	}
+
+	byID := request.FromID != "" || request.ToID != ""
+	byTime := request.URL != "" || !request.From.IsZero() || !request.To.IsZero()
+	if byID && byTime {
+		return i.errorCreator(
+			http.StatusBadRequest,
+			"Ошибка ввода: нужно указать либо идентификаторы снимков, либо урл и время",
+			fmt.Sprintf("input validation error: %s", "both snapshot ids and url with time"),
+		)
+	}
+	if byID {
+		if request.FromID == "" || request.ToID == "" {
+			return i.errorCreator(
+				http.StatusBadRequest,
+				"Ошибка ввода: не указан идентификатор снимка",
+				fmt.Sprintf("input validation error: %s", "no snapshot id"),
+			)
+		}
+		return nil
+	}
+
+	if request.From.IsZero() || request.To.IsZero() {
+		return i.errorCreator(
+			http.StatusBadRequest,
+			"Ошибка ввода: не указано время снимка",
+			fmt.Sprintf("input validation error: %s", "no snapshot time"),
+		)
+	}
+	return i.CheckSnapshotsRequest(&api.GetSnapshotsRequest{URL: request.URL, From: request.From, To: request.To})
+}
+
 // NewInput ...
-func NewInput(maxURLsCount int, errorCreator httperror.ErrorCreator) Input {
+func NewInput(
+	maxURLsCount int,
+	maxJobURLsCount int,
+	maxTimeout time.Duration,
+	callbacksEnabled bool,
+	callbackGuard callbackGuard,
+	errorCreator httperror.ErrorCreator,
+) Input {
 	return &input{
-		maxURLsCount: maxURLsCount,
-		errorCreator: errorCreator,
+		maxURLsCount:     maxURLsCount,
+		maxJobURLsCount:  maxJobURLsCount,
+		maxTimeout:       maxTimeout,
+		callbacksEnabled: callbacksEnabled,
+		callbackGuard:    callbackGuard,
+		errorCreator:     errorCreator,
 	}
 }
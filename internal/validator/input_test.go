@@ -0,0 +1,84 @@
+package validator
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mts-test-task/internal/netguard"
+	"github.com/mts-test-task/pkg/sitesdataservice/httperror"
+)
+
+func TestInput_CheckCallbackURL(t *testing.T) {
+	tests := []struct {
+		name             string
+		callbackURL      string
+		callbacksEnabled bool
+		wantStatus       int
+	}{
+		{
+			name:             "empty",
+			callbacksEnabled: false,
+		},
+		{
+			name:        "callbacks disabled",
+			callbackURL: "https://example.com/hook",
+			wantStatus:  http.StatusBadRequest,
+		},
+		{
+			name:             "public host",
+			callbackURL:      "https://example.com/hook",
+			callbacksEnabled: true,
+		},
+		{
+			name:             "bad scheme",
+			callbackURL:      "ftp://example.com/hook",
+			callbacksEnabled: true,
+			wantStatus:       http.StatusBadRequest,
+		},
+		{
+			name:             "loopback",
+			callbackURL:      "http://127.0.0.1:8080/hook",
+			callbacksEnabled: true,
+			wantStatus:       http.StatusBadRequest,
+		},
+		{
+			name:             "localhost",
+			callbackURL:      "http://localhost/hook",
+			callbacksEnabled: true,
+			wantStatus:       http.StatusBadRequest,
+		},
+		{
+			name:             "cloud metadata",
+			callbackURL:      "http://169.254.169.254/latest/meta-data",
+			callbacksEnabled: true,
+			wantStatus:       http.StatusBadRequest,
+		},
+		{
+			name:             "ipv6 loopback",
+			callbackURL:      "http://[::1]/hook",
+			callbacksEnabled: true,
+			wantStatus:       http.StatusBadRequest,
+		},
+		{
+			name:             "allowed internal host",
+			callbackURL:      "http://hooks.internal/hook",
+			callbacksEnabled: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := NewInput(10, 10, time.Second, tt.callbacksEnabled, netguard.NewGuard([]string{"hooks.internal"}, time.Second), httperror.NewError)
+			err := input.CheckCallbackURL(tt.callbackURL)
+			if tt.wantStatus == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.Error(t, err) {
+				assert.Equal(t, tt.wantStatus, err.(*httperror.Error).StatusCode())
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/mts-test-task/internal/storages/mongodb/models"
+	"github.com/mts-test-task/pkg/sitesdataservice/api"
+	"github.com/mts-test-task/pkg/sitesdataservice/httperror"
+)
+
+// Максимальный размер тела вебхука. Недоставленный вебхук хранится в монге целиком, поэтому тело
+// должно быть намного меньше предельного размера документа
+const maxPayloadSize = 256 * 1024
+
+type webhookDeliveriesMongoObjectsBuilder interface {
+	FailedDelivery(id string, url string, payload []byte, attempts int, lastError string, createTime int64) (delivery *models.WebhookDelivery)
+	DeliveryFilter(id string) (filter bson.M)
+	FailedDeliveriesFilter() (filter bson.M)
+	DeliveriesPageOptions(offset int, limit int) (findOptions *options.FindOptions)
+	DeliveryAttemptUpdate(status string, attempts int, lastError string, updateTime int64) (update bson.M)
+}
+
+type webhookDeliveriesMongoWrapper interface {
+	AddDelivery(ctx context.Context, delivery interface{}) (err error)
+	GetDelivery(ctx context.Context, filter bson.M) (delivery *models.WebhookDelivery, err error)
+	GetDeliveries(ctx context.Context, filter bson.M, findOptions *options.FindOptions) (deliveries []*models.WebhookDelivery, err error)
+	UpdateDelivery(ctx context.Context, filter bson.M, update bson.M) (delivery *models.WebhookDelivery, err error)
+}
+
+type webhookDeliveriesConverter interface {
+	DeliveryToAPI(delivery *models.WebhookDelivery) (apiDelivery *api.WebhookDelivery)
+	DeliveriesToAPI(deliveries []*models.WebhookDelivery) (apiDeliveries []*api.WebhookDelivery)
+}
+
+// Sender delivers signed webhooks and keeps failed deliveries in mongo
+type Sender interface {
+	// Send retries delivery with exponential backoff and saves it as failed when attempts are over.
+	// Payloads larger than maxPayloadSize are rejected
+	Send(ctx context.Context, callbackURL string, payload interface{}) (err error)
+	GetFailed(ctx context.Context, offset int, limit int) (deliveries []*api.WebhookDelivery, err error)
+	// Replay makes one more attempt to deliver saved webhook
+	Replay(ctx context.Context, id string) (delivery *api.WebhookDelivery, err error)
+}
+
+type sender struct {
+	clientHTTP                    http.Client
+	secret                        []byte
+	maxAttempts                   int
+	baseBackoff                   time.Duration
+	maxBackoff                    time.Duration
+	defaultPageLimit              int
+	maxPageLimit                  int
+	webhookDeliveriesMongoObjects webhookDeliveriesMongoObjectsBuilder
+	webhookDeliveriesMongoWrapper webhookDeliveriesMongoWrapper
+	webhookDeliveriesConverter    webhookDeliveriesConverter
+	errorCreator                  httperror.ErrorCreator
+	logger                        log.Logger
+}
+
+func (s *sender) Send(ctx context.Context, callbackURL string, payload interface{}) (err error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %s", err)
+	}
+	if len(body) > maxPayloadSize {
+		return fmt.Errorf("webhook payload is %d bytes, limit is %d", len(body), maxPayloadSize)
+	}
+
+	attempts, err := s.deliver(ctx, callbackURL, body)
+	if err == nil {
+		return
+	}
+
+	// Сохраняем недоставленный вебхук даже при остановке сервиса, поэтому не используем ctx
+	delivery := s.webhookDeliveriesMongoObjects.FailedDelivery(
+		primitive.NewObjectID().Hex(),
+		callbackURL,
+		body,
+		attempts,
+		err.Error(),
+		time.Now().Unix(),
+	)
+	if addErr := s.webhookDeliveriesMongoWrapper.AddDelivery(context.Background(), delivery); addErr != nil {
+		_ = level.Error(s.logger).Log("msg", "Failed to put webhook delivery to mongo:", "url", callbackURL, "err", addErr)
+	}
+
+	return
+}
+
+func (s *sender) GetFailed(ctx context.Context, offset int, limit int) (deliveries []*api.WebhookDelivery, err error) {
+	if limit <= 0 {
+		limit = s.defaultPageLimit
+	}
+	if limit > s.maxPageLimit {
+		limit = s.maxPageLimit
+	}
+
+	storedDeliveries, err := s.webhookDeliveriesMongoWrapper.GetDeliveries(
+		ctx,
+		s.webhookDeliveriesMongoObjects.FailedDeliveriesFilter(),
+		s.webhookDeliveriesMongoObjects.DeliveriesPageOptions(offset, limit),
+	)
+	if err != nil {
+		return nil, s.errorCreator(
+			http.StatusInternalServerError,
+			"Не удалось получить список недоставленных вебхуков",
+			fmt.Sprintf("failed to get webhook deliveries: %s", err),
+		)
+	}
+
+	return s.webhookDeliveriesConverter.DeliveriesToAPI(storedDeliveries), nil
+}
+
+func (s *sender) Replay(ctx context.Context, id string) (delivery *api.WebhookDelivery, err error) {
+	storedDelivery, err := s.webhookDeliveriesMongoWrapper.GetDelivery(ctx, s.webhookDeliveriesMongoObjects.DeliveryFilter(id))
+	if err != nil {
+		return nil, s.errorCreator(
+			http.StatusInternalServerError,
+			"Не удалось получить вебхук",
+			fmt.Sprintf("failed to get webhook delivery %s: %s", id, err),
+		)
+	}
+	if storedDelivery == nil {
+		return nil, s.errorCreator(
+			http.StatusNotFound,
+			fmt.Sprintf("Вебхук %s не найден", id),
+			fmt.Sprintf("webhook delivery %s not found", id),
+		)
+	}
+
+	status, lastError := api.WebhookDeliveryStatusDelivered, ""
+	if postErr := s.post(ctx, storedDelivery.URL, []byte(storedDelivery.Payload)); postErr != nil {
+		status, lastError = api.WebhookDeliveryStatusFailed, postErr.Error()
+	}
+
+	storedDelivery, err = s.webhookDeliveriesMongoWrapper.UpdateDelivery(
+		ctx,
+		s.webhookDeliveriesMongoObjects.DeliveryFilter(id),
+		s.webhookDeliveriesMongoObjects.DeliveryAttemptUpdate(status, 1, lastError, time.Now().Unix()),
+	)
+	if err != nil || storedDelivery == nil {
+		return nil, s.errorCreator(
+			http.StatusInternalServerError,
+			"Не удалось сохранить результат доставки вебхука",
+			fmt.Sprintf("failed to update webhook delivery %s: %v", id, err),
+		)
+	}
+
+	return s.webhookDeliveriesConverter.DeliveryToAPI(storedDelivery), nil
+}
+
+// deliver отправляет вебхук, повторяя попытки с экспоненциально растущей паузой
+func (s *sender) deliver(ctx context.Context, callbackURL string, body []byte) (attempts int, err error) {
+	backoff := s.baseBackoff
+	for attempts = 1; ; attempts++ {
+		if err = s.post(ctx, callbackURL, body); err == nil {
+			return
+		}
+		if attempts >= s.maxAttempts {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return attempts, fmt.Errorf("delivery interrupted: %s, last error: %s", ctx.Err(), err)
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > s.maxBackoff {
+			backoff = s.maxBackoff
+		}
+	}
+}
+
+func (s *sender) post(ctx context.Context, callbackURL string, body []byte) (err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callbackURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %s", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(api.WebhookSignatureHeader, api.WebhookSignaturePrefix+s.sign(body))
+
+	resp, err := s.clientHTTP.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %s", err)
+	}
+	defer resp.Body.Close()
+	// Дочитываем тело, чтобы соединение вернулось в пул
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("status code %d for url %s", resp.StatusCode, callbackURL)
+	}
+
+	return
+}
+
+// sign возвращает HMAC-SHA256 тела в hex
+func (s *sender) sign(body []byte) string {
+	mac := hmac.New(sha256.New, s.secret)
+	_, _ = mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewSender ...
+func NewSender(
+	clientHTTP http.Client,
+	secret string,
+	maxAttempts int,
+	baseBackoff time.Duration,
+	maxBackoff time.Duration,
+	defaultPageLimit int,
+	maxPageLimit int,
+	webhookDeliveriesMongoObjects webhookDeliveriesMongoObjectsBuilder,
+	webhookDeliveriesMongoWrapper webhookDeliveriesMongoWrapper,
+	webhookDeliveriesConverter webhookDeliveriesConverter,
+	errorCreator httperror.ErrorCreator,
+	logger log.Logger,
+) Sender {
+	return &sender{
+		clientHTTP:                    clientHTTP,
+		secret:                        []byte(secret),
+		maxAttempts:                   maxAttempts,
+		baseBackoff:                   baseBackoff,
+		maxBackoff:                    maxBackoff,
+		defaultPageLimit:              defaultPageLimit,
+		maxPageLimit:                  maxPageLimit,
+		webhookDeliveriesMongoObjects: webhookDeliveriesMongoObjects,
+		webhookDeliveriesMongoWrapper: webhookDeliveriesMongoWrapper,
+		webhookDeliveriesConverter:    webhookDeliveriesConverter,
+		errorCreator:                  errorCreator,
+		logger:                        logger,
+	}
+}
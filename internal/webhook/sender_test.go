@@ -0,0 +1,124 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/mts-test-task/internal/converter"
+	"github.com/mts-test-task/internal/storages/mongodb/builder"
+	"github.com/mts-test-task/internal/storages/mongodb/models"
+	"github.com/mts-test-task/pkg/sitesdataservice/api"
+	"github.com/mts-test-task/pkg/sitesdataservice/httperror"
+)
+
+const testSecret = "secret"
+
+type deliveriesWrapperStub struct {
+	added []*models.WebhookDelivery
+}
+
+func (d *deliveriesWrapperStub) AddDelivery(ctx context.Context, delivery interface{}) (err error) {
+	d.added = append(d.added, delivery.(*models.WebhookDelivery))
+	return nil
+}
+
+func (d *deliveriesWrapperStub) GetDelivery(ctx context.Context, filter bson.M) (delivery *models.WebhookDelivery, err error) {
+	return nil, nil
+}
+
+func (d *deliveriesWrapperStub) GetDeliveries(ctx context.Context, filter bson.M, findOptions *options.FindOptions) (deliveries []*models.WebhookDelivery, err error) {
+	return nil, nil
+}
+
+func (d *deliveriesWrapperStub) UpdateDelivery(ctx context.Context, filter bson.M, update bson.M) (delivery *models.WebhookDelivery, err error) {
+	return nil, nil
+}
+
+func TestSender_Send(t *testing.T) {
+	tests := []struct {
+		name         string
+		payload      interface{}
+		status       int
+		wantRequests int32
+		wantErr      bool
+		wantStored   bool
+	}{
+		{
+			name:         "delivered",
+			payload:      &api.JobCallback{Job: &api.Job{ID: "1"}, ResultsURL: "/api/v1/jobs/1"},
+			status:       http.StatusNoContent,
+			wantRequests: 1,
+		},
+		{
+			name:         "failed delivery is stored",
+			payload:      &api.JobCallback{Job: &api.Job{ID: "1"}, ResultsURL: "/api/v1/jobs/1"},
+			status:       http.StatusInternalServerError,
+			wantRequests: 2,
+			wantErr:      true,
+			wantStored:   true,
+		},
+		{
+			name:    "oversized payload is rejected",
+			payload: strings.Repeat("a", maxPayloadSize),
+			status:  http.StatusOK,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&requests, 1)
+				body, _ := ioutil.ReadAll(r.Body)
+				mac := hmac.New(sha256.New, []byte(testSecret))
+				_, _ = mac.Write(body)
+				assert.Equal(t, api.WebhookSignaturePrefix+hex.EncodeToString(mac.Sum(nil)), r.Header.Get(api.WebhookSignatureHeader))
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			wrapper := &deliveriesWrapperStub{}
+			sender := NewSender(
+				http.Client{Timeout: time.Second},
+				testSecret,
+				2,
+				time.Millisecond,
+				time.Millisecond,
+				10,
+				100,
+				builder.NewWebhookDeliveriesMongoObjects(),
+				wrapper,
+				converter.NewWebhookDeliveries(),
+				httperror.NewError,
+				log.NewNopLogger(),
+			)
+
+			err := sender.Send(context.Background(), server.URL, tt.payload)
+			assert.Equal(t, tt.wantErr, err != nil, "unexpected error: %v", err)
+			assert.Equal(t, tt.wantRequests, atomic.LoadInt32(&requests))
+			if !tt.wantStored {
+				assert.Empty(t, wrapper.added)
+				return
+			}
+			if assert.Len(t, wrapper.added, 1) {
+				assert.Equal(t, api.WebhookDeliveryStatusFailed, wrapper.added[0].Status)
+				assert.Equal(t, 2, wrapper.added[0].Attempts)
+				assert.Less(t, len(wrapper.added[0].Payload), maxPayloadSize)
+			}
+		})
+	}
+}
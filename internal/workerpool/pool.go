@@ -0,0 +1,148 @@
+package workerpool
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrOverloaded is returned when tasks do not fit into the queue
+var ErrOverloaded = errors.New("worker pool queue is full")
+
+// Границы гистограммы времени ожидания в очереди, в миллисекундах
+var queueWaitBuckets = []int64{1, 5, 10, 50, 100, 500, 1000, 5000}
+
+// Stats describes pool load and time tasks spent in the queue
+type Stats struct {
+	Workers          int              `json:"workers"`
+	QueueSize        int              `json:"queue_size"`
+	Queued           int              `json:"queued"`
+	Submitted        int64            `json:"submitted"`
+	Rejected         int64            `json:"rejected"`
+	Started          int64            `json:"started"`
+	QueueWaitMsTotal int64            `json:"queue_wait_ms_total"`
+	QueueWaitMsMax   int64            `json:"queue_wait_ms_max"`
+	QueueWaitMs      map[string]int64 `json:"queue_wait_ms"`
+}
+
+// Pool runs tasks on a fixed number of workers shared by all requests
+type Pool interface {
+	Submit(tasks []func()) (err error)
+	Stats() (stats *Stats)
+}
+
+type task struct {
+	run      func()
+	queuedAt time.Time
+}
+
+type pool struct {
+	workers   int
+	queueSize int
+	queue     chan *task
+
+	mu               sync.Mutex
+	queued           int
+	submitted        int64
+	rejected         int64
+	started          int64
+	queueWaitMsTotal int64
+	queueWaitMsMax   int64
+	queueWaitCounts  []int64
+}
+
+// Submit ставит в очередь все задачи или ни одной, чтобы запрос не выполнялся частично
+func (p *pool) Submit(tasks []func()) (err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.queued+len(tasks) > p.queueSize {
+		p.rejected += int64(len(tasks))
+		return ErrOverloaded
+	}
+	p.queued += len(tasks)
+	p.submitted += int64(len(tasks))
+
+	// Место в канале зарезервировано счетчиком, поэтому запись не блокируется
+	now := time.Now()
+	for _, run := range tasks {
+		p.queue <- &task{run: run, queuedAt: now}
+	}
+
+	return
+}
+
+func (p *pool) Stats() (stats *Stats) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats = &Stats{
+		Workers:          p.workers,
+		QueueSize:        p.queueSize,
+		Queued:           p.queued,
+		Submitted:        p.submitted,
+		Rejected:         p.rejected,
+		Started:          p.started,
+		QueueWaitMsTotal: p.queueWaitMsTotal,
+		QueueWaitMsMax:   p.queueWaitMsMax,
+		QueueWaitMs:      make(map[string]int64, len(p.queueWaitCounts)),
+	}
+	for i, count := range p.queueWaitCounts {
+		stats.QueueWaitMs[bucketName(i)] = count
+	}
+
+	return
+}
+
+func (p *pool) work() {
+	for t := range p.queue {
+		p.record(time.Since(t.queuedAt))
+		t.run()
+	}
+}
+
+// record учитывает задачу, которую взял воркер, и время ее ожидания в очереди
+func (p *pool) record(wait time.Duration) {
+	waitMs := wait.Milliseconds()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.queued--
+	p.started++
+	p.queueWaitMsTotal += waitMs
+	if waitMs > p.queueWaitMsMax {
+		p.queueWaitMsMax = waitMs
+	}
+	bucket := len(queueWaitBuckets)
+	for i, bound := range queueWaitBuckets {
+		if waitMs <= bound {
+			bucket = i
+			break
+		}
+	}
+	p.queueWaitCounts[bucket]++
+}
+
+// bucketName возвращает имя интервала гистограммы: le_<граница> или inf для последнего
+func bucketName(i int) string {
+	if i == len(queueWaitBuckets) {
+		return "inf"
+	}
+	return "le_" + time.Duration(queueWaitBuckets[i]*int64(time.Millisecond)).String()
+}
+
+// NewPool starts workers, they live as long as the process
+func NewPool(workers int, queueSize int) Pool {
+	p := &pool{
+		workers:         workers,
+		queueSize:       queueSize,
+		queue:           make(chan *task, queueSize),
+		queueWaitCounts: make([]int64, len(queueWaitBuckets)+1),
+	}
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+
+	return p
+}
@@ -0,0 +1,107 @@
+package workerpool
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPool_Submit(t *testing.T) {
+	tests := []struct {
+		name string
+		// batches - размеры порций задач, отправляемых, пока единственный воркер занят
+		batches      []int
+		wantRejected []bool
+	}{
+		{
+			name:         "fits queue",
+			batches:      []int{2, 1},
+			wantRejected: []bool{false, false},
+		},
+		{
+			name:         "batch is rejected entirely",
+			batches:      []int{2, 2},
+			wantRejected: []bool{false, true},
+		},
+		{
+			name:         "smaller batch fits after rejected one",
+			batches:      []int{4, 3},
+			wantRejected: []bool{true, false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPool(1, 3)
+
+			// Занимаем воркер, чтобы задачи оставались в очереди
+			block := make(chan struct{})
+			started := make(chan struct{})
+			assert.NoError(t, p.Submit([]func(){func() {
+				close(started)
+				<-block
+			}}))
+			<-started
+
+			var wg sync.WaitGroup
+			var ran, accepted, rejected int
+			var mu sync.Mutex
+			for i, size := range tt.batches {
+				tasks := make([]func(), size)
+				for j := range tasks {
+					tasks[j] = func() {
+						defer wg.Done()
+						mu.Lock()
+						ran++
+						mu.Unlock()
+					}
+				}
+				wg.Add(size)
+				err := p.Submit(tasks)
+				assert.Equal(t, tt.wantRejected[i], errors.Is(err, ErrOverloaded))
+				if err != nil {
+					wg.Add(-size)
+					rejected += size
+					continue
+				}
+				accepted += size
+			}
+
+			stats := p.Stats()
+			assert.Equal(t, accepted, stats.Queued)
+			assert.Equal(t, int64(rejected), stats.Rejected)
+
+			close(block)
+			wg.Wait()
+			assert.Equal(t, accepted, ran)
+
+			stats = p.Stats()
+			assert.Equal(t, 0, stats.Queued)
+			assert.Equal(t, int64(accepted+1), stats.Submitted)
+			assert.Equal(t, int64(accepted+1), stats.Started)
+			var histogram int64
+			for _, count := range stats.QueueWaitMs {
+				histogram += count
+			}
+			assert.Equal(t, stats.Started, histogram)
+		})
+	}
+}
+
+func TestBucketName(t *testing.T) {
+	tests := []struct {
+		bucket int
+		name   string
+	}{
+		{bucket: 0, name: "le_1ms"},
+		{bucket: 3, name: "le_50ms"},
+		{bucket: 6, name: "le_1s"},
+		{bucket: len(queueWaitBuckets), name: "inf"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.name, bucketName(tt.bucket))
+		})
+	}
+}
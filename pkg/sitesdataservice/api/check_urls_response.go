@@ -1,7 +1,74 @@
 package api
 
+import "time"
+
+// Статусы обработки урла
+const (
+	SiteDataStatusOK    = "ok"
+	SiteDataStatusError = "error"
+)
+
+// Кодировка данных в ответе
+const (
+	// SiteDataEncodingBase64 двоичные данные закодированы в base64, текст передается без поля encoding
+	SiteDataEncodingBase64 = "base64"
+)
+
 // SiteData struct for user response
 type SiteData struct {
-	URL  string `json:"url"`
-	Data string `json:"data"`
+	URL      string         `json:"url"`
+	Data     string         `json:"data"`
+	Encoding string         `json:"encoding,omitempty"`
+	Status   string         `json:"status,omitempty"`
+	Error    *SiteDataError `json:"error,omitempty"`
+	Meta     *SiteDataMeta  `json:"meta,omitempty"`
+}
+
+// SiteDataError describes why data for the url wasn't received
+type SiteDataError struct {
+	Code     int    `json:"code"`
+	Message  string `json:"message"`
+	Attempts int    `json:"attempts,omitempty"`
+	Rule     string `json:"rule,omitempty"`
+}
+
+// SiteDataMeta describes how data for the url was received
+type SiteDataMeta struct {
+	StatusCode      int               `json:"status_code"`
+	Headers         map[string]string `json:"headers,omitempty"`
+	ContentType     string            `json:"content_type,omitempty"`
+	ContentLength   int64             `json:"content_length"`
+	Charset         string            `json:"charset,omitempty"`
+	FetchDurationMs int64             `json:"fetch_duration_ms"`
+	FetchedAt       time.Time         `json:"fetched_at"`
+	Cached          bool              `json:"cached"`
+	CacheAgeMs      int64             `json:"cache_age_ms,omitempty"`
+	Truncated       bool              `json:"truncated,omitempty"`
+	// Revalidated is set when stale cached data was confirmed by the site with 304 Not Modified
+	Revalidated bool `json:"revalidated,omitempty"`
+	// Stale is set when cached data is served after its freshness lifetime: the site failed
+	// or the data is being refreshed in background
+	Stale        bool   `json:"stale,omitempty"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	// CacheTTLMs is the freshness lifetime from caching headers of the site, absent without them.
+	// NoStore is set when the site forbids caching, MustRevalidate when stale data must be checked
+	// with the site before use, Vary lists request headers the data depends on
+	CacheTTLMs     *int64   `json:"cache_ttl_ms,omitempty"`
+	NoStore        bool     `json:"no_store,omitempty"`
+	MustRevalidate bool     `json:"must_revalidate,omitempty"`
+	Vary           []string `json:"vary,omitempty"`
+	// Attempts is the number of requests to the site, zero for cached data
+	Attempts int `json:"attempts,omitempty"`
+	// Rule is the name of matched domain rule
+	Rule string `json:"rule,omitempty"`
+	// Redirects is the chain of followed redirects, FinalURL is set when there were redirects
+	Redirects []*SiteDataRedirect `json:"redirects,omitempty"`
+	FinalURL  string              `json:"final_url,omitempty"`
+}
+
+// SiteDataRedirect is one hop of redirect chain
+type SiteDataRedirect struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code"`
 }
@@ -0,0 +1,66 @@
+package api
+
+// Режимы обработки ошибок при получении данных
+const (
+	// FailureModeFailFast ошибка по одному урлу прерывает обработку всего списка
+	FailureModeFailFast = "fail_fast"
+	// FailureModePartial каждый урл получает собственный результат: данные или ошибку
+	FailureModePartial = "partial"
+)
+
+// Политики редиректов
+const (
+	// RedirectPolicyFollow следовать редиректам, не больше max_redirects
+	RedirectPolicyFollow = "follow"
+	// RedirectPolicyNone не следовать редиректам и возвращать ответ с редиректом
+	RedirectPolicyNone = "none"
+	// RedirectPolicySameHost следовать редиректам только в пределах исходного хоста
+	RedirectPolicySameHost = "same_host"
+)
+
+// Поведение при превышении лимита размера ответа сайта
+const (
+	// BodyLimitModeFail ответ больше лимита считается ошибкой
+	BodyLimitModeFail = "fail"
+	// BodyLimitModeTruncate ответ обрезается до лимита и помечается как обрезанный
+	BodyLimitModeTruncate = "truncate"
+)
+
+// Обработка двоичных ответов сайтов
+const (
+	// BinaryContentAllow двоичные данные возвращаются в base64
+	BinaryContentAllow = "allow"
+	// BinaryContentReject двоичный ответ считается ошибкой
+	BinaryContentReject = "reject"
+)
+
+// Поля результата по урлу, которые можно запросить через FetchOptions.Fields. Урл возвращается всегда
+const (
+	SiteDataFieldData   = "data"
+	SiteDataFieldStatus = "status"
+	SiteDataFieldError  = "error"
+	SiteDataFieldMeta   = "meta"
+)
+
+// FetchOptions options of processing urls
+type FetchOptions struct {
+	FailureMode string `json:"failure_mode,omitempty"`
+	// TimeoutMs limits request to every single url, zero means client default
+	TimeoutMs int64 `json:"timeout_ms,omitempty"`
+	// MaxCacheAgeMs is the maximum age of cached data, zero means service default
+	MaxCacheAgeMs int64 `json:"max_cache_age_ms,omitempty"`
+	// Headers are added to requests to sites
+	Headers map[string]string `json:"headers,omitempty"`
+	// RedirectPolicy and MaxRedirects override service defaults
+	RedirectPolicy string `json:"redirect_policy,omitempty"`
+	MaxRedirects   int    `json:"max_redirects,omitempty"`
+	// MaxBodySize limits response of every site in bytes, it can't exceed service limit
+	MaxBodySize   int64  `json:"max_body_size,omitempty"`
+	BodyLimitMode string `json:"body_limit_mode,omitempty"`
+	// RawBody returns body as is, without transcoding to UTF-8
+	RawBody bool `json:"raw_body,omitempty"`
+	// BinaryContent is allow or reject, empty means service default
+	BinaryContent string `json:"binary_content,omitempty"`
+	// Fields of SiteData to return, empty means all fields
+	Fields []string `json:"fields,omitempty"`
+}
@@ -0,0 +1,40 @@
+package api
+
+import "time"
+
+// Статусы задания
+const (
+	JobStatusPending   = "pending"
+	JobStatusRunning   = "running"
+	JobStatusDone      = "done"
+	JobStatusFailed    = "failed"
+	JobStatusCancelled = "cancelled"
+)
+
+// CreateJobRequest struct for creating batch job
+type CreateJobRequest struct {
+	URLs    []string      `json:"urls"`
+	Options *FetchOptions `json:"options,omitempty"`
+	// CallbackURL receives JobCallback when job is finished
+	CallbackURL string `json:"callback_url,omitempty"`
+}
+
+// Job struct describing batch job progress
+type Job struct {
+	ID        string    `json:"id"`
+	Status    string    `json:"status"`
+	Total     int       `json:"total"`
+	Processed int       `json:"processed"`
+	Failed    int       `json:"failed"`
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// GetJobResponse struct for job progress with a page of per-url results
+type GetJobResponse struct {
+	Job     *Job        `json:"job"`
+	Results []*SiteData `json:"results"`
+	Offset  int         `json:"offset"`
+	Limit   int         `json:"limit"`
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// SiteRequest describes request to one site. In JSON it is either a plain url string
+// or an object with url, method, headers and body
+type SiteRequest struct {
+	URL     string            `json:"url"`
+	Method  string            `json:"method,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+}
+
+type siteRequest SiteRequest
+
+// UnmarshalJSON accepts both plain url string and object
+func (r *SiteRequest) UnmarshalJSON(data []byte) (err error) {
+	var url string
+	if err = json.Unmarshal(data, &url); err == nil {
+		*r = SiteRequest{URL: url}
+		return
+	}
+
+	return json.Unmarshal(data, (*siteRequest)(r))
+}
+
+// MarshalJSON writes plain url string for a bare GET request
+func (r SiteRequest) MarshalJSON() ([]byte, error) {
+	if r.IsPlain() {
+		return json.Marshal(r.URL)
+	}
+
+	return json.Marshal(siteRequest(r))
+}
+
+// IsPlain reports whether it is a GET request without headers and body
+func (r *SiteRequest) IsPlain() bool {
+	return (r.Method == "" || r.Method == http.MethodGet) && len(r.Headers) == 0 && r.Body == ""
+}
+
+// SiteRequestsFromURLs makes bare GET requests for urls
+func SiteRequestsFromURLs(urls []string) (requests []*SiteRequest) {
+	requests = make([]*SiteRequest, len(urls))
+	for i := range urls {
+		requests[i] = &SiteRequest{URL: urls[i]}
+	}
+
+	return
+}
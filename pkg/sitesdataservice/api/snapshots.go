@@ -0,0 +1,81 @@
+package api
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// GetSnapshotsRequest struct for a page of url history, zero From and To mean unbounded range
+type GetSnapshotsRequest struct {
+	URL  string
+	From time.Time
+	To   time.Time
+	// Cursor is NextCursor of the previous page, empty for the first page
+	Cursor string
+	Limit  int
+}
+
+// Snapshot struct describing site data stored at some moment, Data is returned only for a single snapshot
+type Snapshot struct {
+	ID          string        `json:"id"`
+	URL         string        `json:"url"`
+	CreatedAt   time.Time     `json:"created_at"`
+	ContentHash string        `json:"content_hash,omitempty"`
+	Data        string        `json:"data,omitempty"`
+	Encoding    string        `json:"encoding,omitempty"`
+	Meta        *SiteDataMeta `json:"meta,omitempty"`
+}
+
+// GetSnapshotsResponse struct for a page of url history, newest snapshots first
+type GetSnapshotsResponse struct {
+	Snapshots []*Snapshot `json:"snapshots"`
+	// NextCursor is empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+	Limit      int    `json:"limit"`
+}
+
+// Форматы сравнения снимков
+const (
+	// DiffFormatUnified line-based unified diff of snapshot data
+	DiffFormatUnified = "unified"
+	// DiffFormatJSON structural diff of JSON snapshot data
+	DiffFormatJSON = "json"
+)
+
+// Операции структурного сравнения JSON
+const (
+	JSONChangeAdd     = "add"
+	JSONChangeRemove  = "remove"
+	JSONChangeReplace = "replace"
+)
+
+// GetSnapshotsDiffRequest struct for comparing two snapshots, given either by FromID and ToID
+// or by URL and two moments From and To. The latest snapshot not newer than the moment is used
+type GetSnapshotsDiffRequest struct {
+	FromID string
+	ToID   string
+	URL    string
+	From   time.Time
+	To     time.Time
+	// Format is DiffFormatUnified by default
+	Format string
+}
+
+// SnapshotsDiff struct describing changes between two snapshots, snapshots are returned without data
+type SnapshotsDiff struct {
+	From      *Snapshot `json:"from"`
+	To        *Snapshot `json:"to"`
+	Format    string    `json:"format"`
+	Identical bool      `json:"identical"`
+	// Unified is set for DiffFormatUnified, Changes for DiffFormatJSON
+	Unified string        `json:"unified,omitempty"`
+	Changes []*JSONChange `json:"changes,omitempty"`
+}
+
+// JSONChange is one change of JSON value, Path is JSON Pointer to the changed value
+type JSONChange struct {
+	Op       string          `json:"op"`
+	Path     string          `json:"path"`
+	OldValue json.RawMessage `json:"old_value,omitempty"`
+	NewValue json.RawMessage `json:"new_value,omitempty"`
+}
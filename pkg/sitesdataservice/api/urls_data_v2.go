@@ -0,0 +1,12 @@
+package api
+
+// GetDataFromURLsRequest is the v2 request envelope
+type GetDataFromURLsRequest struct {
+	URLs    []*SiteRequest `json:"urls"`
+	Options *FetchOptions  `json:"options,omitempty"`
+}
+
+// GetDataFromURLsResponse is the v2 response envelope
+type GetDataFromURLsResponse struct {
+	Results []*SiteData `json:"results"`
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Статусы доставки вебхука
+const (
+	WebhookDeliveryStatusFailed    = "failed"
+	WebhookDeliveryStatusDelivered = "delivered"
+)
+
+// Заголовок с подписью тела вебхука: "sha256=" и HMAC-SHA256 тела в hex
+const (
+	WebhookSignatureHeader = "X-Signature-256"
+	WebhookSignaturePrefix = "sha256="
+)
+
+// JobCallback struct for payload sent to callback url on job completion. Results are not sent,
+// they are read page by page from ResultsURL
+type JobCallback struct {
+	Job        *Job   `json:"job"`
+	ResultsURL string `json:"results_url"`
+}
+
+// WebhookDelivery struct describing failed webhook delivery
+type WebhookDelivery struct {
+	ID        string          `json:"id"`
+	URL       string          `json:"url"`
+	Status    string          `json:"status"`
+	Attempts  int             `json:"attempts"`
+	LastError string          `json:"last_error,omitempty"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
@@ -13,19 +13,37 @@ import (
 
 type client struct {
 	cli *fasthttp.HostClient
+	// fasthttp не умеет читать тело ответа по частям, поэтому для потоковых запросов используется net/http
+	streamCli *http.Client
 
-	transportGetDataFromURLs GetDataFromURLsClientTransport
+	transportGetDataFromURLs    GetDataFromURLsClientTransport
+	transportStreamDataFromURLs StreamDataFromURLsClientTransport
+	transportCreateJob          CreateJobClientTransport
+	transportGetJob             GetJobClientTransport
+	transportCancelJob          CancelJobClientTransport
+
+	transportGetFailedWebhookDeliveries GetFailedWebhookDeliveriesClientTransport
+	transportReplayWebhookDelivery      ReplayWebhookDeliveryClientTransport
+
+	transportGetSnapshots     GetSnapshotsClientTransport
+	transportGetSnapshot      GetSnapshotClientTransport
+	transportGetSnapshotsDiff GetSnapshotsDiffClientTransport
 }
 
 // GetDataFromURLs ...
 func (s *client) GetDataFromURLs(ctx context.Context, request []string) (response []*api.SiteData, err error) {
+	return s.GetDataFromURLsWithOptions(ctx, api.SiteRequestsFromURLs(request), nil)
+}
+
+// GetDataFromURLsWithOptions ...
+func (s *client) GetDataFromURLsWithOptions(ctx context.Context, request []*api.SiteRequest, options *api.FetchOptions) (response []*api.SiteData, err error) {
 	req, res := fasthttp.AcquireRequest(), fasthttp.AcquireResponse()
 	defer func() {
 		fasthttp.ReleaseRequest(req)
 		fasthttp.ReleaseResponse(res)
 	}()
 
-	if err = s.transportGetDataFromURLs.EncodeRequest(ctx, req, request); err != nil {
+	if err = s.transportGetDataFromURLs.EncodeRequest(ctx, req, request, options); err != nil {
 		return
 	}
 
@@ -36,16 +54,206 @@ func (s *client) GetDataFromURLs(ctx context.Context, request []string) (respons
 	return s.transportGetDataFromURLs.DecodeResponse(ctx, res)
 }
 
+// StreamDataFromURLs ...
+func (s *client) StreamDataFromURLs(ctx context.Context, request []*api.SiteRequest, options *api.FetchOptions, handle func(siteData *api.SiteData) error) (err error) {
+	req, err := s.transportStreamDataFromURLs.EncodeRequest(ctx, request, options)
+	if err != nil {
+		return
+	}
+
+	res, err := s.streamCli.Do(req)
+	if err != nil {
+		return
+	}
+	defer res.Body.Close()
+
+	return s.transportStreamDataFromURLs.DecodeResponse(ctx, res, handle)
+}
+
+// CreateJob ...
+func (s *client) CreateJob(ctx context.Context, request *api.CreateJobRequest) (job *api.Job, err error) {
+	req, res := fasthttp.AcquireRequest(), fasthttp.AcquireResponse()
+	defer func() {
+		fasthttp.ReleaseRequest(req)
+		fasthttp.ReleaseResponse(res)
+	}()
+
+	if err = s.transportCreateJob.EncodeRequest(ctx, req, request); err != nil {
+		return
+	}
+
+	err = s.cli.Do(req, res)
+	if err != nil {
+		return
+	}
+	return s.transportCreateJob.DecodeResponse(ctx, res)
+}
+
+// GetJob ...
+func (s *client) GetJob(ctx context.Context, id string, offset int, limit int) (response *api.GetJobResponse, err error) {
+	req, res := fasthttp.AcquireRequest(), fasthttp.AcquireResponse()
+	defer func() {
+		fasthttp.ReleaseRequest(req)
+		fasthttp.ReleaseResponse(res)
+	}()
+
+	if err = s.transportGetJob.EncodeRequest(ctx, req, id, offset, limit); err != nil {
+		return
+	}
+
+	err = s.cli.Do(req, res)
+	if err != nil {
+		return
+	}
+	return s.transportGetJob.DecodeResponse(ctx, res)
+}
+
+// CancelJob ...
+func (s *client) CancelJob(ctx context.Context, id string) (job *api.Job, err error) {
+	req, res := fasthttp.AcquireRequest(), fasthttp.AcquireResponse()
+	defer func() {
+		fasthttp.ReleaseRequest(req)
+		fasthttp.ReleaseResponse(res)
+	}()
+
+	if err = s.transportCancelJob.EncodeRequest(ctx, req, id); err != nil {
+		return
+	}
+
+	err = s.cli.Do(req, res)
+	if err != nil {
+		return
+	}
+	return s.transportCancelJob.DecodeResponse(ctx, res)
+}
+
+// GetFailedWebhookDeliveries ...
+func (s *client) GetFailedWebhookDeliveries(ctx context.Context, offset int, limit int) (deliveries []*api.WebhookDelivery, err error) {
+	req, res := fasthttp.AcquireRequest(), fasthttp.AcquireResponse()
+	defer func() {
+		fasthttp.ReleaseRequest(req)
+		fasthttp.ReleaseResponse(res)
+	}()
+
+	if err = s.transportGetFailedWebhookDeliveries.EncodeRequest(ctx, req, offset, limit); err != nil {
+		return
+	}
+
+	err = s.cli.Do(req, res)
+	if err != nil {
+		return
+	}
+	return s.transportGetFailedWebhookDeliveries.DecodeResponse(ctx, res)
+}
+
+// ReplayWebhookDelivery ...
+func (s *client) ReplayWebhookDelivery(ctx context.Context, id string) (delivery *api.WebhookDelivery, err error) {
+	req, res := fasthttp.AcquireRequest(), fasthttp.AcquireResponse()
+	defer func() {
+		fasthttp.ReleaseRequest(req)
+		fasthttp.ReleaseResponse(res)
+	}()
+
+	if err = s.transportReplayWebhookDelivery.EncodeRequest(ctx, req, id); err != nil {
+		return
+	}
+
+	err = s.cli.Do(req, res)
+	if err != nil {
+		return
+	}
+	return s.transportReplayWebhookDelivery.DecodeResponse(ctx, res)
+}
+
+// GetSnapshots ...
+func (s *client) GetSnapshots(ctx context.Context, request *api.GetSnapshotsRequest) (response *api.GetSnapshotsResponse, err error) {
+	req, res := fasthttp.AcquireRequest(), fasthttp.AcquireResponse()
+	defer func() {
+		fasthttp.ReleaseRequest(req)
+		fasthttp.ReleaseResponse(res)
+	}()
+
+	if err = s.transportGetSnapshots.EncodeRequest(ctx, req, request); err != nil {
+		return
+	}
+
+	err = s.cli.Do(req, res)
+	if err != nil {
+		return
+	}
+	return s.transportGetSnapshots.DecodeResponse(ctx, res)
+}
+
+// GetSnapshot ...
+func (s *client) GetSnapshot(ctx context.Context, id string) (snapshot *api.Snapshot, err error) {
+	req, res := fasthttp.AcquireRequest(), fasthttp.AcquireResponse()
+	defer func() {
+		fasthttp.ReleaseRequest(req)
+		fasthttp.ReleaseResponse(res)
+	}()
+
+	if err = s.transportGetSnapshot.EncodeRequest(ctx, req, id); err != nil {
+		return
+	}
+
+	err = s.cli.Do(req, res)
+	if err != nil {
+		return
+	}
+	return s.transportGetSnapshot.DecodeResponse(ctx, res)
+}
+
+// GetSnapshotsDiff ...
+func (s *client) GetSnapshotsDiff(ctx context.Context, request *api.GetSnapshotsDiffRequest) (diff *api.SnapshotsDiff, err error) {
+	req, res := fasthttp.AcquireRequest(), fasthttp.AcquireResponse()
+	defer func() {
+		fasthttp.ReleaseRequest(req)
+		fasthttp.ReleaseResponse(res)
+	}()
+
+	if err = s.transportGetSnapshotsDiff.EncodeRequest(ctx, req, request); err != nil {
+		return
+	}
+
+	err = s.cli.Do(req, res)
+	if err != nil {
+		return
+	}
+	return s.transportGetSnapshotsDiff.DecodeResponse(ctx, res)
+}
+
 // NewClient the client creator
 func NewClient(
 	cli *fasthttp.HostClient,
+	streamCli *http.Client,
 
 	transportGetDataFromURLs GetDataFromURLsClientTransport,
+	transportStreamDataFromURLs StreamDataFromURLsClientTransport,
+	transportCreateJob CreateJobClientTransport,
+	transportGetJob GetJobClientTransport,
+	transportCancelJob CancelJobClientTransport,
+	transportGetFailedWebhookDeliveries GetFailedWebhookDeliveriesClientTransport,
+	transportReplayWebhookDelivery ReplayWebhookDeliveryClientTransport,
+	transportGetSnapshots GetSnapshotsClientTransport,
+	transportGetSnapshot GetSnapshotClientTransport,
+	transportGetSnapshotsDiff GetSnapshotsDiffClientTransport,
 ) svc.Service {
 	return &client{
-		cli: cli,
+		cli:       cli,
+		streamCli: streamCli,
+
+		transportGetDataFromURLs:    transportGetDataFromURLs,
+		transportStreamDataFromURLs: transportStreamDataFromURLs,
+		transportCreateJob:          transportCreateJob,
+		transportGetJob:             transportGetJob,
+		transportCancelJob:          transportCancelJob,
+
+		transportGetFailedWebhookDeliveries: transportGetFailedWebhookDeliveries,
+		transportReplayWebhookDelivery:      transportReplayWebhookDelivery,
 
-		transportGetDataFromURLs: transportGetDataFromURLs,
+		transportGetSnapshots:     transportGetSnapshots,
+		transportGetSnapshot:      transportGetSnapshot,
+		transportGetSnapshotsDiff: transportGetSnapshotsDiff,
 	}
 }
 
@@ -61,13 +269,72 @@ func NewPreparedClient(
 		MethodHTTP+serverURL+URIPathClientGetDataFromURLs,
 		HTTPMethodClientGetDataFromURLs,
 	)
+	transportStreamDataFromURLs := NewStreamDataFromURLsClientTransport(
+		errorProcessor,
+		MethodHTTP+serverURL+URIPathClientGetDataFromURLs,
+		HTTPMethodClientGetDataFromURLs,
+	)
+	transportCreateJob := NewCreateJobClientTransport(
+		errorProcessor,
+		MethodHTTP+serverURL+URIPathClientJobs,
+		HTTPMethodClientCreateJob,
+	)
+	transportGetJob := NewGetJobClientTransport(
+		errorProcessor,
+		MethodHTTP+serverURL+URIPathClientJob,
+		HTTPMethodClientGetJob,
+	)
+	transportCancelJob := NewCancelJobClientTransport(
+		errorProcessor,
+		MethodHTTP+serverURL+URIPathClientJob,
+		HTTPMethodClientCancelJob,
+	)
+	transportGetFailedWebhookDeliveries := NewGetFailedWebhookDeliveriesClientTransport(
+		errorProcessor,
+		MethodHTTP+serverURL+URIPathClientWebhookDeliveries,
+		HTTPMethodClientGetFailedWebhookDeliveries,
+	)
+	transportReplayWebhookDelivery := NewReplayWebhookDeliveryClientTransport(
+		errorProcessor,
+		MethodHTTP+serverURL+URIPathClientReplayWebhookDelivery,
+		HTTPMethodClientReplayWebhookDelivery,
+	)
+	transportGetSnapshots := NewGetSnapshotsClientTransport(
+		errorProcessor,
+		MethodHTTP+serverURL+URIPathClientSnapshots,
+		HTTPMethodClientGetSnapshots,
+	)
+	transportGetSnapshot := NewGetSnapshotClientTransport(
+		errorProcessor,
+		MethodHTTP+serverURL+URIPathClientSnapshot,
+		HTTPMethodClientGetSnapshot,
+	)
+	transportGetSnapshotsDiff := NewGetSnapshotsDiffClientTransport(
+		errorProcessor,
+		MethodHTTP+serverURL+URIPathClientSnapshotsDiff,
+		HTTPMethodClientGetSnapshotsDiff,
+	)
 
 	return NewClient(
 		&fasthttp.HostClient{
 			Addr:     serverHost,
 			MaxConns: maxConns,
 		},
+		&http.Client{
+			Transport: &http.Transport{
+				MaxConnsPerHost: maxConns,
+			},
+		},
 
 		transportGetDataFromURLs,
+		transportStreamDataFromURLs,
+		transportCreateJob,
+		transportGetJob,
+		transportCancelJob,
+		transportGetFailedWebhookDeliveries,
+		transportReplayWebhookDelivery,
+		transportGetSnapshots,
+		transportGetSnapshot,
+		transportGetSnapshotsDiff,
 	)
 }
@@ -2,6 +2,7 @@ package httpclient
 
 import (
 	"context"
+	"encoding/json"
 	"log"
 	"net/http"
 	"testing"
@@ -23,18 +24,47 @@ const (
 	maxConns                 = 512
 	maxRequestBodySize       = 15 * 1024 * 1024
 	serverTimeout            = 1 * time.Millisecond
+	handlerTimeout           = 1 * time.Second
 	serverLaunchingWaitSleep = 1 * time.Second
 
 	getDataFromURLsSuccess = "GetDataFromURLs success test"
 
 	getDataFromURLsFail = "GetDataFromURLs fail test"
 
-	serviceMethodGetDataFromURLs = "GetDataFromURLs"
+	getDataFromURLsPartial = "GetDataFromURLs partial results test"
+
+	getDataFromURLsV2Success = "GetDataFromURLs v2 success test"
+
+	streamDataFromURLsSuccess = "StreamDataFromURLs success test"
+
+	streamDataFromURLsFail = "StreamDataFromURLs fail test"
+
+	createJobSuccess = "CreateJob success test"
+
+	getJobFail = "GetJob fail test"
+
+	replayWebhookDeliverySuccess = "ReplayWebhookDelivery success test"
+
+	getSnapshotsSuccess = "GetSnapshots success test"
+
+	getSnapshotFail = "GetSnapshot fail test"
+
+	getSnapshotsDiffSuccess = "GetSnapshotsDiff success test"
+
+	serviceMethodGetDataFromURLsWithOptions = "GetDataFromURLsWithOptions"
+	serviceMethodStreamDataFromURLs         = "StreamDataFromURLs"
+	serviceMethodCreateJob                  = "CreateJob"
+	serviceMethodGetJob                     = "GetJob"
+	serviceMethodReplayWebhookDelivery      = "ReplayWebhookDelivery"
+	serviceMethodGetSnapshots               = "GetSnapshots"
+	serviceMethodGetSnapshot                = "GetSnapshot"
+	serviceMethodGetSnapshotsDiff           = "GetSnapshotsDiff"
 
 	ozonURL  = "http://ozon.ru"
 	wikiURL  = "https://ru.wikipedia.org"
 	siteData = "html"
 	fail     = "fail"
+	jobID    = "5fc8b1a8e4b0a1b2c3d4e5f6"
 )
 
 var (
@@ -55,7 +85,7 @@ func TestClient_GetDataFromURLsSuccess(t *testing.T) {
 	}
 	t.Run(getDataFromURLsSuccess, func(t *testing.T) {
 		serviceMock := new(sitesdataservice.MockService)
-		serviceMock.On(serviceMethodGetDataFromURLs, context.Background(), urls).
+		serviceMock.On(serviceMethodGetDataFromURLsWithOptions, context.Background(), api.SiteRequestsFromURLs(urls), &api.FetchOptions{}).
 			Return(response, nilError).
 			Once()
 		server, client := makeServerClient(serverAddr, serviceMock)
@@ -77,7 +107,7 @@ func TestClient_GetDataFromURLsFail(t *testing.T) {
 	var response []*api.SiteData
 	t.Run(getDataFromURLsFail, func(t *testing.T) {
 		serviceMock := new(sitesdataservice.MockService)
-		serviceMock.On(serviceMethodGetDataFromURLs, context.Background(), urls).
+		serviceMock.On(serviceMethodGetDataFromURLsWithOptions, context.Background(), api.SiteRequestsFromURLs(urls), &api.FetchOptions{}).
 			Return(response, httperror.NewError(http.StatusBadRequest, fail, fail)).
 			Once()
 		server, client := makeServerClient(serverAddr, serviceMock)
@@ -94,9 +124,372 @@ func TestClient_GetDataFromURLsFail(t *testing.T) {
 	})
 }
 
+func TestClient_GetDataFromURLsPartial(t *testing.T) {
+	urls := []string{ozonURL, wikiURL}
+	options := &api.FetchOptions{FailureMode: api.FailureModePartial}
+	response := []*api.SiteData{
+		{
+			URL:    ozonURL,
+			Status: api.SiteDataStatusError,
+			Error: &api.SiteDataError{
+				Code:    http.StatusBadGateway,
+				Message: fail,
+			},
+		},
+		{
+			URL:    wikiURL,
+			Data:   siteData,
+			Status: api.SiteDataStatusOK,
+		},
+	}
+	t.Run(getDataFromURLsPartial, func(t *testing.T) {
+		serviceMock := new(sitesdataservice.MockService)
+		serviceMock.On(serviceMethodGetDataFromURLsWithOptions, context.Background(), api.SiteRequestsFromURLs(urls), options).
+			Return(response, nilError).
+			Once()
+		server, client := makeServerClient(serverAddr, serviceMock)
+		defer func() {
+			err := server.Shutdown()
+			if err != nil {
+				log.Printf("server shut down err: %v", err)
+			}
+		}()
+		time.Sleep(serverLaunchingWaitSleep)
+		resp, err := client.GetDataFromURLsWithOptions(context.Background(), api.SiteRequestsFromURLs(urls), options)
+		assert.Equal(t, response, resp)
+		assert.NoError(t, err, "unexpected error:", err)
+	})
+}
+
+func TestClientV2_GetDataFromURLsSuccess(t *testing.T) {
+	request := &api.GetDataFromURLsRequest{
+		URLs: []*api.SiteRequest{
+			{URL: ozonURL},
+			{
+				URL:     wikiURL,
+				Method:  http.MethodPost,
+				Headers: map[string]string{"Authorization": "Bearer token"},
+				Body:    `{"query":"html"}`,
+			},
+		},
+		Options: &api.FetchOptions{
+			FailureMode:   api.FailureModePartial,
+			TimeoutMs:     300,
+			MaxCacheAgeMs: 5000,
+			Headers:       map[string]string{"Accept-Language": "ru"},
+			Fields:        []string{api.SiteDataFieldData},
+		},
+	}
+	response := []*api.SiteData{
+		{
+			URL:  ozonURL,
+			Data: siteData,
+		},
+		{
+			URL:  wikiURL,
+			Data: siteData,
+		},
+	}
+	t.Run(getDataFromURLsV2Success, func(t *testing.T) {
+		serviceMock := new(sitesdataservice.MockService)
+		serviceMock.On(serviceMethodGetDataFromURLsWithOptions, context.Background(), request.URLs, request.Options).
+			Return(response, nilError).
+			Once()
+		server, _ := makeServerClient(serverAddr, serviceMock)
+		defer func() {
+			err := server.Shutdown()
+			if err != nil {
+				log.Printf("server shut down err: %v", err)
+			}
+		}()
+		client := NewPreparedClientV2(serverAddr, hostAddr, maxConns)
+		time.Sleep(serverLaunchingWaitSleep)
+		resp, err := client.GetDataFromURLs(context.Background(), request)
+		assert.Equal(t, &api.GetDataFromURLsResponse{Results: response}, resp)
+		assert.NoError(t, err, "unexpected error:", err)
+	})
+}
+
+func TestClient_StreamDataFromURLsSuccess(t *testing.T) {
+	urls := []string{ozonURL, wikiURL}
+	options := &api.FetchOptions{FailureMode: api.FailureModePartial}
+	response := []*api.SiteData{
+		{
+			URL:    wikiURL,
+			Data:   siteData,
+			Status: api.SiteDataStatusOK,
+		},
+		{
+			URL:    ozonURL,
+			Data:   siteData,
+			Status: api.SiteDataStatusOK,
+		},
+	}
+	t.Run(streamDataFromURLsSuccess, func(t *testing.T) {
+		serviceMock := new(sitesdataservice.MockService)
+		serviceMock.On(serviceMethodStreamDataFromURLs, context.Background(), api.SiteRequestsFromURLs(urls), options).
+			Return(response, nilError).
+			Once()
+		server, client := makeServerClient(serverAddr, serviceMock)
+		defer func() {
+			err := server.Shutdown()
+			if err != nil {
+				log.Printf("server shut down err: %v", err)
+			}
+		}()
+		time.Sleep(serverLaunchingWaitSleep)
+		var resp []*api.SiteData
+		err := client.StreamDataFromURLs(context.Background(), api.SiteRequestsFromURLs(urls), options, func(siteData *api.SiteData) error {
+			resp = append(resp, siteData)
+			return nil
+		})
+		assert.Equal(t, response, resp)
+		assert.NoError(t, err, "unexpected error:", err)
+	})
+}
+
+func TestClient_StreamDataFromURLsFail(t *testing.T) {
+	urls := []string{ozonURL, wikiURL}
+	var response []*api.SiteData
+	t.Run(streamDataFromURLsFail, func(t *testing.T) {
+		serviceMock := new(sitesdataservice.MockService)
+		serviceMock.On(serviceMethodStreamDataFromURLs, context.Background(), api.SiteRequestsFromURLs(urls), &api.FetchOptions{}).
+			Return(response, httperror.NewError(http.StatusBadRequest, fail, fail)).
+			Once()
+		server, client := makeServerClient(serverAddr, serviceMock)
+		defer func() {
+			err := server.Shutdown()
+			if err != nil {
+				log.Printf("server shut down err: %v", err)
+			}
+		}()
+		time.Sleep(serverLaunchingWaitSleep)
+		var resp []*api.SiteData
+		err := client.StreamDataFromURLs(context.Background(), api.SiteRequestsFromURLs(urls), nil, func(siteData *api.SiteData) error {
+			resp = append(resp, siteData)
+			return nil
+		})
+		assert.Equal(t, response, resp)
+		assert.Equal(t, err, httperror.NewError(http.StatusBadRequest, fail, ""))
+	})
+}
+
+func TestClient_CreateJobSuccess(t *testing.T) {
+	request := &api.CreateJobRequest{
+		URLs:    []string{ozonURL, wikiURL},
+		Options: &api.FetchOptions{FailureMode: api.FailureModePartial},
+	}
+	response := &api.Job{
+		ID:        jobID,
+		Status:    api.JobStatusPending,
+		Total:     len(request.URLs),
+		CreatedAt: time.Unix(0, 0).UTC(),
+		UpdatedAt: time.Unix(0, 0).UTC(),
+	}
+	t.Run(createJobSuccess, func(t *testing.T) {
+		serviceMock := new(sitesdataservice.MockService)
+		serviceMock.On(serviceMethodCreateJob, context.Background(), request).
+			Return(response, nilError).
+			Once()
+		server, client := makeServerClient(serverAddr, serviceMock)
+		defer func() {
+			err := server.Shutdown()
+			if err != nil {
+				log.Printf("server shut down err: %v", err)
+			}
+		}()
+		time.Sleep(serverLaunchingWaitSleep)
+		resp, err := client.CreateJob(context.Background(), request)
+		assert.Equal(t, response, resp)
+		assert.NoError(t, err, "unexpected error:", err)
+	})
+}
+
+func TestClient_GetJobFail(t *testing.T) {
+	var response *api.GetJobResponse
+	t.Run(getJobFail, func(t *testing.T) {
+		serviceMock := new(sitesdataservice.MockService)
+		serviceMock.On(serviceMethodGetJob, context.Background(), jobID, 10, 5).
+			Return(response, httperror.NewError(http.StatusNotFound, fail, fail)).
+			Once()
+		server, client := makeServerClient(serverAddr, serviceMock)
+		defer func() {
+			err := server.Shutdown()
+			if err != nil {
+				log.Printf("server shut down err: %v", err)
+			}
+		}()
+		time.Sleep(serverLaunchingWaitSleep)
+		resp, err := client.GetJob(context.Background(), jobID, 10, 5)
+		assert.Equal(t, response, resp)
+		assert.Equal(t, err, httperror.NewError(http.StatusNotFound, fail, ""))
+	})
+}
+
+func TestClient_ReplayWebhookDeliverySuccess(t *testing.T) {
+	response := &api.WebhookDelivery{
+		ID:        jobID,
+		URL:       ozonURL,
+		Status:    api.WebhookDeliveryStatusDelivered,
+		Attempts:  2,
+		Payload:   []byte(`{"job":{}}`),
+		CreatedAt: time.Unix(0, 0).UTC(),
+		UpdatedAt: time.Unix(0, 0).UTC(),
+	}
+	t.Run(replayWebhookDeliverySuccess, func(t *testing.T) {
+		serviceMock := new(sitesdataservice.MockService)
+		serviceMock.On(serviceMethodReplayWebhookDelivery, context.Background(), jobID).
+			Return(response, nilError).
+			Once()
+		server, client := makeAdminServerClient(serverAddr, serviceMock)
+		defer func() {
+			err := server.Shutdown()
+			if err != nil {
+				log.Printf("server shut down err: %v", err)
+			}
+		}()
+		time.Sleep(serverLaunchingWaitSleep)
+		resp, err := client.ReplayWebhookDelivery(context.Background(), jobID)
+		assert.Equal(t, response, resp)
+		assert.NoError(t, err, "unexpected error:", err)
+	})
+}
+
+func TestClient_ReplayWebhookDeliveryNotOnPublicServer(t *testing.T) {
+	serviceMock := new(sitesdataservice.MockService)
+	server, client := makeServerClient(serverAddr, serviceMock)
+	defer func() {
+		err := server.Shutdown()
+		if err != nil {
+			log.Printf("server shut down err: %v", err)
+		}
+	}()
+	time.Sleep(serverLaunchingWaitSleep)
+	_, err := client.ReplayWebhookDelivery(context.Background(), jobID)
+	if assert.Error(t, err) {
+		assert.Equal(t, http.StatusNotFound, err.(*httperror.Error).StatusCode())
+	}
+	serviceMock.AssertNotCalled(t, serviceMethodReplayWebhookDelivery, context.Background(), jobID)
+}
+
+func TestClient_GetSnapshotsSuccess(t *testing.T) {
+	request := &api.GetSnapshotsRequest{
+		URL:    ozonURL,
+		From:   time.Unix(1600000000, 0).UTC(),
+		To:     time.Unix(1700000000, 0).UTC(),
+		Cursor: "cursor",
+		Limit:  2,
+	}
+	response := &api.GetSnapshotsResponse{
+		Snapshots: []*api.Snapshot{
+			{
+				ID:          jobID,
+				URL:         ozonURL,
+				CreatedAt:   time.Unix(1650000000, 0).UTC(),
+				ContentHash: "hash",
+				Meta:        &api.SiteDataMeta{StatusCode: http.StatusOK, FetchedAt: time.Unix(1650000000, 0).UTC()},
+			},
+		},
+		NextCursor: "next",
+		Limit:      2,
+	}
+	t.Run(getSnapshotsSuccess, func(t *testing.T) {
+		serviceMock := new(sitesdataservice.MockService)
+		serviceMock.On(serviceMethodGetSnapshots, context.Background(), request).
+			Return(response, nilError).
+			Once()
+		server, client := makeServerClient(serverAddr, serviceMock)
+		defer func() {
+			err := server.Shutdown()
+			if err != nil {
+				log.Printf("server shut down err: %v", err)
+			}
+		}()
+		time.Sleep(serverLaunchingWaitSleep)
+		resp, err := client.GetSnapshots(context.Background(), request)
+		assert.Equal(t, response, resp)
+		assert.NoError(t, err, "unexpected error:", err)
+	})
+}
+
+func TestClient_GetSnapshotFail(t *testing.T) {
+	var response *api.Snapshot
+	t.Run(getSnapshotFail, func(t *testing.T) {
+		serviceMock := new(sitesdataservice.MockService)
+		serviceMock.On(serviceMethodGetSnapshot, context.Background(), jobID).
+			Return(response, httperror.NewError(http.StatusNotFound, fail, fail)).
+			Once()
+		server, client := makeServerClient(serverAddr, serviceMock)
+		defer func() {
+			err := server.Shutdown()
+			if err != nil {
+				log.Printf("server shut down err: %v", err)
+			}
+		}()
+		time.Sleep(serverLaunchingWaitSleep)
+		resp, err := client.GetSnapshot(context.Background(), jobID)
+		assert.Equal(t, response, resp)
+		assert.Equal(t, err, httperror.NewError(http.StatusNotFound, fail, ""))
+	})
+}
+
+func TestClient_GetSnapshotsDiffSuccess(t *testing.T) {
+	request := &api.GetSnapshotsDiffRequest{
+		URL:    ozonURL,
+		From:   time.Unix(1600000000, 0).UTC(),
+		To:     time.Unix(1700000000, 0).UTC(),
+		Format: api.DiffFormatJSON,
+	}
+	response := &api.SnapshotsDiff{
+		From:   &api.Snapshot{ID: jobID, URL: ozonURL, CreatedAt: time.Unix(1600000000, 0).UTC(), ContentHash: "from"},
+		To:     &api.Snapshot{ID: jobID, URL: ozonURL, CreatedAt: time.Unix(1700000000, 0).UTC(), ContentHash: "to"},
+		Format: api.DiffFormatJSON,
+		Changes: []*api.JSONChange{
+			{Op: api.JSONChangeReplace, Path: "/price", OldValue: json.RawMessage(`100`), NewValue: json.RawMessage(`120`)},
+			{Op: api.JSONChangeAdd, Path: "/sale", NewValue: json.RawMessage(`true`)},
+		},
+	}
+	t.Run(getSnapshotsDiffSuccess, func(t *testing.T) {
+		serviceMock := new(sitesdataservice.MockService)
+		serviceMock.On(serviceMethodGetSnapshotsDiff, context.Background(), request).
+			Return(response, nilError).
+			Once()
+		server, client := makeServerClient(serverAddr, serviceMock)
+		defer func() {
+			err := server.Shutdown()
+			if err != nil {
+				log.Printf("server shut down err: %v", err)
+			}
+		}()
+		time.Sleep(serverLaunchingWaitSleep)
+		resp, err := client.GetSnapshotsDiff(context.Background(), request)
+		assert.Equal(t, response, resp)
+		assert.NoError(t, err, "unexpected error:", err)
+	})
+}
+
 func makeServerClient(serverAddr string, svc svc.Service) (server *fasthttp.Server, client svc.Service) {
 	client = NewPreparedClient(serverAddr, hostAddr, maxConns)
-	router := httpserver.NewPreparedServer(svc)
+	router := httpserver.NewPreparedServer(svc, handlerTimeout)
+	server = &fasthttp.Server{
+		Handler:            router.Handler,
+		MaxRequestBodySize: maxRequestBodySize,
+		ReadTimeout:        serverTimeout,
+	}
+	go func() {
+		err := server.ListenAndServe(serverAddr)
+		if err != nil {
+			log.Printf("server shut down err: %v", err)
+		}
+	}()
+
+	return
+}
+
+// makeAdminServerClient запускает служебный сервер: ручки вебхуков не отдаются публичным роутером
+func makeAdminServerClient(serverAddr string, svc svc.Service) (server *fasthttp.Server, client svc.Service) {
+	client = NewPreparedClient(serverAddr, hostAddr, maxConns)
+	router := httpserver.NewPreparedAdminServer(svc)
 	server = &fasthttp.Server{
 		Handler:            router.Handler,
 		MaxRequestBodySize: maxRequestBodySize,
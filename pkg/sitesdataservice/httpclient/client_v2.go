@@ -0,0 +1,70 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/valyala/fasthttp"
+
+	svc "github.com/mts-test-task/pkg/sitesdataservice"
+	"github.com/mts-test-task/pkg/sitesdataservice/api"
+	"github.com/mts-test-task/pkg/sitesdataservice/httperror"
+)
+
+type clientV2 struct {
+	cli *fasthttp.HostClient
+
+	transportGetDataFromURLs GetDataFromURLsV2ClientTransport
+}
+
+// GetDataFromURLs ...
+func (s *clientV2) GetDataFromURLs(ctx context.Context, request *api.GetDataFromURLsRequest) (response *api.GetDataFromURLsResponse, err error) {
+	req, res := fasthttp.AcquireRequest(), fasthttp.AcquireResponse()
+	defer func() {
+		fasthttp.ReleaseRequest(req)
+		fasthttp.ReleaseResponse(res)
+	}()
+
+	if err = s.transportGetDataFromURLs.EncodeRequest(ctx, req, request); err != nil {
+		return
+	}
+
+	err = s.cli.Do(req, res)
+	if err != nil {
+		return
+	}
+	return s.transportGetDataFromURLs.DecodeResponse(ctx, res)
+}
+
+// NewClientV2 the v2 client creator
+func NewClientV2(
+	cli *fasthttp.HostClient,
+	transportGetDataFromURLs GetDataFromURLsV2ClientTransport,
+) svc.ServiceV2 {
+	return &clientV2{
+		cli:                      cli,
+		transportGetDataFromURLs: transportGetDataFromURLs,
+	}
+}
+
+// NewPreparedClientV2 create and set up v2 http client
+func NewPreparedClientV2(
+	serverURL string,
+	serverHost string,
+	maxConns int,
+) svc.ServiceV2 {
+	errorProcessor := httperror.NewErrorProcessor(http.StatusInternalServerError, "Внутряння ошибка сервиса")
+	transportGetDataFromURLs := NewGetDataFromURLsV2ClientTransport(
+		errorProcessor,
+		MethodHTTP+serverURL+URIPathClientGetDataFromURLsV2,
+		HTTPMethodClientGetDataFromURLsV2,
+	)
+
+	return NewClientV2(
+		&fasthttp.HostClient{
+			Addr:     serverHost,
+			MaxConns: maxConns,
+		},
+		transportGetDataFromURLs,
+	)
+}
@@ -0,0 +1,147 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/mts-test-task/pkg/sitesdataservice/api"
+)
+
+// CreateJobClientTransport transport interface
+type CreateJobClientTransport interface {
+	EncodeRequest(ctx context.Context, r *fasthttp.Request, request *api.CreateJobRequest) (err error)
+	DecodeResponse(ctx context.Context, r *fasthttp.Response) (job *api.Job, err error)
+}
+
+type createJobClientTransport struct {
+	errorProcessor errorProcessor
+	pathTemplate   string
+	method         string
+}
+
+// EncodeRequest method for encoding requests on client side
+func (c *createJobClientTransport) EncodeRequest(ctx context.Context, r *fasthttp.Request, request *api.CreateJobRequest) (err error) {
+	r.Header.SetMethod(c.method)
+	r.SetRequestURI(c.pathTemplate)
+	r.Header.Set("Content-Type", "application/json")
+	return json.NewEncoder(r.BodyWriter()).Encode(request)
+}
+
+// DecodeResponse method for decoding response on client side
+func (c *createJobClientTransport) DecodeResponse(ctx context.Context, r *fasthttp.Response) (job *api.Job, err error) {
+	if r.StatusCode() != http.StatusAccepted {
+		err = c.errorProcessor.Decode(r)
+		return
+	}
+	err = json.Unmarshal(r.Body(), &job)
+	return
+}
+
+// NewCreateJobClientTransport the transport creator for http requests
+func NewCreateJobClientTransport(
+	errorProcessor errorProcessor,
+	pathTemplate string,
+	method string,
+) CreateJobClientTransport {
+	return &createJobClientTransport{
+		errorProcessor: errorProcessor,
+		pathTemplate:   pathTemplate,
+		method:         method,
+	}
+}
+
+// GetJobClientTransport transport interface
+type GetJobClientTransport interface {
+	EncodeRequest(ctx context.Context, r *fasthttp.Request, id string, offset int, limit int) (err error)
+	DecodeResponse(ctx context.Context, r *fasthttp.Response) (response *api.GetJobResponse, err error)
+}
+
+type getJobClientTransport struct {
+	errorProcessor errorProcessor
+	pathTemplate   string
+	method         string
+}
+
+// EncodeRequest method for encoding requests on client side
+func (g *getJobClientTransport) EncodeRequest(ctx context.Context, r *fasthttp.Request, id string, offset int, limit int) (err error) {
+	r.Header.SetMethod(g.method)
+	r.SetRequestURI(fmt.Sprintf(g.pathTemplate, url.PathEscape(id)))
+	if offset > 0 {
+		r.URI().QueryArgs().Set(QueryArgOffset, strconv.Itoa(offset))
+	}
+	if limit > 0 {
+		r.URI().QueryArgs().Set(QueryArgLimit, strconv.Itoa(limit))
+	}
+	return
+}
+
+// DecodeResponse method for decoding response on client side
+func (g *getJobClientTransport) DecodeResponse(ctx context.Context, r *fasthttp.Response) (response *api.GetJobResponse, err error) {
+	if r.StatusCode() != http.StatusOK {
+		err = g.errorProcessor.Decode(r)
+		return
+	}
+	err = json.Unmarshal(r.Body(), &response)
+	return
+}
+
+// NewGetJobClientTransport the transport creator for http requests
+func NewGetJobClientTransport(
+	errorProcessor errorProcessor,
+	pathTemplate string,
+	method string,
+) GetJobClientTransport {
+	return &getJobClientTransport{
+		errorProcessor: errorProcessor,
+		pathTemplate:   pathTemplate,
+		method:         method,
+	}
+}
+
+// CancelJobClientTransport transport interface
+type CancelJobClientTransport interface {
+	EncodeRequest(ctx context.Context, r *fasthttp.Request, id string) (err error)
+	DecodeResponse(ctx context.Context, r *fasthttp.Response) (job *api.Job, err error)
+}
+
+type cancelJobClientTransport struct {
+	errorProcessor errorProcessor
+	pathTemplate   string
+	method         string
+}
+
+// EncodeRequest method for encoding requests on client side
+func (c *cancelJobClientTransport) EncodeRequest(ctx context.Context, r *fasthttp.Request, id string) (err error) {
+	r.Header.SetMethod(c.method)
+	r.SetRequestURI(fmt.Sprintf(c.pathTemplate, url.PathEscape(id)))
+	return
+}
+
+// DecodeResponse method for decoding response on client side
+func (c *cancelJobClientTransport) DecodeResponse(ctx context.Context, r *fasthttp.Response) (job *api.Job, err error) {
+	if r.StatusCode() != http.StatusOK {
+		err = c.errorProcessor.Decode(r)
+		return
+	}
+	err = json.Unmarshal(r.Body(), &job)
+	return
+}
+
+// NewCancelJobClientTransport the transport creator for http requests
+func NewCancelJobClientTransport(
+	errorProcessor errorProcessor,
+	pathTemplate string,
+	method string,
+) CancelJobClientTransport {
+	return &cancelJobClientTransport{
+		errorProcessor: errorProcessor,
+		pathTemplate:   pathTemplate,
+		method:         method,
+	}
+}
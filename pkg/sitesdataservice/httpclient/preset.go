@@ -2,10 +2,55 @@ package httpclient
 
 // URIs
 const (
-	URIPrefix  = "/api/v1"
-	MethodHTTP = "http://"
+	URIPrefix   = "/api/v1"
+	URIPrefixV2 = "/api/v2"
+	MethodHTTP  = "http://"
 
 	URIPathClientGetDataFromURLs = URIPrefix + "/urls/data"
 
 	HTTPMethodClientGetDataFromURLs = "POST"
+
+	URIPathClientGetDataFromURLsV2 = URIPrefixV2 + "/urls/data"
+
+	HTTPMethodClientGetDataFromURLsV2 = "POST"
+
+	URIPathClientJobs = URIPrefix + "/jobs"
+	URIPathClientJob  = URIPathClientJobs + "/%s"
+
+	HTTPMethodClientCreateJob = "POST"
+	HTTPMethodClientGetJob    = "GET"
+	HTTPMethodClientCancelJob = "DELETE"
+
+	URIPathClientWebhookDeliveries     = URIPrefix + "/webhooks/deliveries"
+	URIPathClientReplayWebhookDelivery = URIPathClientWebhookDeliveries + "/%s/replay"
+
+	HTTPMethodClientGetFailedWebhookDeliveries = "GET"
+	HTTPMethodClientReplayWebhookDelivery      = "POST"
+
+	URIPathClientSnapshots     = URIPrefix + "/snapshots"
+	URIPathClientSnapshot      = URIPathClientSnapshots + "/%s"
+	URIPathClientSnapshotsDiff = URIPrefix + "/diff"
+
+	HTTPMethodClientGetSnapshots     = "GET"
+	HTTPMethodClientGetSnapshot      = "GET"
+	HTTPMethodClientGetSnapshotsDiff = "GET"
+)
+
+// Content types
+const (
+	ContentTypeNDJSON = "application/x-ndjson"
+)
+
+// Query args
+const (
+	QueryArgFailureMode = "failure_mode"
+	QueryArgOffset      = "offset"
+	QueryArgLimit       = "limit"
+	QueryArgURL         = "url"
+	QueryArgFrom        = "from"
+	QueryArgTo          = "to"
+	QueryArgCursor      = "cursor"
+	QueryArgFromID      = "from_id"
+	QueryArgToID        = "to_id"
+	QueryArgFormat      = "format"
 )
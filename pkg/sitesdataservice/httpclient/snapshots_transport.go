@@ -0,0 +1,174 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/mts-test-task/pkg/sitesdataservice/api"
+)
+
+// GetSnapshotsClientTransport transport interface
+type GetSnapshotsClientTransport interface {
+	EncodeRequest(ctx context.Context, r *fasthttp.Request, request *api.GetSnapshotsRequest) (err error)
+	DecodeResponse(ctx context.Context, r *fasthttp.Response) (response *api.GetSnapshotsResponse, err error)
+}
+
+type getSnapshotsClientTransport struct {
+	errorProcessor errorProcessor
+	pathTemplate   string
+	method         string
+}
+
+// EncodeRequest method for encoding requests on client side
+func (g *getSnapshotsClientTransport) EncodeRequest(ctx context.Context, r *fasthttp.Request, request *api.GetSnapshotsRequest) (err error) {
+	r.Header.SetMethod(g.method)
+	r.SetRequestURI(g.pathTemplate)
+	args := r.URI().QueryArgs()
+	args.Set(QueryArgURL, request.URL)
+	if !request.From.IsZero() {
+		args.Set(QueryArgFrom, request.From.Format(time.RFC3339))
+	}
+	if !request.To.IsZero() {
+		args.Set(QueryArgTo, request.To.Format(time.RFC3339))
+	}
+	if request.Cursor != "" {
+		args.Set(QueryArgCursor, request.Cursor)
+	}
+	if request.Limit > 0 {
+		args.Set(QueryArgLimit, strconv.Itoa(request.Limit))
+	}
+	return
+}
+
+// DecodeResponse method for decoding response on client side
+func (g *getSnapshotsClientTransport) DecodeResponse(ctx context.Context, r *fasthttp.Response) (response *api.GetSnapshotsResponse, err error) {
+	if r.StatusCode() != http.StatusOK {
+		err = g.errorProcessor.Decode(r)
+		return
+	}
+	err = json.Unmarshal(r.Body(), &response)
+	return
+}
+
+// NewGetSnapshotsClientTransport the transport creator for http requests
+func NewGetSnapshotsClientTransport(
+	errorProcessor errorProcessor,
+	pathTemplate string,
+	method string,
+) GetSnapshotsClientTransport {
+	return &getSnapshotsClientTransport{
+		errorProcessor: errorProcessor,
+		pathTemplate:   pathTemplate,
+		method:         method,
+	}
+}
+
+// GetSnapshotClientTransport transport interface
+type GetSnapshotClientTransport interface {
+	EncodeRequest(ctx context.Context, r *fasthttp.Request, id string) (err error)
+	DecodeResponse(ctx context.Context, r *fasthttp.Response) (snapshot *api.Snapshot, err error)
+}
+
+type getSnapshotClientTransport struct {
+	errorProcessor errorProcessor
+	pathTemplate   string
+	method         string
+}
+
+// EncodeRequest method for encoding requests on client side
+func (g *getSnapshotClientTransport) EncodeRequest(ctx context.Context, r *fasthttp.Request, id string) (err error) {
+	r.Header.SetMethod(g.method)
+	r.SetRequestURI(fmt.Sprintf(g.pathTemplate, url.PathEscape(id)))
+	return
+}
+
+// DecodeResponse method for decoding response on client side
+func (g *getSnapshotClientTransport) DecodeResponse(ctx context.Context, r *fasthttp.Response) (snapshot *api.Snapshot, err error) {
+	if r.StatusCode() != http.StatusOK {
+		err = g.errorProcessor.Decode(r)
+		return
+	}
+	err = json.Unmarshal(r.Body(), &snapshot)
+	return
+}
+
+// NewGetSnapshotClientTransport the transport creator for http requests
+func NewGetSnapshotClientTransport(
+	errorProcessor errorProcessor,
+	pathTemplate string,
+	method string,
+) GetSnapshotClientTransport {
+	return &getSnapshotClientTransport{
+		errorProcessor: errorProcessor,
+		pathTemplate:   pathTemplate,
+		method:         method,
+	}
+}
+
+// GetSnapshotsDiffClientTransport transport interface
+type GetSnapshotsDiffClientTransport interface {
+	EncodeRequest(ctx context.Context, r *fasthttp.Request, request *api.GetSnapshotsDiffRequest) (err error)
+	DecodeResponse(ctx context.Context, r *fasthttp.Response) (diff *api.SnapshotsDiff, err error)
+}
+
+type getSnapshotsDiffClientTransport struct {
+	errorProcessor errorProcessor
+	pathTemplate   string
+	method         string
+}
+
+// EncodeRequest method for encoding requests on client side
+func (g *getSnapshotsDiffClientTransport) EncodeRequest(ctx context.Context, r *fasthttp.Request, request *api.GetSnapshotsDiffRequest) (err error) {
+	r.Header.SetMethod(g.method)
+	r.SetRequestURI(g.pathTemplate)
+	args := r.URI().QueryArgs()
+	if request.FromID != "" {
+		args.Set(QueryArgFromID, request.FromID)
+	}
+	if request.ToID != "" {
+		args.Set(QueryArgToID, request.ToID)
+	}
+	if request.URL != "" {
+		args.Set(QueryArgURL, request.URL)
+	}
+	if !request.From.IsZero() {
+		args.Set(QueryArgFrom, request.From.Format(time.RFC3339))
+	}
+	if !request.To.IsZero() {
+		args.Set(QueryArgTo, request.To.Format(time.RFC3339))
+	}
+	if request.Format != "" {
+		args.Set(QueryArgFormat, request.Format)
+	}
+	return
+}
+
+// DecodeResponse method for decoding response on client side
+func (g *getSnapshotsDiffClientTransport) DecodeResponse(ctx context.Context, r *fasthttp.Response) (diff *api.SnapshotsDiff, err error) {
+	if r.StatusCode() != http.StatusOK {
+		err = g.errorProcessor.Decode(r)
+		return
+	}
+	err = json.Unmarshal(r.Body(), &diff)
+	return
+}
+
+// NewGetSnapshotsDiffClientTransport the transport creator for http requests
+func NewGetSnapshotsDiffClientTransport(
+	errorProcessor errorProcessor,
+	pathTemplate string,
+	method string,
+) GetSnapshotsDiffClientTransport {
+	return &getSnapshotsDiffClientTransport{
+		errorProcessor: errorProcessor,
+		pathTemplate:   pathTemplate,
+		method:         method,
+	}
+}
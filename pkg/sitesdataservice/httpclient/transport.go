@@ -1,8 +1,11 @@
 package httpclient
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"io"
+	"io/ioutil"
 	"net/http"
 
 	"github.com/valyala/fasthttp"
@@ -17,7 +20,7 @@ type errorProcessor interface {
 
 // GetDataFromURLsClientTransport transport interface
 type GetDataFromURLsClientTransport interface {
-	EncodeRequest(ctx context.Context, r *fasthttp.Request, urls []string) (err error)
+	EncodeRequest(ctx context.Context, r *fasthttp.Request, requests []*api.SiteRequest, options *api.FetchOptions) (err error)
 	DecodeResponse(ctx context.Context, r *fasthttp.Response) (response []*api.SiteData, err error)
 }
 
@@ -28,11 +31,14 @@ type getDataFromURLsClientTransport struct {
 }
 
 // EncodeRequest method for encoding requests on client side
-func (g *getDataFromURLsClientTransport) EncodeRequest(ctx context.Context, r *fasthttp.Request, urls []string) (err error) {
+func (g *getDataFromURLsClientTransport) EncodeRequest(ctx context.Context, r *fasthttp.Request, requests []*api.SiteRequest, options *api.FetchOptions) (err error) {
 	r.Header.SetMethod(g.method)
 	r.SetRequestURI(g.pathTemplate)
+	if options != nil && options.FailureMode != "" {
+		r.URI().QueryArgs().Set(QueryArgFailureMode, options.FailureMode)
+	}
 	r.Header.Set("Content-Type", "application/json")
-	return json.NewEncoder(r.BodyWriter()).Encode(urls)
+	return json.NewEncoder(r.BodyWriter()).Encode(requests)
 }
 
 // DecodeResponse method for decoding response on client side
@@ -57,3 +63,78 @@ func NewGetDataFromURLsClientTransport(
 		method:         method,
 	}
 }
+
+// StreamDataFromURLsClientTransport transport interface
+type StreamDataFromURLsClientTransport interface {
+	EncodeRequest(ctx context.Context, requests []*api.SiteRequest, options *api.FetchOptions) (r *http.Request, err error)
+	DecodeResponse(ctx context.Context, r *http.Response, handle func(siteData *api.SiteData) error) (err error)
+}
+
+type streamDataFromURLsClientTransport struct {
+	errorProcessor errorProcessor
+	pathTemplate   string
+	method         string
+}
+
+// EncodeRequest method for encoding requests on client side
+func (s *streamDataFromURLsClientTransport) EncodeRequest(ctx context.Context, requests []*api.SiteRequest, options *api.FetchOptions) (r *http.Request, err error) {
+	body, err := json.Marshal(requests)
+	if err != nil {
+		return
+	}
+
+	r, err = http.NewRequestWithContext(ctx, s.method, s.pathTemplate, bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	if options != nil && options.FailureMode != "" {
+		query := r.URL.Query()
+		query.Set(QueryArgFailureMode, options.FailureMode)
+		r.URL.RawQuery = query.Encode()
+	}
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Accept", ContentTypeNDJSON)
+	return
+}
+
+// DecodeResponse method for decoding NDJSON stream on client side, handle is called for every line
+func (s *streamDataFromURLsClientTransport) DecodeResponse(ctx context.Context, r *http.Response, handle func(siteData *api.SiteData) error) (err error) {
+	if r.StatusCode != http.StatusOK {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return err
+		}
+		res := fasthttp.AcquireResponse()
+		defer fasthttp.ReleaseResponse(res)
+		res.SetStatusCode(r.StatusCode)
+		res.SetBody(body)
+		return s.errorProcessor.Decode(res)
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	for {
+		var siteData api.SiteData
+		if err = decoder.Decode(&siteData); err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return
+		}
+		if err = handle(&siteData); err != nil {
+			return
+		}
+	}
+}
+
+// NewStreamDataFromURLsClientTransport the transport creator for streaming http requests
+func NewStreamDataFromURLsClientTransport(
+	errorProcessor errorProcessor,
+	pathTemplate string,
+	method string,
+) StreamDataFromURLsClientTransport {
+	return &streamDataFromURLsClientTransport{
+		errorProcessor: errorProcessor,
+		pathTemplate:   pathTemplate,
+		method:         method,
+	}
+}
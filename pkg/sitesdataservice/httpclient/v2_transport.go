@@ -0,0 +1,54 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/mts-test-task/pkg/sitesdataservice/api"
+)
+
+// GetDataFromURLsV2ClientTransport transport interface
+type GetDataFromURLsV2ClientTransport interface {
+	EncodeRequest(ctx context.Context, r *fasthttp.Request, request *api.GetDataFromURLsRequest) (err error)
+	DecodeResponse(ctx context.Context, r *fasthttp.Response) (response *api.GetDataFromURLsResponse, err error)
+}
+
+type getDataFromURLsV2ClientTransport struct {
+	errorProcessor errorProcessor
+	pathTemplate   string
+	method         string
+}
+
+// EncodeRequest method for encoding requests on client side
+func (g *getDataFromURLsV2ClientTransport) EncodeRequest(ctx context.Context, r *fasthttp.Request, request *api.GetDataFromURLsRequest) (err error) {
+	r.Header.SetMethod(g.method)
+	r.SetRequestURI(g.pathTemplate)
+	r.Header.Set("Content-Type", "application/json")
+	return json.NewEncoder(r.BodyWriter()).Encode(request)
+}
+
+// DecodeResponse method for decoding response on client side
+func (g *getDataFromURLsV2ClientTransport) DecodeResponse(ctx context.Context, r *fasthttp.Response) (response *api.GetDataFromURLsResponse, err error) {
+	if r.StatusCode() != http.StatusOK {
+		err = g.errorProcessor.Decode(r)
+		return
+	}
+	err = json.Unmarshal(r.Body(), &response)
+	return
+}
+
+// NewGetDataFromURLsV2ClientTransport the transport creator for v2 http requests
+func NewGetDataFromURLsV2ClientTransport(
+	errorProcessor errorProcessor,
+	pathTemplate string,
+	method string,
+) GetDataFromURLsV2ClientTransport {
+	return &getDataFromURLsV2ClientTransport{
+		errorProcessor: errorProcessor,
+		pathTemplate:   pathTemplate,
+		method:         method,
+	}
+}
@@ -0,0 +1,104 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/mts-test-task/pkg/sitesdataservice/api"
+)
+
+// GetFailedWebhookDeliveriesClientTransport transport interface
+type GetFailedWebhookDeliveriesClientTransport interface {
+	EncodeRequest(ctx context.Context, r *fasthttp.Request, offset int, limit int) (err error)
+	DecodeResponse(ctx context.Context, r *fasthttp.Response) (deliveries []*api.WebhookDelivery, err error)
+}
+
+type getFailedWebhookDeliveriesClientTransport struct {
+	errorProcessor errorProcessor
+	pathTemplate   string
+	method         string
+}
+
+// EncodeRequest method for encoding requests on client side
+func (g *getFailedWebhookDeliveriesClientTransport) EncodeRequest(ctx context.Context, r *fasthttp.Request, offset int, limit int) (err error) {
+	r.Header.SetMethod(g.method)
+	r.SetRequestURI(g.pathTemplate)
+	if offset > 0 {
+		r.URI().QueryArgs().Set(QueryArgOffset, strconv.Itoa(offset))
+	}
+	if limit > 0 {
+		r.URI().QueryArgs().Set(QueryArgLimit, strconv.Itoa(limit))
+	}
+	return
+}
+
+// DecodeResponse method for decoding response on client side
+func (g *getFailedWebhookDeliveriesClientTransport) DecodeResponse(ctx context.Context, r *fasthttp.Response) (deliveries []*api.WebhookDelivery, err error) {
+	if r.StatusCode() != http.StatusOK {
+		err = g.errorProcessor.Decode(r)
+		return
+	}
+	err = json.Unmarshal(r.Body(), &deliveries)
+	return
+}
+
+// NewGetFailedWebhookDeliveriesClientTransport the transport creator for http requests
+func NewGetFailedWebhookDeliveriesClientTransport(
+	errorProcessor errorProcessor,
+	pathTemplate string,
+	method string,
+) GetFailedWebhookDeliveriesClientTransport {
+	return &getFailedWebhookDeliveriesClientTransport{
+		errorProcessor: errorProcessor,
+		pathTemplate:   pathTemplate,
+		method:         method,
+	}
+}
+
+// ReplayWebhookDeliveryClientTransport transport interface
+type ReplayWebhookDeliveryClientTransport interface {
+	EncodeRequest(ctx context.Context, r *fasthttp.Request, id string) (err error)
+	DecodeResponse(ctx context.Context, r *fasthttp.Response) (delivery *api.WebhookDelivery, err error)
+}
+
+type replayWebhookDeliveryClientTransport struct {
+	errorProcessor errorProcessor
+	pathTemplate   string
+	method         string
+}
+
+// EncodeRequest method for encoding requests on client side
+func (rw *replayWebhookDeliveryClientTransport) EncodeRequest(ctx context.Context, r *fasthttp.Request, id string) (err error) {
+	r.Header.SetMethod(rw.method)
+	r.SetRequestURI(fmt.Sprintf(rw.pathTemplate, url.PathEscape(id)))
+	return
+}
+
+// DecodeResponse method for decoding response on client side
+func (rw *replayWebhookDeliveryClientTransport) DecodeResponse(ctx context.Context, r *fasthttp.Response) (delivery *api.WebhookDelivery, err error) {
+	if r.StatusCode() != http.StatusOK {
+		err = rw.errorProcessor.Decode(r)
+		return
+	}
+	err = json.Unmarshal(r.Body(), &delivery)
+	return
+}
+
+// NewReplayWebhookDeliveryClientTransport the transport creator for http requests
+func NewReplayWebhookDeliveryClientTransport(
+	errorProcessor errorProcessor,
+	pathTemplate string,
+	method string,
+) ReplayWebhookDeliveryClientTransport {
+	return &replayWebhookDeliveryClientTransport{
+		errorProcessor: errorProcessor,
+		pathTemplate:   pathTemplate,
+		method:         method,
+	}
+}
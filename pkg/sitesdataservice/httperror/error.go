@@ -13,6 +13,8 @@ type Error struct {
 	Code    int
 	Message string
 	Log     string
+	// Err is the cause of the error, it is not shown to users
+	Err error
 }
 
 // Error returns a text message corresponding to the given error.
@@ -20,6 +22,11 @@ func (e *Error) Error() string {
 	return e.Log
 }
 
+// Unwrap returns the cause of the error, so it can be checked with errors.Is.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
 // StatusCode returns an HTTP status code corresponding to the given error.
 func (e *Error) StatusCode() int {
 	return e.Code
@@ -74,6 +81,17 @@ func NewErrorProcessor(defaultCode int, defaultMessage string) ErrorProcessor {
 // ErrorCreator ...
 type ErrorCreator func(status int, message string, log string) error
 
+// WithCause returns a copy of service error err with the given cause, other errors are returned as is.
+func WithCause(err error, cause error) error {
+	e, ok := err.(*Error)
+	if !ok {
+		return err
+	}
+	withCause := *e
+	withCause.Err = cause
+	return &withCause
+}
+
 // NewError ...
 func NewError(status int, message string, log string) error {
 	return &Error{
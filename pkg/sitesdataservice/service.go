@@ -9,4 +9,23 @@ import (
 // Service ...
 type Service interface {
 	GetDataFromURLs(ctx context.Context, urls []string) (response []*api.SiteData, err error)
+	GetDataFromURLsWithOptions(ctx context.Context, requests []*api.SiteRequest, options *api.FetchOptions) (response []*api.SiteData, err error)
+	// StreamDataFromURLs passes result for every url to handle as soon as it is ready
+	StreamDataFromURLs(ctx context.Context, requests []*api.SiteRequest, options *api.FetchOptions, handle func(siteData *api.SiteData) error) (err error)
+	// CreateJob creates asynchronous batch job, results are available through GetJob
+	CreateJob(ctx context.Context, request *api.CreateJobRequest) (job *api.Job, err error)
+	GetJob(ctx context.Context, id string, offset int, limit int) (response *api.GetJobResponse, err error)
+	CancelJob(ctx context.Context, id string) (job *api.Job, err error)
+	GetFailedWebhookDeliveries(ctx context.Context, offset int, limit int) (deliveries []*api.WebhookDelivery, err error)
+	ReplayWebhookDelivery(ctx context.Context, id string) (delivery *api.WebhookDelivery, err error)
+	// GetSnapshots returns a page of stored history of the url, GetSnapshot returns one snapshot with data
+	GetSnapshots(ctx context.Context, request *api.GetSnapshotsRequest) (response *api.GetSnapshotsResponse, err error)
+	GetSnapshot(ctx context.Context, id string) (snapshot *api.Snapshot, err error)
+	// GetSnapshotsDiff compares data of two snapshots
+	GetSnapshotsDiff(ctx context.Context, request *api.GetSnapshotsDiffRequest) (diff *api.SnapshotsDiff, err error)
+}
+
+// ServiceV2 is the v2 api with request and response envelopes
+type ServiceV2 interface {
+	GetDataFromURLs(ctx context.Context, request *api.GetDataFromURLsRequest) (response *api.GetDataFromURLsResponse, err error)
 }